@@ -1,39 +1,31 @@
 package main
 
 import (
-	"bytes"
 	"crypto/sha256"
 	"database/sql"
-	"encoding/json"
 	"errors"
-	"fmt"
-	"io"
 	"log/slog"
 	"net/http"
 	"strings"
 	"time"
 
-	"slices"
-
 	db "github.com/Hopertz/rent/db/sqlc"
+	mailer "github.com/Hopertz/rent/pkg/Mailer"
+	"github.com/Hopertz/rent/pkg/templates"
+	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 )
 
-type SignupData struct {
-	ID    string `json:"id"`
-	Email string `json:"email"`
-	Token string `json:"token"`
-}
-
-type ResetCompleteData struct {
-	Email string `json:"email"`
-}
-
+// registerAdminHandler redeems an invite. The invite proves ownership of the
+// email address, so the admin is created already activated with the role the
+// super user chose.
 func (app *application) registerAdminHandler(c echo.Context) error {
 
 	var input struct {
-		Email    string `json:"email" validate:"required,email"`
-		Password string `json:"password" validate:"required,min=8"`
+		TokenPlaintext    string `json:"token" validate:"required,len=26"`
+		Email             string `json:"email" validate:"required,email"`
+		Password          string `json:"password" validate:"required"`
+		PreferredLanguage string `json:"preferred_language"`
 	}
 
 	if err := c.Bind(&input); err != nil {
@@ -44,12 +36,35 @@ func (app *application) registerAdminHandler(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, envelope{"error": err.Error()})
 	}
 
-	emails := strings.Split(app.config.emails, ",")
+	language, ok := app.readLanguage(input.PreferredLanguage)
+
+	if !ok {
+		return app.unsupportedLanguage(c)
+	}
+
+	tokenHash := sha256.Sum256([]byte(input.TokenPlaintext))
 
-	found := slices.Contains(emails, input.Email)
+	invite, err := app.store.GetPendingInviteByHash(c.Request().Context(), db.GetPendingInviteByHashParams{
+		Hash:   tokenHash[:],
+		Expiry: time.Now(),
+	})
 
-	if !found {
-		return c.JSON(http.StatusUnauthorized, envelope{"error": "email not allowed"})
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return c.JSON(http.StatusNotFound, envelope{"error": "invalid or expired invite"})
+		default:
+			slog.Error("error fetching invite", "error", err)
+			return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+		}
+	}
+
+	if !strings.EqualFold(invite.Email, input.Email) {
+		return c.JSON(http.StatusUnauthorized, envelope{"error": "email does not match invite"})
+	}
+
+	if err := app.passwordPolicy().Check(input.Password); err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": err.Error()})
 	}
 
 	pwd, err := db.SetPassword(input.Password)
@@ -60,16 +75,29 @@ func (app *application) registerAdminHandler(c echo.Context) error {
 	}
 
 	args := db.CreateAdminParams{
-		Email:        input.Email,
-		PasswordHash: pwd.Hash,
-		Activated:    false,
+		Email:             invite.Email,
+		PasswordHash:      pwd.Hash,
+		Activated:         true,
+		Role:              invite.Role,
+		PreferredLanguage: language,
 	}
 
-	a, err := app.store.CreateAdmin(c.Request().Context(), args)
+	_, err = app.store.TxnAcceptInvite(c.Request().Context(), invite.ID, args, func(created db.CreateAdminRow) db.AuditEntry {
+		return auditEntry(c, db.AuditEntry{
+			Action:     auditRegister,
+			EntityType: auditAdmin,
+			Snapshot:   adminSnapshot,
+			AdminID:    uuid.NullUUID{UUID: created.ID, Valid: true},
+			AdminEmail: invite.Email,
+		})
+	})
 
 	if err != nil {
 		switch {
 
+		case errors.Is(err, db.ErrInviteUsed):
+			return c.JSON(http.StatusNotFound, envelope{"error": "invalid or expired invite"})
+
 		case err.Error() == db.DuplicateEmail:
 			return c.JSON(http.StatusBadRequest, envelope{"error": "email is already in use"})
 
@@ -80,57 +108,6 @@ func (app *application) registerAdminHandler(c echo.Context) error {
 
 	}
 
-	expiry := time.Now().Add(3 * 24 * time.Hour)
-
-	token, err := app.store.NewToken(a.ID, expiry, db.ScopeActivation)
-	if err != nil {
-		slog.Error("error generating new token", "error", err)
-		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
-	}
-
-	app.background(func() {
-
-		dt := SignupData{
-			ID:    a.ID.String(),
-			Email: args.Email,
-			Token: token.Plaintext,
-		}
-
-		jsonData, err := json.Marshal(dt)
-		if err != nil {
-			slog.Error("Error marshaling JSON", "Error", err)
-		}
-
-		client := &http.Client{
-			Timeout: 10 * time.Second,
-		}
-
-		req, err := http.NewRequest("POST", fmt.Sprintf("%s/signup", app.config.mailer_url), bytes.NewBuffer(jsonData))
-		if err != nil {
-			slog.Error("Error creating request", "Error", err)
-		}
-
-		req.Header.Set("Content-Type", "application/json")
-
-		resp, err := client.Do(req)
-		if err != nil {
-			slog.Error("Error sending request", "Error", err)
-		}
-		defer resp.Body.Close()
-
-		respBody, err := io.ReadAll(resp.Body)
-		if err != nil {
-			slog.Error("Error reading response", "Error", err)
-		}
-
-		if resp.StatusCode != http.StatusOK {
-			slog.Error(fmt.Sprintf("Request failed with status code %d: %s", resp.StatusCode, string(respBody)))
-		}
-
-		slog.Info(fmt.Sprintf("Email sent successfully to %s\n", args.Email))
-
-	})
-
 	return c.JSON(http.StatusCreated, nil)
 }
 
@@ -182,7 +159,20 @@ func (app *application) activateAdminHandler(c echo.Context) error {
 		PasswordHash: admin.PasswordHash,
 		Version:      admin.Version,
 	}
-	_, err = app.store.UpdateAdmin(c.Request().Context(), param)
+
+	entry := auditEntry(c, db.AuditEntry{
+		Action:     auditActivate,
+		EntityType: auditAdmin,
+		EntityID:   admin.ID,
+		Snapshot:   adminSnapshot,
+		AdminID:    uuid.NullUUID{UUID: admin.ID, Valid: true},
+		AdminEmail: admin.Email,
+	})
+
+	_, err = app.store.TxnAudit(c.Request().Context(), entry, func(q *db.Queries) (uuid.UUID, error) {
+		_, err := q.UpdateAdmin(c.Request().Context(), param)
+		return uuid.Nil, err
+	})
 
 	if err != nil {
 		switch {
@@ -202,7 +192,7 @@ func (app *application) activateAdminHandler(c echo.Context) error {
 func (app *application) updateAdminPasswordOnResetHandler(c echo.Context) error {
 
 	var input struct {
-		Password       string `json:"password" validate:"required,min=8"`
+		Password       string `json:"password" validate:"required"`
 		TokenPlaintext string `json:"token" validate:"required,len=26"`
 	}
 
@@ -235,18 +225,49 @@ func (app *application) updateAdminPasswordOnResetHandler(c echo.Context) error
 		}
 	}
 
+	if err := app.passwordPolicy().Check(input.Password); err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": err.Error()})
+	}
+
+	reused, err := app.passwordReused(c.Request().Context(), admin.ID, admin.PasswordHash, input.Password)
+
+	if err != nil {
+		slog.Error("error checking password history", "error", err)
+		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+	}
+
+	if reused {
+		return c.JSON(http.StatusBadRequest, envelope{"error": "password was used recently, choose a different one"})
+	}
+
 	pwd, err := db.SetPassword(input.Password)
 
 	if err != nil {
 		return err
 	}
 
-	_, err = app.store.UpdateAdmin(c.Request().Context(), db.UpdateAdminParams{
+	notice := mailer.PasswordResetCompleteData{Email: admin.Email}
+	notice.Content = app.mailContent(admin.PreferredLanguage, templates.PasswordResetComplete, notice)
+
+	// whoever asked for the reset may not be the only one holding the old
+	// password, so every session goes
+	err = app.store.TxnChangePassword(c.Request().Context(), db.UpdateAdminParams{
 		Email:        admin.Email,
 		PasswordHash: pwd.Hash,
 		Activated:    true,
 		ID:           admin.ID,
 		Version:      admin.Version,
+	}, admin.PasswordHash, uuid.NullUUID{}, auditEntry(c, db.AuditEntry{
+		Action:     auditPassword,
+		EntityType: auditAdmin,
+		EntityID:   admin.ID,
+		Snapshot:   adminSnapshot,
+		AdminID:    uuid.NullUUID{UUID: admin.ID, Valid: true},
+		AdminEmail: admin.Email,
+	}), db.OutboxMessage{
+		Kind:      mailer.PathPasswordResetComplete,
+		Recipient: admin.Email,
+		Payload:   notice,
 	})
 
 	if err != nil {
@@ -259,56 +280,88 @@ func (app *application) updateAdminPasswordOnResetHandler(c echo.Context) error
 		}
 	}
 
-	d := db.DeleteAllTokenParams{
-		Scope: db.ScopePasswordReset,
-		ID:    admin.ID,
+	return c.JSON(http.StatusOK, nil)
+}
+
+func (app *application) listAdminsHandler(c echo.Context) error {
+
+	admins, err := app.store.GetAdmins(c.Request().Context())
+
+	if err != nil {
+		slog.Error("error fetching admins", "error", err)
+		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
 	}
-	err = app.store.DeleteAllToken(c.Request().Context(), d)
+
+	return c.JSON(http.StatusOK, admins)
+}
+
+func (app *application) updateAdminRoleHandler(c echo.Context) error {
+
+	id, err := db.ReadUUIDParam(c)
 
 	if err != nil {
-		slog.Error("error deleting reset password token for user admin", "error", err)
+		return c.JSON(http.StatusBadRequest, envelope{"error": "invalid admin id"})
 	}
 
-	app.background(func() {
+	var input struct {
+		Role string `json:"role" validate:"required"`
+	}
 
-		dt := ResetCompleteData{
-			Email: admin.Email,
-		}
+	if err := c.Bind(&input); err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": err.Error()})
+	}
 
-		jsonData, err := json.Marshal(dt)
-		if err != nil {
-			slog.Error("Error marshaling JSON", "Error", err)
-		}
+	if err := app.validator.Struct(input); err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": err.Error()})
+	}
 
-		client := &http.Client{
-			Timeout: 10 * time.Second,
-		}
+	if !validRole(input.Role) {
+		return c.JSON(http.StatusBadRequest, envelope{"error": "role must be one of owner, manager, accountant or caretaker"})
+	}
 
-		req, err := http.NewRequest("POST", fmt.Sprintf("%s/completedpwdreset", app.config.mailer_url), bytes.NewBuffer(jsonData))
-		if err != nil {
-			slog.Error("Error creating request", "Error", err)
+	admin, err := app.store.GetAdminById(c.Request().Context(), id)
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return c.JSON(http.StatusNotFound, envelope{"error": "admin not found"})
+		default:
+			slog.Error("error fetching admin by id", "error", err)
+			return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
 		}
+	}
 
-		req.Header.Set("Content-Type", "application/json")
+	entry := auditEntry(c, db.AuditEntry{
+		Action:     auditRole,
+		EntityType: auditAdmin,
+		EntityID:   admin.ID,
+		Snapshot:   adminSnapshot,
+	})
 
-		resp, err := client.Do(req)
-		if err != nil {
-			slog.Error("Error sending request", "Error", err)
-		}
-		defer resp.Body.Close()
+	_, err = app.store.TxnAudit(c.Request().Context(), entry, func(q *db.Queries) (uuid.UUID, error) {
 
-		respBody, err := io.ReadAll(resp.Body)
-		if err != nil {
-			slog.Error("Error reading response", "Error", err)
-		}
+		n, err := q.UpdateAdminRole(c.Request().Context(), db.UpdateAdminRoleParams{
+			Role:    input.Role,
+			ID:      admin.ID,
+			Version: admin.Version,
+		})
 
-		if resp.StatusCode != http.StatusOK {
-			slog.Error(fmt.Sprintf("Request failed with status code %d: %s", resp.StatusCode, string(respBody)))
+		if err == nil && n == 0 {
+			err = db.ErrEditConflict
 		}
 
-		slog.Info(fmt.Sprintf("Email sent successfully to %s", admin.Email))
-
+		return uuid.Nil, err
 	})
 
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrEditConflict):
+			return c.JSON(http.StatusConflict, envelope{"error": "unable to complete request due to an edit conflict"})
+		default:
+			slog.Error("error updating admin role", "error", err)
+			return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+		}
+	}
+
 	return c.JSON(http.StatusOK, nil)
 }
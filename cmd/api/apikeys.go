@@ -0,0 +1,307 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base32"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/netip"
+	"strings"
+	"time"
+
+	db "github.com/Hopertz/rent/db/sqlc"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	headerAPIKey = "X-API-Key"
+
+	// keys look like rk_ followed by 32 base32 characters; the prefix makes
+	// them easy to spot in logs and secret scanners
+	apiKeyPrefix     = "rk_"
+	apiKeyLength     = len(apiKeyPrefix) + 32
+	apiKeyUsageLimit = 100
+)
+
+type FormattedApiKey struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	AllowedIps []string   `json:"allowed_ips"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
+
+// authenticateAPIKey is the X-API-Key branch of authenticate. The owning
+// admin is put in the context as for a bearer token and the key alongside
+// it, and every request made with the key is logged against it.
+func (app *application) authenticateAPIKey(c echo.Context, plaintext string, next echo.HandlerFunc) error {
+
+	if len(plaintext) != apiKeyLength || !strings.HasPrefix(plaintext, apiKeyPrefix) {
+		return c.JSON(http.StatusUnauthorized, envelope{"error": "invalid api key"})
+	}
+
+	hash := sha256.Sum256([]byte(plaintext))
+
+	key, err := app.store.GetApiKeyByHash(c.Request().Context(), db.GetApiKeyByHashParams{
+		Hash:      hash[:],
+		ExpiresAt: time.Now(),
+	})
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return c.JSON(http.StatusUnauthorized, envelope{"error": "invalid api key"})
+		default:
+			slog.Error("error fetching api key", "error", err)
+			return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+		}
+	}
+
+	// the address comes from the connection or from trusted proxies only,
+	// see ipExtractor, so a caller cannot claim an allowed one
+	ip := c.RealIP()
+
+	if !ipAllowed(key.AllowedIps, ip) {
+		slog.Warn("api key used from address outside its allow-list", "api_key_id", key.ID, "ip", ip)
+		return c.JSON(http.StatusForbidden, envelope{"error": "api key not allowed from this address"})
+	}
+
+	c.Set("admin", db.GetHashTokenForAdminRow{
+		ID:           key.AdminID,
+		CreatedAt:    key.CreatedAt,
+		Email:        key.Email,
+		PasswordHash: key.PasswordHash,
+		Version:      key.Version,
+		Activated:    key.Activated,
+		IsSuperUser:  key.IsSuperUser,
+		Role:         key.Role,
+	})
+	c.Set("api_key", key)
+
+	err = next(c)
+
+	status := c.Response().Status
+	var he *echo.HTTPError
+	if errors.As(err, &he) {
+		status = he.Code
+	}
+
+	usage := app.store.RecordApiKeyUsage(c.Request().Context(), db.RecordApiKeyUsageParams{
+		ApiKeyID: key.ID,
+		Method:   c.Request().Method,
+		Path:     c.Request().URL.Path,
+		Ip:       ip,
+		Status:   int32(status),
+	})
+
+	if usage != nil {
+		slog.Error("error recording api key usage", "error", usage)
+	}
+
+	return err
+}
+
+// ipAllowed reports whether ip matches one of the addresses or CIDR ranges
+// in allowed. An empty list allows every address. An IPv4 address reaching a
+// dual stack listener as ::ffff:a.b.c.d is matched as a.b.c.d.
+func ipAllowed(allowed []string, ip string) bool {
+
+	if len(allowed) == 0 {
+		return true
+	}
+
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+
+	addr = addr.Unmap()
+
+	for _, a := range allowed {
+		if prefix, err := netip.ParsePrefix(a); err == nil && prefix.Contains(addr) {
+			return true
+		}
+		if other, err := netip.ParseAddr(a); err == nil && other == addr {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (app *application) listApiKeysHandler(c echo.Context) error {
+
+	admin := c.Get("admin").(db.GetHashTokenForAdminRow)
+
+	keys, err := app.store.GetApiKeysForAdmin(c.Request().Context(), admin.ID)
+
+	if err != nil {
+		slog.Error("error fetching api keys", "error", err)
+		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+	}
+
+	data := make([]FormattedApiKey, 0, len(keys))
+
+	for _, k := range keys {
+
+		f := FormattedApiKey{
+			ID:         k.ID.String(),
+			Name:       k.Name,
+			Prefix:     k.Prefix,
+			Scopes:     k.Scopes,
+			AllowedIps: k.AllowedIps,
+			CreatedAt:  k.CreatedAt,
+		}
+
+		if k.ExpiresAt.Valid {
+			f.ExpiresAt = &k.ExpiresAt.Time
+		}
+		if k.LastUsedAt.Valid {
+			f.LastUsedAt = &k.LastUsedAt.Time
+		}
+		if k.RevokedAt.Valid {
+			f.RevokedAt = &k.RevokedAt.Time
+		}
+
+		data = append(data, f)
+	}
+
+	return c.JSON(http.StatusOK, data)
+}
+
+// createApiKeyHandler issues a key for the calling admin. A key can never
+// hold a permission its owner lacks; the plaintext is returned only once.
+func (app *application) createApiKeyHandler(c echo.Context) error {
+
+	admin := c.Get("admin").(db.GetHashTokenForAdminRow)
+
+	var input struct {
+		Name       string     `json:"name" validate:"required,max=100"`
+		Scopes     []string   `json:"scopes" validate:"required,min=1"`
+		AllowedIps []string   `json:"allowed_ips"`
+		ExpiresAt  *time.Time `json:"expires_at"`
+	}
+
+	if err := c.Bind(&input); err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": err.Error()})
+	}
+
+	if err := app.validator.Struct(input); err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": err.Error()})
+	}
+
+	for _, scope := range input.Scopes {
+		if !validPermission(scope) {
+			return c.JSON(http.StatusBadRequest, envelope{"error": "unknown scope " + scope})
+		}
+		if !hasPermission(admin, scope) {
+			return c.JSON(http.StatusForbidden, envelope{"error": "your role does not grant scope " + scope})
+		}
+	}
+
+	allowed := []string{}
+
+	for _, a := range input.AllowedIps {
+		a = strings.TrimSpace(a)
+		_, perr := netip.ParsePrefix(a)
+		_, aerr := netip.ParseAddr(a)
+		if perr != nil && aerr != nil {
+			return c.JSON(http.StatusBadRequest, envelope{"error": "invalid address or range " + a})
+		}
+		allowed = append(allowed, a)
+	}
+
+	var expiresAt sql.NullTime
+
+	if input.ExpiresAt != nil {
+		if !input.ExpiresAt.After(time.Now()) {
+			return c.JSON(http.StatusBadRequest, envelope{"error": "expires_at must be in the future"})
+		}
+		expiresAt = sql.NullTime{Time: *input.ExpiresAt, Valid: true}
+	}
+
+	randomBytes := make([]byte, 20)
+
+	if _, err := rand.Read(randomBytes); err != nil {
+		slog.Error("error generating api key", "error", err)
+		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+	}
+
+	plaintext := apiKeyPrefix + base32.StdEncoding.EncodeToString(randomBytes)
+	hash := sha256.Sum256([]byte(plaintext))
+
+	key, err := app.store.CreateApiKey(c.Request().Context(), db.CreateApiKeyParams{
+		AdminID:    admin.ID,
+		Name:       strings.TrimSpace(input.Name),
+		Prefix:     plaintext[:len(apiKeyPrefix)+6],
+		Hash:       hash[:],
+		Scopes:     input.Scopes,
+		AllowedIps: allowed,
+		ExpiresAt:  expiresAt,
+	})
+
+	if err != nil {
+		slog.Error("error creating api key", "error", err)
+		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+	}
+
+	return c.JSON(http.StatusCreated, envelope{"id": key.ID, "key": plaintext, "created_at": key.CreatedAt})
+}
+
+func (app *application) revokeApiKeyHandler(c echo.Context) error {
+
+	admin := c.Get("admin").(db.GetHashTokenForAdminRow)
+
+	id, err := db.ReadUUIDParam(c)
+
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": "invalid api key id"})
+	}
+
+	n, err := app.store.RevokeApiKey(c.Request().Context(), db.RevokeApiKeyParams{
+		ID:      id,
+		AdminID: admin.ID,
+	})
+
+	if err != nil {
+		slog.Error("error revoking api key", "error", err)
+		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+	}
+
+	if n == 0 {
+		return c.JSON(http.StatusNotFound, envelope{"error": "api key not found"})
+	}
+
+	return c.JSON(http.StatusOK, nil)
+}
+
+func (app *application) listApiKeyUsageHandler(c echo.Context) error {
+
+	admin := c.Get("admin").(db.GetHashTokenForAdminRow)
+
+	id, err := db.ReadUUIDParam(c)
+
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": "invalid api key id"})
+	}
+
+	usage, err := app.store.GetApiKeyUsage(c.Request().Context(), db.GetApiKeyUsageParams{
+		ApiKeyID: id,
+		AdminID:  admin.ID,
+		Limit:    apiKeyUsageLimit,
+	})
+
+	if err != nil {
+		slog.Error("error fetching api key usage", "error", err)
+		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+	}
+
+	return c.JSON(http.StatusOK, usage)
+}
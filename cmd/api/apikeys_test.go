@@ -0,0 +1,46 @@
+package main
+
+import "testing"
+
+func TestIPAllowed(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		allowed []string
+		ip      string
+		want    bool
+	}{
+		{"empty list allows IPv4", nil, "196.41.32.7", true},
+		{"empty list allows IPv6", []string{}, "2001:db8::1", true},
+
+		{"IPv4 single address", []string{"196.41.32.7"}, "196.41.32.7", true},
+		{"IPv4 other address", []string{"196.41.32.7"}, "196.41.32.8", false},
+		{"IPv4 in range", []string{"10.0.0.0/8"}, "10.20.30.40", true},
+		{"IPv4 outside range", []string{"10.0.0.0/8"}, "11.0.0.1", false},
+		{"IPv4 single address as /32", []string{"196.41.32.7/32"}, "196.41.32.7", true},
+		{"IPv4 next to a /32", []string{"196.41.32.7/32"}, "196.41.32.6", false},
+		{"IPv4 mapped into IPv6", []string{"10.0.0.0/8"}, "::ffff:10.1.2.3", true},
+		{"IPv4 mapped single address", []string{"196.41.32.7"}, "::ffff:196.41.32.7", true},
+
+		{"IPv6 single address", []string{"2001:db8::1"}, "2001:db8::1", true},
+		{"IPv6 written differently", []string{"2001:db8::1"}, "2001:0db8:0:0:0:0:0:1", true},
+		{"IPv6 other address", []string{"2001:db8::1"}, "2001:db8::2", false},
+		{"IPv6 in range", []string{"2001:db8::/32"}, "2001:db8:abcd::1", true},
+		{"IPv6 outside range", []string{"2001:db8::/32"}, "2001:db9::1", false},
+		{"IPv6 does not match an IPv4 range", []string{"0.0.0.0/0"}, "2001:db8::1", false},
+		{"IPv4 does not match an IPv6 range", []string{"::/0"}, "196.41.32.7", false},
+
+		{"second entry matches", []string{"10.0.0.0/8", "196.41.32.7"}, "196.41.32.7", true},
+		{"malformed entries are skipped", []string{"not an ip", "10.0.0.0/33", "196.41.32.7"}, "196.41.32.7", true},
+		{"only malformed entries allow nothing", []string{"not an ip", "10.0.0.0/33", ""}, "10.0.0.1", false},
+		{"malformed address", []string{"10.0.0.0/8"}, "10.0.0", false},
+		{"empty address", []string{"10.0.0.0/8"}, "", false},
+		{"address with a port", []string{"196.41.32.7"}, "196.41.32.7:443", false},
+	}
+
+	for _, tt := range tests {
+		if got := ipAllowed(tt.allowed, tt.ip); got != tt.want {
+			t.Errorf("%s: ipAllowed(%q, %q) = %t, want %t", tt.name, tt.allowed, tt.ip, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	db "github.com/Hopertz/rent/db/sqlc"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	auditCreate         = "create"
+	auditUpdate         = "update"
+	auditDelete         = "delete"
+	auditArchive        = "archive"
+	auditUnarchive      = "unarchive"
+	auditImport         = "import"
+	auditRegister       = "register"
+	auditActivate       = "activate"
+	auditPassword       = "password_reset"
+	auditPasswordChange = "password_change"
+	auditRole           = "role_change"
+
+	auditHouse   = "house"
+	auditTenant  = "tenant"
+	auditPayment = "payment"
+	auditAdmin   = "admin"
+)
+
+// auditedAdmin is what the log keeps of an admin; the password hash never goes
+// in it.
+type auditedAdmin struct {
+	ID          uuid.UUID `json:"id"`
+	Email       string    `json:"email"`
+	Activated   bool      `json:"activated"`
+	IsSuperUser bool      `json:"is_super_user"`
+	Role        string    `json:"role"`
+}
+
+// auditEntry fills in who made the change entry records from the request. The
+// actor is the signed in admin unless entry names one, which flows without a
+// signed in admin (registration, activation, password reset) have to do.
+func auditEntry(c echo.Context, entry db.AuditEntry) db.AuditEntry {
+
+	entry.Ip = c.RealIP()
+
+	if admin, ok := c.Get("admin").(db.GetHashTokenForAdminRow); ok && !entry.AdminID.Valid {
+		entry.AdminID = uuid.NullUUID{UUID: admin.ID, Valid: true}
+		entry.AdminEmail = admin.Email
+	}
+
+	if org, ok := c.Get("organization").(db.Organization); ok {
+		entry.OrganizationID = uuid.NullUUID{UUID: org.ID, Valid: true}
+	}
+
+	if key, ok := c.Get("api_key").(db.GetApiKeyByHashRow); ok {
+		entry.ApiKeyID = uuid.NullUUID{UUID: key.ID, Valid: true}
+	}
+
+	return entry
+}
+
+// houseSnapshot reads houses of organization orgID for the audit log.
+func houseSnapshot(orgID uuid.UUID) db.Snapshot {
+
+	return func(ctx context.Context, q *db.Queries, id uuid.UUID) (any, error) {
+
+		err := q.LockHouse(ctx, db.LockHouseParams{ID: id, OrganizationID: orgID})
+
+		if err != nil {
+			return nil, err
+		}
+
+		houses, err := q.GetHousesByIds(ctx, db.GetHousesByIdsParams{
+			Ids:            []uuid.UUID{id},
+			OrganizationID: orgID,
+		})
+
+		if err != nil || len(houses) == 0 {
+			return nil, err
+		}
+
+		return houses[0], nil
+	}
+}
+
+// tenantSnapshot reads tenants of organization orgID for the audit log.
+func tenantSnapshot(orgID uuid.UUID) db.Snapshot {
+
+	return func(ctx context.Context, q *db.Queries, id uuid.UUID) (any, error) {
+
+		err := q.LockTenant(ctx, db.LockTenantParams{ID: id, OrganizationID: orgID})
+
+		if err != nil {
+			return nil, err
+		}
+
+		tenant, err := q.GetTenantById(ctx, db.GetTenantByIdParams{
+			ID:             id,
+			OrganizationID: orgID,
+		})
+
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, nil
+			}
+			return nil, err
+		}
+
+		return tenant, nil
+	}
+}
+
+// paymentSnapshot reads payments of organization orgID for the audit log.
+func paymentSnapshot(orgID uuid.UUID) db.Snapshot {
+
+	return func(ctx context.Context, q *db.Queries, id uuid.UUID) (any, error) {
+
+		err := q.LockPayment(ctx, db.LockPaymentParams{ID: id, OrganizationID: orgID})
+
+		if err != nil {
+			return nil, err
+		}
+
+		payment, err := q.GetPaymentById(ctx, db.GetPaymentByIdParams{
+			ID:             id,
+			OrganizationID: orgID,
+		})
+
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, nil
+			}
+			return nil, err
+		}
+
+		return payment, nil
+	}
+}
+
+// adminSnapshot reads an admin for the audit log.
+func adminSnapshot(ctx context.Context, q *db.Queries, id uuid.UUID) (any, error) {
+
+	if err := q.LockAdmin(ctx, id); err != nil {
+		return nil, err
+	}
+
+	admin, err := q.GetAdminById(ctx, id)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return auditedAdmin{
+		ID:          admin.ID,
+		Email:       admin.Email,
+		Activated:   admin.Activated,
+		IsSuperUser: admin.IsSuperUser,
+		Role:        admin.Role,
+	}, nil
+}
+
+// listAuditLogHandler pages through the log newest first. It can be narrowed
+// to one entity, one admin and a time range; X-Next-Cursor carries on from
+// the last entry returned.
+func (app *application) listAuditLogHandler(c echo.Context) error {
+
+	admin := c.Get("admin").(db.GetHashTokenForAdminRow)
+
+	args := db.GetAuditLogParams{
+		OrganizationID: uuid.NullUUID{UUID: organizationID(c), Valid: true},
+		IncludeGlobal:  admin.IsSuperUser,
+	}
+
+	if v := c.QueryParam("entity_type"); v != "" {
+		args.EntityType = sql.NullString{String: v, Valid: true}
+	}
+
+	for param, dst := range map[string]*uuid.NullUUID{
+		"entity_id": &args.EntityID,
+		"admin_id":  &args.AdminID,
+	} {
+		v := c.QueryParam(param)
+		if v == "" {
+			continue
+		}
+		id, err := uuid.Parse(v)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, envelope{"error": "invalid " + param})
+		}
+		*dst = uuid.NullUUID{UUID: id, Valid: true}
+	}
+
+	for param, dst := range map[string]*sql.NullTime{
+		"from": &args.From,
+		"to":   &args.To,
+	} {
+		v := c.QueryParam(param)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, envelope{"error": param + " must be an RFC 3339 time"})
+		}
+		*dst = sql.NullTime{Time: t, Valid: true}
+	}
+
+	if cursor := c.QueryParam("cursor"); cursor != "" {
+		id, err := strconv.ParseInt(cursor, 10, 64)
+		if err != nil || id < 1 {
+			return c.JSON(http.StatusBadRequest, envelope{"error": "invalid cursor"})
+		}
+		args.BeforeID = sql.NullInt64{Int64: id, Valid: true}
+	}
+
+	limit, err := readLimit(c)
+
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": err.Error()})
+	}
+
+	args.Limit = int32(limit) + 1
+
+	entries, err := app.store.GetAuditLog(c.Request().Context(), args)
+
+	if err != nil {
+		slog.Error("error fetching audit log", "error", err)
+		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+	}
+
+	if len(entries) > limit {
+		entries = entries[:limit]
+		c.Response().Header().Set(headerNextCursor, strconv.FormatInt(entries[limit-1].ID, 10))
+	}
+
+	return c.JSON(http.StatusOK, entries)
+}
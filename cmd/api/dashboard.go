@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	db "github.com/Hopertz/rent/db/sqlc"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	defaultEosDays = 14
+	maxEosDays     = 90
+)
+
+// dashboard is what needs attention in an organization: the payments of the
+// last 24 hours, stays ending soon, tenants in arrears and houses left vacant
+// in the last 24 hours. A section the viewer may not see is nil.
+type dashboard struct {
+	Payments      []db.GetRecentPaymentsRow   `json:"payments"`
+	PaymentsTotal int64                       `json:"payments_total"`
+	Ending        []db.GetTenantsEndingRow    `json:"ending"`
+	Arrears       []db.GetTenantsInArrearsRow `json:"arrears"`
+	Vacated       []db.GetVacatedHousesRow    `json:"vacated"`
+}
+
+func (d dashboard) empty() bool {
+	return len(d.Payments) == 0 && len(d.Ending) == 0 && len(d.Arrears) == 0 && len(d.Vacated) == 0
+}
+
+// today is the local date at now, as the database stores dates.
+func (app *application) today(now time.Time) time.Time {
+	local := now.In(app.location)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// dashboard gathers an organization's dashboard at now, with the sections
+// can allows and stays ending in the next eosDays days. The API and the daily
+// digest both use it, so that they agree.
+func (app *application) dashboard(ctx context.Context, orgID uuid.UUID, can func(permission string) bool, now time.Time, eosDays int) (dashboard, error) {
+
+	var (
+		d     dashboard
+		err   error
+		since = now.Add(-24 * time.Hour)
+		today = app.today(now)
+	)
+
+	if can(PermPaymentsRead) {
+
+		d.Payments, err = app.store.GetRecentPayments(ctx, db.GetRecentPaymentsParams{
+			OrganizationID: orgID,
+			Since:          since,
+		})
+
+		if err != nil {
+			return d, fmt.Errorf("recent payments: %w", err)
+		}
+
+		for _, p := range d.Payments {
+			d.PaymentsTotal += int64(p.Amount)
+		}
+	}
+
+	if can(PermTenantsRead) {
+
+		d.Ending, err = app.store.GetTenantsEnding(ctx, db.GetTenantsEndingParams{
+			OrganizationID: orgID,
+			FromDate:       today,
+			ToDate:         today.AddDate(0, 0, eosDays),
+		})
+
+		if err != nil {
+			return d, fmt.Errorf("tenants ending: %w", err)
+		}
+
+		d.Arrears, err = app.store.GetTenantsInArrears(ctx, db.GetTenantsInArrearsParams{
+			OrganizationID: orgID,
+			AsOf:           today,
+		})
+
+		if err != nil {
+			return d, fmt.Errorf("tenants in arrears: %w", err)
+		}
+	}
+
+	if can(PermHousesRead) {
+
+		d.Vacated, err = app.store.GetVacatedHouses(ctx, db.GetVacatedHousesParams{
+			OrganizationID: orgID,
+			Since:          sql.NullTime{Time: since, Valid: true},
+		})
+
+		if err != nil {
+			return d, fmt.Errorf("vacated houses: %w", err)
+		}
+	}
+
+	return d, nil
+}
+
+// showDashboardHandler returns the organization's dashboard, with stays ending
+// in the next eos_days days, 14 by default.
+func (app *application) showDashboardHandler(c echo.Context) error {
+
+	eosDays := defaultEosDays
+
+	if v := c.QueryParam("eos_days"); v != "" {
+
+		n, err := strconv.Atoi(v)
+
+		if err != nil || n < 1 || n > maxEosDays {
+			return c.JSON(http.StatusBadRequest, envelope{"error": fmt.Sprintf("eos_days must be between 1 and %d", maxEosDays)})
+		}
+
+		eosDays = n
+	}
+
+	d, err := app.dashboard(c.Request().Context(), organizationID(c), func(permission string) bool {
+		return permitted(c, permission)
+	}, time.Now(), eosDays)
+
+	if err != nil {
+		slog.Error("error fetching dashboard", "error", err)
+		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+	}
+
+	return c.JSON(http.StatusOK, d)
+}
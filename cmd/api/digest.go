@@ -0,0 +1,306 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	db "github.com/Hopertz/rent/db/sqlc"
+	mailer "github.com/Hopertz/rent/pkg/Mailer"
+	"github.com/Hopertz/rent/pkg/templates"
+	"github.com/labstack/echo/v4"
+)
+
+// digestView is what the daily digest template is rendered with.
+type digestView struct {
+	Date          time.Time
+	EosDays       int
+	Organizations []digestOrganization
+}
+
+type digestOrganization struct {
+	Name          string
+	Payments      []digestPayment
+	PaymentsTotal int64
+	Ending        []digestTenant
+	Arrears       []digestArrears
+	Vacated       []digestHouse
+}
+
+type digestPayment struct {
+	Tenant string
+	House  string
+	Amount int64
+}
+
+type digestTenant struct {
+	Name  string
+	House string
+	Eos   time.Time
+}
+
+// digestArrears is a tenant who has not paid since Since, Days ago.
+type digestArrears struct {
+	Name  string
+	House string
+	Since time.Time
+	Days  int
+}
+
+type digestHouse struct {
+	House     string
+	VacatedAt time.Time
+}
+
+// runDigestScheduler queues the daily digest of every admin whose send hour
+// has come, every digest-interval until ctx is cancelled.
+func (app *application) runDigestScheduler(ctx context.Context) {
+
+	if app.config.digest.interval <= 0 {
+		slog.Info("digest scheduler disabled")
+		return
+	}
+
+	ticker := time.NewTicker(app.config.digest.interval)
+	defer ticker.Stop()
+
+	for {
+		app.sendDigests(ctx, time.Now())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sendDigests queues today's digest for each admin who wants one and has not
+// had it yet. An admin with nothing to report in any of their organizations
+// gets no email, but their day is still recorded.
+func (app *application) sendDigests(ctx context.Context, now time.Time) {
+
+	today := app.today(now)
+
+	admins, err := app.store.GetDueDigests(ctx, db.GetDueDigestsParams{
+		Hour:  int16(now.In(app.location).Hour()),
+		Today: today,
+	})
+
+	if err != nil {
+		if !errors.Is(err, context.Canceled) {
+			slog.Error("error fetching due digests", "error", err)
+		}
+		return
+	}
+
+	var queued int
+
+	for _, a := range admins {
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		view, err := app.digest(ctx, a, now)
+
+		if err != nil {
+			slog.Error("error gathering digest", "error", err, "admin_id", a.ID)
+			continue
+		}
+
+		var messages []db.OutboxMessage
+
+		if len(view.Organizations) > 0 {
+
+			msg, err := app.templates.Render(a.PreferredLanguage, templates.DailyDigest, view)
+
+			if err != nil {
+				slog.Error("error rendering digest", "error", err, "admin_id", a.ID)
+				continue
+			}
+
+			messages = append(messages, db.OutboxMessage{
+				Kind:      mailer.PathNotice,
+				Recipient: a.Email,
+				Payload: mailer.NoticeData{
+					Email: a.Email,
+					Content: mailer.Content{
+						Locale:  msg.Locale,
+						Subject: msg.Subject,
+						Text:    msg.Text,
+						HTML:    msg.HTML,
+					},
+				},
+			})
+		}
+
+		created, err := app.store.TxnQueueDigest(ctx, db.MarkDigestSentParams{AdminID: a.ID, Today: today}, messages...)
+
+		if err != nil {
+			slog.Error("error queueing digest", "error", err, "admin_id", a.ID)
+			continue
+		}
+
+		if created && len(messages) > 0 {
+			queued++
+		}
+	}
+
+	if queued > 0 {
+		slog.Info("queued digests", "count", queued)
+	}
+}
+
+// digest gathers the dashboard of each of the admin's organizations that has
+// something to report, as the admin's role lets them see it.
+func (app *application) digest(ctx context.Context, a db.GetDueDigestsRow, now time.Time) (digestView, error) {
+
+	var (
+		orgs []db.Organization
+		err  error
+	)
+
+	if a.IsSuperUser {
+		orgs, err = app.store.GetOrganizations(ctx)
+	} else {
+		orgs, err = app.store.GetOrganizationsForAdmin(ctx, a.ID)
+	}
+
+	if err != nil {
+		return digestView{}, err
+	}
+
+	view := digestView{Date: app.today(now), EosDays: int(a.EosDays)}
+
+	can := func(permission string) bool {
+		return roleHasPermission(a.IsSuperUser, a.Role, permission)
+	}
+
+	for _, org := range orgs {
+
+		d, err := app.dashboard(ctx, org.ID, can, now, int(a.EosDays))
+
+		if err != nil {
+			return digestView{}, err
+		}
+
+		if d.empty() {
+			continue
+		}
+
+		o := digestOrganization{Name: org.Name, PaymentsTotal: d.PaymentsTotal}
+
+		for _, p := range d.Payments {
+			o.Payments = append(o.Payments, digestPayment{
+				Tenant: p.TenantName,
+				House:  houseName(p.Location, p.Block, p.Partition),
+				Amount: int64(p.Amount),
+			})
+		}
+
+		for _, t := range d.Ending {
+			o.Ending = append(o.Ending, digestTenant{
+				Name:  t.Name,
+				House: houseName(t.Location, t.Block, t.Partition),
+				Eos:   t.Eos,
+			})
+		}
+
+		for _, t := range d.Arrears {
+
+			since := t.Sos
+			if t.PaidThrough.Valid {
+				since = t.PaidThrough.Time.AddDate(0, 0, 1)
+			}
+
+			o.Arrears = append(o.Arrears, digestArrears{
+				Name:  t.Name,
+				House: houseName(t.Location, t.Block, t.Partition),
+				Since: since,
+				Days:  int(view.Date.Sub(since).Hours() / 24),
+			})
+		}
+
+		for _, h := range d.Vacated {
+			o.Vacated = append(o.Vacated, digestHouse{
+				House:     houseName(h.Location, h.Block, h.Partition),
+				VacatedAt: h.VacatedAt.Time.In(app.location),
+			})
+		}
+
+		view.Organizations = append(view.Organizations, o)
+	}
+
+	return view, nil
+}
+
+func (app *application) showMyDigestHandler(c echo.Context) error {
+
+	admin := c.Get("admin").(db.GetHashTokenForAdminRow)
+
+	settings, err := app.store.GetDigestSettings(c.Request().Context(), admin.ID)
+
+	if err != nil {
+		slog.Error("error fetching digest settings", "error", err)
+		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+	}
+
+	return c.JSON(http.StatusOK, settings)
+}
+
+// updateMyDigestHandler sets whether the signed in admin gets the daily
+// digest, from which local hour, and how far ahead it looks for stays ending.
+func (app *application) updateMyDigestHandler(c echo.Context) error {
+
+	admin := c.Get("admin").(db.GetHashTokenForAdminRow)
+
+	settings, err := app.store.GetDigestSettings(c.Request().Context(), admin.ID)
+
+	if err != nil {
+		slog.Error("error fetching digest settings", "error", err)
+		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+	}
+
+	var input struct {
+		Enabled  *bool  `json:"enabled"`
+		SendHour *int16 `json:"send_hour" validate:"omitempty,min=0,max=23"`
+		EosDays  *int16 `json:"eos_days" validate:"omitempty,min=1,max=90"`
+	}
+
+	if err := c.Bind(&input); err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": err.Error()})
+	}
+
+	if err := app.validator.Struct(input); err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": err.Error()})
+	}
+
+	if input.Enabled != nil {
+		settings.Enabled = *input.Enabled
+	}
+
+	if input.SendHour != nil {
+		settings.SendHour = *input.SendHour
+	}
+
+	if input.EosDays != nil {
+		settings.EosDays = *input.EosDays
+	}
+
+	err = app.store.UpsertDigestSettings(c.Request().Context(), db.UpsertDigestSettingsParams{
+		AdminID:  admin.ID,
+		Enabled:  settings.Enabled,
+		SendHour: settings.SendHour,
+		EosDays:  settings.EosDays,
+	})
+
+	if err != nil {
+		slog.Error("error saving digest settings", "error", err)
+		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+	}
+
+	return c.JSON(http.StatusOK, settings)
+}
@@ -0,0 +1,316 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	db "github.com/Hopertz/rent/db/sqlc"
+	"github.com/Hopertz/rent/pkg/xlsx"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	exportCSV  = "csv"
+	exportXLSX = "xlsx"
+
+	// exportFlushRows is how many rows are written between flushes to the
+	// client.
+	exportFlushRows = 500
+
+	// exportWriteTimeout replaces the server's write timeout for an export,
+	// which may take far longer than any other response to write.
+	exportWriteTimeout = 10 * time.Minute
+)
+
+// rowWriter writes an export in one of the formats it can be had in.
+type rowWriter interface {
+	WriteRow(cells ...any) error
+	Flush() error
+	Close() error
+}
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+// WriteRow writes cells as text. A text cell that a spreadsheet would take
+// for a formula, such as a tenant named "=HYPERLINK(...)", is quoted with a
+// leading apostrophe so it is shown as written instead of run.
+func (w csvWriter) WriteRow(cells ...any) error {
+
+	record := make([]string, len(cells))
+
+	for i, v := range cells {
+		switch v := v.(type) {
+		case nil:
+		case string:
+			record[i] = csvText(v)
+		default:
+			record[i] = fmt.Sprint(v)
+		}
+	}
+
+	return w.w.Write(record)
+}
+
+// csvNumber matches text a spreadsheet reads as a number even with a leading
+// sign, such as a phone number written +255 712 345 678, which needs no
+// quoting and would be spoilt by it.
+var csvNumber = regexp.MustCompile(`^[+-]?[0-9][0-9 .]*$`)
+
+// csvText neutralizes s if it starts with a character that makes a
+// spreadsheet read the cell as a formula.
+func csvText(s string) string {
+
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) && !csvNumber.MatchString(s) {
+		return "'" + s
+	}
+
+	return s
+}
+
+func (w csvWriter) Flush() error {
+	w.w.Flush()
+	return w.w.Error()
+}
+
+func (w csvWriter) Close() error {
+	return w.Flush()
+}
+
+// readExportFormat reads the format query parameter, csv by default.
+func readExportFormat(c echo.Context) (string, bool) {
+
+	switch format := c.QueryParam("format"); format {
+	case "":
+		return exportCSV, true
+	case exportCSV, exportXLSX:
+		return format, true
+	default:
+		return "", false
+	}
+}
+
+// export streams a file named after name and today's date in format, with a
+// row of column names followed by the rows each calls write with. Once the
+// first byte is sent the status can no longer change, so an error part way
+// through is logged and the file is left cut short.
+func (app *application) export(c echo.Context, name, format string, columns []string, each func(write func(cells ...any) error) error) error {
+
+	res := c.Response()
+
+	filename := fmt.Sprintf("%s-%s.%s", name, app.today(time.Now()).Format(time.DateOnly), format)
+
+	if err := http.NewResponseController(res).SetWriteDeadline(time.Now().Add(exportWriteTimeout)); err != nil {
+		slog.Warn("error extending export write deadline", "error", err)
+	}
+
+	contentType := "text/csv; charset=utf-8"
+	if format == exportXLSX {
+		contentType = xlsx.ContentType
+	}
+
+	res.Header().Set(echo.HeaderContentType, contentType)
+	res.Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", filename))
+	res.WriteHeader(http.StatusOK)
+
+	var (
+		w   rowWriter
+		err error
+	)
+
+	switch format {
+	case exportXLSX:
+		w, err = xlsx.NewWriter(res, name)
+	default:
+		w = csvWriter{csv.NewWriter(res)}
+	}
+
+	if err == nil {
+		header := make([]any, len(columns))
+		for i, col := range columns {
+			header[i] = col
+		}
+		err = w.WriteRow(header...)
+	}
+
+	if err == nil {
+
+		var n int
+
+		err = each(func(cells ...any) error {
+
+			if err := w.WriteRow(cells...); err != nil {
+				return err
+			}
+
+			if n++; n%exportFlushRows == 0 {
+				if err := w.Flush(); err != nil {
+					return err
+				}
+				res.Flush()
+			}
+
+			return nil
+		})
+	}
+
+	if err == nil {
+		err = w.Close()
+	}
+
+	if err != nil {
+		slog.Error("error exporting", "error", err, "export", name)
+	}
+
+	return nil
+}
+
+// exportHousesHandler exports the houses the houses list would show, given
+// the same filters, all on one page and with the tenant of each.
+func (app *application) exportHousesHandler(c echo.Context) error {
+
+	format, ok := readExportFormat(c)
+
+	if !ok {
+		return c.JSON(http.StatusBadRequest, envelope{"error": "format must be csv or xlsx"})
+	}
+
+	args, err := readHouseFilters(c)
+
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": err.Error()})
+	}
+
+	args.OrganizationID = uuid.NullUUID{UUID: organizationID(c), Valid: true}
+	args.WithTenant = true
+
+	columns := []string{"id", "location", "block", "partition", "house", "unit_type", "price", "status", "archived", "tenant_id", "tenant"}
+
+	return app.export(c, "houses", format, columns, func(write func(cells ...any) error) error {
+
+		return app.store.EachHouse(c.Request().Context(), args, func(h db.ListHousesRow) error {
+
+			status := "vacant"
+			if h.Occupied {
+				status = "occupied"
+			}
+
+			var tenantID, tenant any
+			if h.TenantID != nil {
+				tenantID, tenant = h.TenantID.String(), *h.TenantName
+			}
+
+			return write(
+				h.ID.String(),
+				h.Location,
+				h.Block,
+				h.Partition,
+				houseName(h.Location, h.Block, h.Partition),
+				h.UnitType,
+				h.Price,
+				status,
+				h.Archived,
+				tenantID,
+				tenant,
+			)
+		})
+	})
+}
+
+// exportTenantsHandler exports the organization's tenants with their houses,
+// with dates written in the locale given or the admin's preferred language.
+func (app *application) exportTenantsHandler(c echo.Context) error {
+
+	format, ok := readExportFormat(c)
+
+	if !ok {
+		return c.JSON(http.StatusBadRequest, envelope{"error": "format must be csv or xlsx"})
+	}
+
+	locale, err := app.readLocale(c)
+
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": err.Error()})
+	}
+
+	columns := []string{
+		"id", "name", "phone", "email", "personal_id_type", "personal_id",
+		"location", "block", "partition", "house", "price",
+		"active", "sos", "eos", "preferred_language",
+	}
+
+	return app.export(c, "tenants", format, columns, func(write func(cells ...any) error) error {
+
+		return app.store.EachTenant(c.Request().Context(), organizationID(c), func(t db.GetTenantsRow) error {
+
+			return write(
+				t.ID.String(),
+				t.Name,
+				t.Phone,
+				t.Email,
+				t.PersonalIDType,
+				t.PersonalID,
+				t.Location,
+				t.Block,
+				t.Partition,
+				houseName(t.Location, t.Block, t.Partition),
+				t.Price,
+				t.Active,
+				app.templates.Date(locale, t.Sos),
+				app.templates.Date(locale, t.Eos),
+				t.PreferredLanguage,
+			)
+		})
+	})
+}
+
+// exportPaymentsHandler exports the organization's payments with the tenant
+// and house each was for, with dates written in the locale given or the
+// admin's preferred language.
+func (app *application) exportPaymentsHandler(c echo.Context) error {
+
+	format, ok := readExportFormat(c)
+
+	if !ok {
+		return c.JSON(http.StatusBadRequest, envelope{"error": "format must be csv or xlsx"})
+	}
+
+	locale, err := app.readLocale(c)
+
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": err.Error()})
+	}
+
+	columns := []string{
+		"id", "tenant_id", "tenant", "location", "block", "partition", "house",
+		"amount", "start_date", "end_date", "recorded_by", "recorded_at",
+	}
+
+	return app.export(c, "payments", format, columns, func(write func(cells ...any) error) error {
+
+		return app.store.EachPayment(c.Request().Context(), organizationID(c), func(p db.GetAllPaymentsRow) error {
+
+			return write(
+				p.ID.String(),
+				p.TenantID.String(),
+				p.TenantName,
+				p.Location,
+				p.Block,
+				p.Partition,
+				houseName(p.Location, p.Block, p.Partition),
+				p.Amount,
+				app.templates.Date(locale, p.StartDate),
+				app.templates.Date(locale, p.EndDate),
+				p.AdminEmail,
+				app.templates.DateTime(locale, p.CreatedAt.In(app.location)),
+			)
+		})
+	})
+}
@@ -0,0 +1,31 @@
+package main
+
+import "testing"
+
+func TestCSVText(t *testing.T) {
+
+	tests := []struct {
+		in, want string
+	}{
+		{"", ""},
+		{"Sinza", "Sinza"},
+		{"+255712345678", "+255712345678"},
+		{"+255 712 345 678", "+255 712 345 678"},
+		{"0712345678", "0712345678"},
+		{"-5", "-5"},
+		{"=1+1", "'=1+1"},
+		{"@x", "'@x"},
+		{"+x", "'+x"},
+		{"-2+3", "'-2+3"},
+		{"+255712345678=", "'+255712345678="},
+		{"=HYPERLINK(\"http://example.com\")", "'=HYPERLINK(\"http://example.com\")"},
+		{"\tx", "'\tx"},
+		{"\rx", "'\rx"},
+	}
+
+	for _, tt := range tests {
+		if got := csvText(tt.in); got != tt.want {
+			t.Errorf("csvText(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	defaultPageSize  = 50
+	maxPageSize      = 200
+	headerNextCursor = "X-Next-Cursor"
+)
+
+func readLimit(c echo.Context) (int, error) {
+
+	v := c.QueryParam("limit")
+
+	if v == "" {
+		return defaultPageSize, nil
+	}
+
+	n, err := strconv.Atoi(v)
+
+	if err != nil || n < 1 || n > maxPageSize {
+		return 0, fmt.Errorf("limit must be between 1 and %d", maxPageSize)
+	}
+
+	return n, nil
+}
+
+// ratio is a over b to 4 decimal places, nil when b is 0.
+func ratio(a, b float64) *float64 {
+
+	if b == 0 {
+		return nil
+	}
+
+	r := math.Round(a/b*10000) / 10000
+
+	return &r
+}
@@ -2,13 +2,19 @@ package main
 
 import (
 	"database/sql"
+	"encoding/csv"
 	"errors"
+	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
+	"strconv"
+	"strings"
 
 	db "github.com/Hopertz/rent/db/sqlc"
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
+	"gopkg.in/go-playground/validator.v9"
 )
 
 type FormattedHouseData struct {
@@ -18,23 +24,123 @@ type FormattedHouseData struct {
 	Partition int16     `json:"partition"`
 	Price     int32     `json:"price"`
 	Occupied  bool      `json:"occupied"`
+	Archived  bool      `json:"archived"`
+	UnitType  string    `json:"unit_type"`
+	Photos    []string  `json:"photos"`
 	Name      string    `json:"name"`
 	TenantID  string    `json:"tenant_id"`
 }
 
+// listHousesHandler lists houses with optional filters:
+//
+//	location, block, partition, unit_type, status (occupied|vacant),
+//	min_price, max_price, archived=true, embed=tenant
+//
+// Results are ordered by sort (location, block, partition or price, prefixed
+// with "-" for descending) and paged with limit and cursor. The cursor for the
+// next page is returned in the X-Next-Cursor header.
 func (app *application) listHousesHandler(c echo.Context) error {
 
-	houses, err := app.store.GetHouses(c.Request().Context())
+	args, err := readHouseFilters(c)
+
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": err.Error()})
+	}
+
+	args.OrganizationID = uuid.NullUUID{UUID: organizationID(c), Valid: true}
+
+	args.Limit, err = readLimit(c)
+
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": err.Error()})
+	}
+
+	if cursor := c.QueryParam("cursor"); cursor != "" {
+		args.After, err = db.DecodeHouseCursor(cursor)
+		if err != nil || args.After.Sort != args.Sort || args.After.Desc != args.Desc {
+			return c.JSON(http.StatusBadRequest, envelope{"error": "invalid cursor"})
+		}
+	}
+
+	limit := args.Limit
+	args.Limit++
+
+	houses, err := app.store.ListHouses(c.Request().Context(), args)
 
 	if err != nil {
 		slog.Error("error fetching houses", "err", err)
 		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
 	}
 
+	if len(houses) > limit {
+		houses = houses[:limit]
+		c.Response().Header().Set(headerNextCursor, db.EncodeHouseCursor(args.Sort, args.Desc, houses[limit-1]))
+	}
+
 	return c.JSON(http.StatusOK, houses)
 
 }
 
+func readHouseFilters(c echo.Context) (db.ListHousesParams, error) {
+
+	qs := c.QueryParams()
+
+	args := db.ListHousesParams{
+		Location:   strings.TrimSpace(qs.Get("location")),
+		Block:      strings.TrimSpace(qs.Get("block")),
+		UnitType:   strings.TrimSpace(qs.Get("unit_type")),
+		Archived:   qs.Get("archived") == "true",
+		WithTenant: qs.Get("embed") == "tenant",
+		Sort:       db.DefaultHouseSort,
+	}
+
+	if sort := qs.Get("sort"); sort != "" {
+		args.Desc = strings.HasPrefix(sort, "-")
+		args.Sort = strings.TrimPrefix(sort, "-")
+		if !db.ValidHouseSort(args.Sort) {
+			return args, fmt.Errorf("invalid sort %q", sort)
+		}
+	}
+
+	switch qs.Get("status") {
+	case "":
+	case "occupied":
+		occupied := true
+		args.Occupied = &occupied
+	case "vacant":
+		occupied := false
+		args.Occupied = &occupied
+	default:
+		return args, errors.New("status must be occupied or vacant")
+	}
+
+	if v := qs.Get("partition"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 16)
+		if err != nil {
+			return args, errors.New("invalid partition")
+		}
+		p := int16(n)
+		args.Partition = &p
+	}
+
+	for name, dst := range map[string]**int32{"min_price": &args.MinPrice, "max_price": &args.MaxPrice} {
+		if v := qs.Get(name); v != "" {
+			n, err := strconv.ParseInt(v, 10, 32)
+			if err != nil || n < 0 {
+				return args, fmt.Errorf("invalid %s", name)
+			}
+			price := int32(n)
+			*dst = &price
+		}
+	}
+
+	if args.MinPrice != nil && args.MaxPrice != nil && *args.MinPrice > *args.MaxPrice {
+		return args, errors.New("min_price must not exceed max_price")
+	}
+
+	return args, nil
+}
+
 func (app *application) showHouseHandler(c echo.Context) error {
 
 	uuid, err := db.ReadUUIDParam(c)
@@ -43,7 +149,10 @@ func (app *application) showHouseHandler(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, envelope{"error": "invalid house id"})
 	}
 
-	house, err := app.store.GetHouseById(c.Request().Context(), uuid)
+	house, err := app.store.GetHouseById(c.Request().Context(), db.GetHouseByIdParams{
+		ID:             uuid,
+		OrganizationID: organizationID(c),
+	})
 
 	if err != nil {
 		switch {
@@ -64,6 +173,9 @@ func (app *application) showHouseHandler(c echo.Context) error {
 		Partition: house.Partition,
 		Price:     house.Price,
 		Occupied:  house.Occupied,
+		Archived:  house.Archived,
+		UnitType:  house.UnitType,
+		Photos:    house.Photos,
 		Name:      "",
 		TenantID:  "",
 	}
@@ -79,32 +191,163 @@ func (app *application) showHouseHandler(c echo.Context) error {
 	return c.JSON(http.StatusOK, formattedHouse)
 }
 
+// deleteHousesHandler only removes houses that never had a tenant. Houses with
+// tenancy or payment history have to be archived so that history is kept.
 func (app *application) deleteHousesHandler(c echo.Context) error {
 
-	uuid, err := db.ReadUUIDParam(c)
+	id, err := db.ReadUUIDParam(c)
 
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, envelope{"error": "invalid house id"})
 	}
 
-	err = app.store.DeleteHouseById(c.Request().Context(), uuid)
+	_, err = app.store.GetHouseById(c.Request().Context(), db.GetHouseByIdParams{
+		ID:             id,
+		OrganizationID: organizationID(c),
+	})
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return c.JSON(http.StatusNotFound, envelope{"error": "house not found"})
+		default:
+			slog.Error("error fetching house by id for delete", "err", err)
+			return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+		}
+	}
+
+	history, err := app.store.GetHouseHistoryCount(c.Request().Context(), db.GetHouseHistoryCountParams{
+		HouseID:        id,
+		OrganizationID: organizationID(c),
+	})
 
 	if err != nil {
-		slog.Error("error fetching house by id", "err", err)
+		slog.Error("error counting house history", "err", err)
 		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
 	}
 
+	if history.Tenants > 0 || history.Payments > 0 {
+		msg := fmt.Sprintf("house has %d tenant(s) and %d payment(s) on record; archive it instead of deleting", history.Tenants, history.Payments)
+		return c.JSON(http.StatusConflict, envelope{"error": msg})
+	}
+
+	entry := auditEntry(c, db.AuditEntry{
+		Action:     auditDelete,
+		EntityType: auditHouse,
+		EntityID:   id,
+		Snapshot:   houseSnapshot(organizationID(c)),
+	})
+
+	_, err = app.store.TxnAudit(c.Request().Context(), entry, func(q *db.Queries) (uuid.UUID, error) {
+		return uuid.Nil, q.DeleteHouseById(c.Request().Context(), db.DeleteHouseByIdParams{
+			ID:             id,
+			OrganizationID: organizationID(c),
+		})
+	})
+
+	if err != nil {
+		switch {
+		case db.IsForeignKeyViolation(err):
+			return c.JSON(http.StatusConflict, envelope{"error": "house has tenant or payment history; archive it instead of deleting"})
+		default:
+			slog.Error("error deleting house by id", "err", err)
+			return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+		}
+	}
+
+	return c.JSON(http.StatusOK, nil)
+}
+
+func (app *application) archiveHouseHandler(c echo.Context) error {
+	return app.setHouseArchived(c, true)
+}
+
+func (app *application) unarchiveHouseHandler(c echo.Context) error {
+	return app.setHouseArchived(c, false)
+}
+
+func (app *application) setHouseArchived(c echo.Context, archived bool) error {
+
+	id, err := db.ReadUUIDParam(c)
+
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": "invalid house id"})
+	}
+
+	house, err := app.store.GetHouseById(c.Request().Context(), db.GetHouseByIdParams{
+		ID:             id,
+		OrganizationID: organizationID(c),
+	})
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return c.JSON(http.StatusNotFound, envelope{"error": "house not found"})
+		default:
+			slog.Error("error fetching house by id for archive", "err", err)
+			return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+		}
+	}
+
+	if archived && house.Occupied {
+		return c.JSON(http.StatusConflict, envelope{"error": "house is occupied; remove its tenant before archiving"})
+	}
+
+	if house.Archived == archived {
+		return c.JSON(http.StatusOK, nil)
+	}
+
+	action := auditArchive
+	if !archived {
+		action = auditUnarchive
+	}
+
+	entry := auditEntry(c, db.AuditEntry{
+		Action:     action,
+		EntityType: auditHouse,
+		EntityID:   house.HouseID,
+		Snapshot:   houseSnapshot(organizationID(c)),
+	})
+
+	_, err = app.store.TxnAudit(c.Request().Context(), entry, func(q *db.Queries) (uuid.UUID, error) {
+
+		n, err := q.SetHouseArchived(c.Request().Context(), db.SetHouseArchivedParams{
+			Archived:       archived,
+			ID:             house.HouseID,
+			Version:        house.Version,
+			OrganizationID: organizationID(c),
+		})
+
+		if err == nil && n == 0 {
+			err = db.ErrEditConflict
+		}
+
+		return uuid.Nil, err
+	})
+
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrEditConflict):
+			return c.JSON(http.StatusConflict, envelope{"error": "unable to complete request due to an edit conflict"})
+		default:
+			slog.Error("error archiving house", "err", err)
+			return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+		}
+	}
+
 	return c.JSON(http.StatusOK, nil)
 }
 
 func (app *application) createHouseHandler(c echo.Context) error {
 
 	var input struct {
-		Location  string `json:"location" validate:"required"`
-		Block     string `json:"block" validate:"required,len=1"`
-		Partition int16  `json:"partition" validate:"required,min=1,max=9"`
-		Price     int32  `json:"amount" validate:"required"`
-		Occupied  bool   `json:"occupied"`
+		Location  string   `json:"location" validate:"required"`
+		Block     string   `json:"block" validate:"required,len=1"`
+		Partition int16    `json:"partition" validate:"required,min=1,max=9"`
+		Price     int32    `json:"amount" validate:"required"`
+		Occupied  bool     `json:"occupied"`
+		UnitType  string   `json:"unit_type"`
+		Photos    []string `json:"photos" validate:"dive,url"`
 	}
 
 	if err := c.Bind(&input); err != nil {
@@ -115,12 +358,28 @@ func (app *application) createHouseHandler(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, envelope{"error": err.Error()})
 	}
 
-	_, err := app.store.CreateHouse(c.Request().Context(), db.CreateHouseParams{
-		Location:  input.Location,
-		Block:     input.Block,
-		Partition: input.Partition,
-		Price:     input.Price,
-		Occupied:  input.Occupied})
+	if input.Photos == nil {
+		input.Photos = []string{}
+	}
+
+	entry := auditEntry(c, db.AuditEntry{
+		Action:     auditCreate,
+		EntityType: auditHouse,
+		Snapshot:   houseSnapshot(organizationID(c)),
+	})
+
+	_, err := app.store.TxnAudit(c.Request().Context(), entry, func(q *db.Queries) (uuid.UUID, error) {
+		return q.CreateHouse(c.Request().Context(), db.CreateHouseParams{
+			Location:       input.Location,
+			Block:          input.Block,
+			Partition:      input.Partition,
+			Price:          input.Price,
+			Occupied:       input.Occupied,
+			UnitType:       input.UnitType,
+			Photos:         input.Photos,
+			OrganizationID: organizationID(c),
+		})
+	})
 
 	if err != nil {
 		slog.Error("error creating house", "err", err)
@@ -132,13 +391,16 @@ func (app *application) createHouseHandler(c echo.Context) error {
 
 func (app *application) updateHouseHandler(c echo.Context) error {
 
-	uuid, err := db.ReadUUIDParam(c)
+	id, err := db.ReadUUIDParam(c)
 
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, envelope{"error": "invalid house id"})
 	}
 
-	house, err := app.store.GetHouseById(c.Request().Context(), uuid)
+	house, err := app.store.GetHouseById(c.Request().Context(), db.GetHouseByIdParams{
+		ID:             id,
+		OrganizationID: organizationID(c),
+	})
 
 	if err != nil {
 		switch {
@@ -154,17 +416,23 @@ func (app *application) updateHouseHandler(c echo.Context) error {
 	}
 
 	var input struct {
-		Location  *string `json:"location"`
-		Block     *string `json:"block"`
-		Partition *int16  `json:"partition"`
-		Price     *int32  `json:"price"`
-		Occupied  *bool   `json:"occupied"`
+		Location  *string  `json:"location"`
+		Block     *string  `json:"block"`
+		Partition *int16   `json:"partition"`
+		Price     *int32   `json:"price"`
+		Occupied  *bool    `json:"occupied"`
+		UnitType  *string  `json:"unit_type"`
+		Photos    []string `json:"photos" validate:"dive,url"`
 	}
 
 	if err := c.Bind(&input); err != nil {
 		return c.JSON(http.StatusBadRequest, envelope{"error": "invalid request payload"})
 	}
 
+	if err := app.validator.Struct(input); err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": err.Error()})
+	}
+
 	if input.Location != nil {
 		house.Location = *input.Location
 	}
@@ -185,21 +453,48 @@ func (app *application) updateHouseHandler(c echo.Context) error {
 		house.Price = *input.Price
 	}
 
+	if input.UnitType != nil {
+		house.UnitType = *input.UnitType
+	}
+
+	if input.Photos != nil {
+		house.Photos = input.Photos
+	}
+
 	args := db.UpdateHouseByIdParams{
-		ID:        house.HouseID,
-		Occupied:  house.Occupied,
-		Price:     house.Price,
-		Location:  house.Location,
-		Block:     house.Block,
-		Partition: house.Partition,
-		Version:   house.Version,
+		ID:             house.HouseID,
+		Occupied:       house.Occupied,
+		Price:          house.Price,
+		Location:       house.Location,
+		Block:          house.Block,
+		Partition:      house.Partition,
+		UnitType:       house.UnitType,
+		Photos:         house.Photos,
+		Version:        house.Version,
+		OrganizationID: organizationID(c),
 	}
 
-	err = app.store.UpdateHouseById(c.Request().Context(), args)
+	entry := auditEntry(c, db.AuditEntry{
+		Action:     auditUpdate,
+		EntityType: auditHouse,
+		EntityID:   house.HouseID,
+		Snapshot:   houseSnapshot(organizationID(c)),
+	})
+
+	_, err = app.store.TxnAudit(c.Request().Context(), entry, func(q *db.Queries) (uuid.UUID, error) {
+
+		n, err := q.UpdateHouseById(c.Request().Context(), args)
+
+		if err == nil && n == 0 {
+			err = db.ErrEditConflict
+		}
+
+		return uuid.Nil, err
+	})
 
 	if err != nil {
 		switch {
-		case errors.Is(err, sql.ErrNoRows):
+		case errors.Is(err, db.ErrEditConflict):
 			return c.JSON(http.StatusConflict, envelope{"error": "unable to complete request due to an edit conflict"})
 		default:
 			slog.Error("error updating house ", "err", err)
@@ -210,45 +505,321 @@ func (app *application) updateHouseHandler(c echo.Context) error {
 	return c.JSON(http.StatusOK, nil)
 }
 
+type houseImportRow struct {
+	Location  string `json:"location" validate:"required"`
+	Block     string `json:"block" validate:"required,len=1"`
+	Partition int16  `json:"partition" validate:"required,min=1,max=9"`
+	Price     int32  `json:"price" validate:"required,gt=0"`
+	Occupied  *bool  `json:"occupied"`
+	UnitType  string `json:"unit_type"`
+
+	// line is the CSV line the row was read from, and errors what could not
+	// be read from it.
+	line   int
+	errors []string
+}
+
+type houseImportResult struct {
+	Row     int        `json:"row"`
+	Line    int        `json:"line,omitempty"`
+	Action  string     `json:"action"`
+	HouseID *uuid.UUID `json:"house_id,omitempty"`
+	Errors  []string   `json:"errors,omitempty"`
+}
+
+const (
+	importActionCreate = "create"
+	importActionUpdate = "update"
+	importActionError  = "error"
+)
+
+// bulkHousesHandler imports a flat list of houses sent either as a JSON array
+// or as a CSV file (text/csv body or multipart "file" field). Every row is
+// validated and checked against existing units before anything is written;
+// with ?dry_run=true only the per-row report is returned. Otherwise all rows
+// are upserted in a single transaction, or none are if any row is invalid.
+// A row may not change an archived house, nor vacate one an active tenant
+// lives in. Photos are not imported: new houses get none and existing houses
+// keep theirs, to be managed through the house endpoints.
 func (app *application) bulkHousesHandler(c echo.Context) error {
 
-	var input []struct {
-		Location  string   `json:"location" validate:"required"`
-		Block     []string `json:"block" validate:"required,min=1,max=5"`
-		Partition [][]int  `json:"partition" validate:"gt=0,dive,min=1,max=9,dive,min=1,max=9"`
+	dryRun := c.QueryParam("dry_run") == "true"
+
+	rows, err := readHouseImport(c)
+
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": err.Error()})
 	}
 
-	if err := c.Bind(&input); err != nil {
-		return c.JSON(http.StatusBadRequest, envelope{"error": "invalid request payload"})
+	if len(rows) == 0 {
+		return c.JSON(http.StatusBadRequest, envelope{"error": "no houses to import"})
 	}
 
-	for _, house := range input {
-		if err := app.validator.Struct(house); err != nil {
-			return c.JSON(http.StatusBadRequest, envelope{"error": err.Error()})
-		}
+	units, err := app.store.GetHouseUnits(c.Request().Context(), organizationID(c))
+
+	if err != nil {
+		slog.Error("error fetching house units for import", "err", err)
+		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
 	}
 
-	var housesBulk []db.HouseBulk
+	existing := make(map[string]db.GetHouseUnitsRow, len(units))
+
+	for _, u := range units {
+		existing[houseUnitKey(u.Location, u.Block, u.Partition)] = u
+	}
+
+	seen := make(map[string]int, len(rows))
+	results := make([]houseImportResult, len(rows))
+	valid := true
+	created, updated := 0, 0
+
+	for i, row := range rows {
+
+		res := houseImportResult{Row: i + 1, Line: row.line, Action: importActionCreate}
+
+		if len(row.errors) > 0 {
+			res.Action = importActionError
+			res.Errors = row.errors
+			results[i] = res
+			valid = false
+			continue
+		}
+
+		row.Location = strings.TrimSpace(row.Location)
+		row.Block = strings.TrimSpace(row.Block)
+		row.UnitType = strings.TrimSpace(row.UnitType)
+		rows[i] = row
 
-	for _, house := range input {
-		for i, block := range house.Block {
-			for _, pt := range house.Partition[i] {
-				housesBulk = append(housesBulk, db.HouseBulk{
-					Location:  house.Location,
-					Block:     block,
-					Partition: pt,
-					Occupied:  false,
-				})
+		if err := app.validator.Struct(row); err != nil {
+			res.Errors = append(res.Errors, validationMessages(err)...)
+		}
+
+		key := houseUnitKey(row.Location, row.Block, row.Partition)
+
+		if first, ok := seen[key]; ok {
+			res.Errors = append(res.Errors, fmt.Sprintf("duplicate of row %d", first))
+		} else {
+			seen[key] = res.Row
+		}
+
+		if unit, ok := existing[key]; ok {
+
+			res.Action = importActionUpdate
+			res.HouseID = &unit.ID
+
+			if unit.Archived {
+				res.Errors = append(res.Errors, "house is archived")
 			}
+
+			if unit.Tenanted && row.Occupied != nil && !*row.Occupied {
+				res.Errors = append(res.Errors, "house has an active tenant and cannot be vacated")
+			}
+		}
+
+		if len(res.Errors) > 0 {
+			res.Action = importActionError
+			valid = false
+		} else if res.Action == importActionCreate {
+			created++
+		} else {
+			updated++
+		}
+
+		results[i] = res
+	}
+
+	report := envelope{
+		"dry_run": dryRun,
+		"valid":   valid,
+		"created": created,
+		"updated": updated,
+		"rows":    results,
+	}
+
+	if dryRun {
+		return c.JSON(http.StatusOK, report)
+	}
+
+	if !valid {
+		return c.JSON(http.StatusUnprocessableEntity, report)
+	}
+
+	houses := make([]db.UpsertHouseParams, len(rows))
+
+	for i, row := range rows {
+		houses[i] = db.UpsertHouseParams{
+			Location:       row.Location,
+			Block:          row.Block,
+			Partition:      row.Partition,
+			Price:          row.Price,
+			UnitType:       row.UnitType,
+			OrganizationID: organizationID(c),
+		}
+		if row.Occupied != nil {
+			houses[i].Occupied = sql.NullBool{Bool: *row.Occupied, Valid: true}
 		}
 	}
 
-	err := app.store.BulkInsert(c.Request().Context(), housesBulk)
+	entry := auditEntry(c, db.AuditEntry{
+		Action:     auditImport,
+		EntityType: auditHouse,
+		Snapshot:   houseSnapshot(organizationID(c)),
+	})
+
+	_, err = app.store.TxnUpsertHouses(c.Request().Context(), houses, entry)
 
 	if err != nil {
-		slog.Error("error bulk inserting houses", "err", err)
-		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+		switch {
+		case errors.Is(err, db.ErrEditConflict):
+			return c.JSON(http.StatusConflict, envelope{"error": "unable to complete request due to an edit conflict"})
+		default:
+			slog.Error("error bulk upserting houses", "err", err)
+			return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+		}
 	}
 
-	return c.JSON(http.StatusOK, nil)
+	return c.JSON(http.StatusOK, report)
+}
+
+func readHouseImport(c echo.Context) ([]houseImportRow, error) {
+
+	contentType := c.Request().Header.Get(echo.HeaderContentType)
+
+	switch {
+	case strings.HasPrefix(contentType, "text/csv"):
+		return parseHouseCSV(c.Request().Body)
+
+	case strings.HasPrefix(contentType, echo.MIMEMultipartForm):
+		fh, err := c.FormFile("file")
+		if err != nil {
+			return nil, errors.New("missing csv file")
+		}
+
+		f, err := fh.Open()
+		if err != nil {
+			return nil, errors.New("unable to read csv file")
+		}
+		defer f.Close()
+
+		return parseHouseCSV(f)
+
+	default:
+		var rows []houseImportRow
+		if err := c.Bind(&rows); err != nil {
+			return nil, errors.New("invalid request payload")
+		}
+		return rows, nil
+	}
+}
+
+// parseHouseCSV reads houses from CSV with a header row naming the columns
+// location, block, partition, price and optionally occupied and unit_type, in
+// any order. A line that cannot be read is kept as a row with its errors, so
+// that it is reported along with the rest.
+func parseHouseCSV(r io.Reader) ([]houseImportRow, error) {
+
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, errors.New("csv file is empty or unreadable")
+	}
+
+	cols := make(map[string]int, len(header))
+	for i, name := range header {
+		cols[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	for _, name := range []string{"location", "block", "partition", "price"} {
+		if _, ok := cols[name]; !ok {
+			return nil, fmt.Errorf("csv header is missing column %q", name)
+		}
+	}
+
+	field := func(record []string, name string) string {
+		i, ok := cols[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var rows []houseImportRow
+
+	for {
+
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+
+		var perr *csv.ParseError
+
+		if errors.As(err, &perr) {
+			rows = append(rows, houseImportRow{line: perr.StartLine, errors: []string{perr.Err.Error()}})
+			continue
+		}
+
+		if err != nil {
+			return nil, errors.New("unable to read csv file")
+		}
+
+		line, _ := reader.FieldPos(0)
+
+		row := houseImportRow{
+			Location: field(record, "location"),
+			Block:    field(record, "block"),
+			UnitType: field(record, "unit_type"),
+			line:     line,
+		}
+
+		if partition, err := strconv.ParseInt(field(record, "partition"), 10, 16); err != nil {
+			row.errors = append(row.errors, "invalid partition")
+		} else {
+			row.Partition = int16(partition)
+		}
+
+		if price, err := strconv.ParseInt(field(record, "price"), 10, 32); err != nil {
+			row.errors = append(row.errors, "invalid price")
+		} else {
+			row.Price = int32(price)
+		}
+
+		if v := field(record, "occupied"); v != "" {
+			if occupied, err := strconv.ParseBool(v); err != nil {
+				row.errors = append(row.errors, "invalid occupied value")
+			} else {
+				row.Occupied = &occupied
+			}
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// houseUnitKey mirrors the case-insensitive (citext) unique index on
+// house (location, block, partition).
+func houseUnitKey(location, block string, partition int16) string {
+	return fmt.Sprintf("%s|%s|%d", strings.ToLower(location), strings.ToLower(block), partition)
+}
+
+func validationMessages(err error) []string {
+
+	var verrs validator.ValidationErrors
+
+	if !errors.As(err, &verrs) {
+		return []string{err.Error()}
+	}
+
+	msgs := make([]string, 0, len(verrs))
+
+	for _, fe := range verrs {
+		msgs = append(msgs, fmt.Sprintf("%s failed on the '%s' rule", strings.ToLower(fe.Field()), fe.Tag()))
+	}
+
+	return msgs
 }
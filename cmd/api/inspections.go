@@ -0,0 +1,627 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	db "github.com/Hopertz/rent/db/sqlc"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	InspectionMoveIn  = "move-in"
+	InspectionMoveOut = "move-out"
+	InspectionRoutine = "routine"
+)
+
+// FormattedInspectionItem is one checklist entry of an inspection. Condition
+// is rated from 1 (damaged) to 5 (excellent).
+type FormattedInspectionItem struct {
+	ID        uuid.UUID `json:"id"`
+	Room      string    `json:"room"`
+	Item      string    `json:"item"`
+	Condition int16     `json:"condition"`
+	Notes     string    `json:"notes"`
+	Photos    []string  `json:"photos"`
+}
+
+type FormattedInspection struct {
+	ID          uuid.UUID                 `json:"id"`
+	HouseID     uuid.UUID                 `json:"house_id"`
+	TenantID    string                    `json:"tenant_id"`
+	Type        string                    `json:"type"`
+	Notes       string                    `json:"notes"`
+	InspectedOn time.Time                 `json:"inspected_on"`
+	CreatedBy   uuid.UUID                 `json:"created_by"`
+	CreatedAt   time.Time                 `json:"created_at"`
+	SignedBy    string                    `json:"signed_by"`
+	Signature   string                    `json:"signature"`
+	SignedAt    *time.Time                `json:"signed_at"`
+	Photos      []string                  `json:"photos,omitempty"`
+	Items       []FormattedInspectionItem `json:"items,omitempty"`
+}
+
+func formatInspection(i db.Inspection) FormattedInspection {
+
+	f := FormattedInspection{
+		ID:          i.ID,
+		HouseID:     i.HouseID,
+		Type:        i.Type,
+		Notes:       i.Notes,
+		InspectedOn: i.InspectedOn,
+		CreatedBy:   i.CreatedBy,
+		CreatedAt:   i.CreatedAt,
+		Signature:   i.Signature,
+	}
+
+	if i.TenantID.Valid {
+		f.TenantID = i.TenantID.UUID.String()
+	}
+
+	if i.SignedBy.Valid {
+		f.SignedBy = i.SignedBy.UUID.String()
+	}
+
+	if i.SignedAt.Valid {
+		f.SignedAt = &i.SignedAt.Time
+	}
+
+	return f
+}
+
+// detailedInspection loads the items and photos of an inspection.
+func (app *application) detailedInspection(c echo.Context, i db.Inspection) (FormattedInspection, error) {
+
+	f := formatInspection(i)
+
+	items, err := app.store.GetInspectionItems(c.Request().Context(), db.GetInspectionItemsParams{
+		InspectionID:   i.ID,
+		OrganizationID: organizationID(c),
+	})
+	if err != nil {
+		return f, err
+	}
+
+	photos, err := app.store.GetInspectionPhotos(c.Request().Context(), db.GetInspectionPhotosParams{
+		InspectionID:   i.ID,
+		OrganizationID: organizationID(c),
+	})
+	if err != nil {
+		return f, err
+	}
+
+	itemPhotos := make(map[uuid.UUID][]string)
+	f.Photos = []string{}
+
+	for _, p := range photos {
+		if p.ItemID.Valid {
+			itemPhotos[p.ItemID.UUID] = append(itemPhotos[p.ItemID.UUID], p.Url)
+		} else {
+			f.Photos = append(f.Photos, p.Url)
+		}
+	}
+
+	f.Items = make([]FormattedInspectionItem, 0, len(items))
+
+	for _, item := range items {
+		ph := itemPhotos[item.ID]
+		if ph == nil {
+			ph = []string{}
+		}
+		f.Items = append(f.Items, FormattedInspectionItem{
+			ID:        item.ID,
+			Room:      item.Room,
+			Item:      item.Item,
+			Condition: item.Condition,
+			Notes:     item.Notes,
+			Photos:    ph,
+		})
+	}
+
+	return f, nil
+}
+
+func (app *application) listChecklistHandler(c echo.Context) error {
+
+	items, err := app.store.GetChecklistItems(c.Request().Context(), organizationID(c))
+
+	if err != nil {
+		slog.Error("error fetching inspection checklist", "err", err)
+		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+	}
+
+	return c.JSON(http.StatusOK, items)
+}
+
+func (app *application) createChecklistItemHandler(c echo.Context) error {
+
+	var input struct {
+		Room     string `json:"room" validate:"required"`
+		Item     string `json:"item" validate:"required"`
+		Position int32  `json:"position"`
+	}
+
+	if err := c.Bind(&input); err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": "invalid request payload"})
+	}
+
+	if err := app.validator.Struct(input); err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": err.Error()})
+	}
+
+	_, err := app.store.CreateChecklistItem(c.Request().Context(), db.CreateChecklistItemParams{
+		Room:           input.Room,
+		Item:           input.Item,
+		Position:       input.Position,
+		OrganizationID: organizationID(c),
+	})
+
+	if err != nil {
+		switch {
+		case db.IsUniqueViolation(err):
+			return c.JSON(http.StatusConflict, envelope{"error": "checklist already has this room and item"})
+		default:
+			slog.Error("error creating checklist item", "err", err)
+			return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+		}
+	}
+
+	return c.JSON(http.StatusCreated, nil)
+}
+
+func (app *application) deleteChecklistItemHandler(c echo.Context) error {
+
+	uuid, err := db.ReadUUIDParam(c)
+
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": "invalid checklist item id"})
+	}
+
+	n, err := app.store.DeleteChecklistItem(c.Request().Context(), db.DeleteChecklistItemParams{
+		ID:             uuid,
+		OrganizationID: organizationID(c),
+	})
+
+	if err != nil {
+		slog.Error("error deleting checklist item", "err", err)
+		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+	}
+
+	if n == 0 {
+		return c.JSON(http.StatusNotFound, envelope{"error": "checklist item not found"})
+	}
+
+	return c.JSON(http.StatusOK, nil)
+}
+
+// createInspectionHandler records an inspection of a house. Its items must be
+// exactly those of the organization's checklist, each once, so that the
+// move-in and move-out inspections of a tenancy can be compared item by item.
+func (app *application) createInspectionHandler(c echo.Context) error {
+
+	admin, ok := c.Get("admin").(db.GetHashTokenForAdminRow)
+
+	if !ok {
+		return c.JSON(http.StatusBadRequest, envelope{"error": "you are not authorized to perform this action"})
+	}
+
+	houseID, err := db.ReadUUIDParam(c)
+
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": "invalid house id"})
+	}
+
+	var input struct {
+		Type        string     `json:"type" validate:"required,oneof=move-in move-out routine"`
+		TenantID    *uuid.UUID `json:"tenant_id"`
+		InspectedOn time.Time  `json:"inspected_on" validate:"required"`
+		Notes       string     `json:"notes"`
+		Photos      []string   `json:"photos" validate:"dive,url"`
+		Items       []struct {
+			Room      string   `json:"room" validate:"required"`
+			Item      string   `json:"item" validate:"required"`
+			Condition int16    `json:"condition" validate:"required,min=1,max=5"`
+			Notes     string   `json:"notes"`
+			Photos    []string `json:"photos" validate:"dive,url"`
+		} `json:"items" validate:"required,min=1,dive"`
+	}
+
+	if err := c.Bind(&input); err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": "invalid request payload"})
+	}
+
+	if err := app.validator.Struct(input); err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": err.Error()})
+	}
+
+	house, err := app.store.GetHouseById(c.Request().Context(), db.GetHouseByIdParams{
+		ID:             houseID,
+		OrganizationID: organizationID(c),
+	})
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return c.JSON(http.StatusNotFound, envelope{"error": "house not found"})
+		default:
+			slog.Error("error fetching house by id for inspection", "err", err)
+			return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+		}
+	}
+
+	checklist, err := app.store.GetChecklistItems(c.Request().Context(), organizationID(c))
+
+	if err != nil {
+		slog.Error("error fetching inspection checklist", "err", err)
+		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+	}
+
+	if len(checklist) == 0 {
+		return c.JSON(http.StatusUnprocessableEntity, envelope{"error": "the inspection checklist is empty, add its items first"})
+	}
+
+	type checklistKey struct {
+		room, item string
+	}
+
+	var (
+		onChecklist = make(map[checklistKey]bool, len(checklist))
+		listed      = make(map[checklistKey]bool, len(input.Items))
+		mismatches  []string
+	)
+
+	for _, item := range checklist {
+		onChecklist[checklistKey{item.Room, item.Item}] = true
+	}
+
+	for i, item := range input.Items {
+
+		input.Items[i].Room = strings.TrimSpace(item.Room)
+		input.Items[i].Item = strings.TrimSpace(item.Item)
+
+		key := checklistKey{input.Items[i].Room, input.Items[i].Item}
+
+		switch {
+		case !onChecklist[key]:
+			mismatches = append(mismatches, fmt.Sprintf("%s: %s is not on the checklist", key.room, key.item))
+		case listed[key]:
+			mismatches = append(mismatches, fmt.Sprintf("%s: %s is listed twice", key.room, key.item))
+		}
+
+		listed[key] = true
+	}
+
+	for _, item := range checklist {
+		if !listed[checklistKey{item.Room, item.Item}] {
+			mismatches = append(mismatches, fmt.Sprintf("%s: %s is missing", item.Room, item.Item))
+		}
+	}
+
+	if len(mismatches) > 0 {
+		return c.JSON(http.StatusUnprocessableEntity, envelope{"error": "items do not match the inspection checklist", "items": mismatches})
+	}
+
+	args := db.CreateInspectionParams{
+		HouseID:        house.HouseID,
+		Type:           input.Type,
+		Notes:          input.Notes,
+		InspectedOn:    input.InspectedOn,
+		CreatedBy:      admin.ID,
+		OrganizationID: organizationID(c),
+	}
+
+	if input.TenantID != nil {
+
+		tenant, err := app.store.GetTenantById(c.Request().Context(), db.GetTenantByIdParams{
+			ID:             *input.TenantID,
+			OrganizationID: organizationID(c),
+		})
+
+		if err != nil {
+			switch {
+			case errors.Is(err, sql.ErrNoRows):
+				return c.JSON(http.StatusNotFound, envelope{"error": "tenant not found"})
+			default:
+				slog.Error("error fetching tenant by id for inspection", "err", err)
+				return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+			}
+		}
+
+		if tenant.HouseID != house.HouseID {
+			return c.JSON(http.StatusBadRequest, envelope{"error": "tenant does not rent this house"})
+		}
+
+		args.TenantID = uuid.NullUUID{UUID: tenant.TenantID, Valid: true}
+
+	} else if input.Type != InspectionRoutine {
+		return c.JSON(http.StatusBadRequest, envelope{"error": "tenant_id is required for move-in and move-out inspections"})
+	}
+
+	items := make([]db.InspectionItemEntry, 0, len(input.Items))
+
+	for _, item := range input.Items {
+		items = append(items, db.InspectionItemEntry{
+			Room:      item.Room,
+			Item:      item.Item,
+			Condition: item.Condition,
+			Notes:     item.Notes,
+			Photos:    item.Photos,
+		})
+	}
+
+	id, err := app.store.TxnCreateInspection(c.Request().Context(), args, items, input.Photos)
+
+	if err != nil {
+		switch {
+		case db.IsUniqueViolation(err):
+			return c.JSON(http.StatusConflict, envelope{"error": "tenancy already has a " + input.Type + " inspection"})
+		default:
+			slog.Error("error creating inspection", "err", err)
+			return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+		}
+	}
+
+	return c.JSON(http.StatusCreated, envelope{"id": id})
+}
+
+func (app *application) listHouseInspectionsHandler(c echo.Context) error {
+
+	uuid, err := db.ReadUUIDParam(c)
+
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": "invalid house id"})
+	}
+
+	inspections, err := app.store.GetInspectionsByHouse(c.Request().Context(), db.GetInspectionsByHouseParams{
+		HouseID:        uuid,
+		OrganizationID: organizationID(c),
+	})
+
+	if err != nil {
+		slog.Error("error fetching inspections by house", "err", err)
+		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+	}
+
+	formatted := make([]FormattedInspection, 0, len(inspections))
+
+	for _, i := range inspections {
+		formatted = append(formatted, formatInspection(i))
+	}
+
+	return c.JSON(http.StatusOK, formatted)
+}
+
+func (app *application) showInspectionHandler(c echo.Context) error {
+
+	uuid, err := db.ReadUUIDParam(c)
+
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": "invalid inspection id"})
+	}
+
+	inspection, err := app.store.GetInspectionById(c.Request().Context(), db.GetInspectionByIdParams{
+		ID:             uuid,
+		OrganizationID: organizationID(c),
+	})
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return c.JSON(http.StatusNotFound, envelope{"error": "inspection not found"})
+		default:
+			slog.Error("error fetching inspection by id", "err", err)
+			return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+		}
+	}
+
+	detailed, err := app.detailedInspection(c, inspection)
+
+	if err != nil {
+		slog.Error("error fetching inspection details", "err", err)
+		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+	}
+
+	return c.JSON(http.StatusOK, detailed)
+}
+
+// signInspectionHandler records the signing admin and their signature. A
+// signed inspection can no longer be changed or deleted.
+func (app *application) signInspectionHandler(c echo.Context) error {
+
+	admin, ok := c.Get("admin").(db.GetHashTokenForAdminRow)
+
+	if !ok {
+		return c.JSON(http.StatusBadRequest, envelope{"error": "you are not authorized to perform this action"})
+	}
+
+	id, err := db.ReadUUIDParam(c)
+
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": "invalid inspection id"})
+	}
+
+	var input struct {
+		Signature string `json:"signature" validate:"required"`
+	}
+
+	if err := c.Bind(&input); err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": "invalid request payload"})
+	}
+
+	if err := app.validator.Struct(input); err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": err.Error()})
+	}
+
+	inspection, err := app.store.GetInspectionById(c.Request().Context(), db.GetInspectionByIdParams{
+		ID:             id,
+		OrganizationID: organizationID(c),
+	})
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return c.JSON(http.StatusNotFound, envelope{"error": "inspection not found"})
+		default:
+			slog.Error("error fetching inspection by id for signing", "err", err)
+			return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+		}
+	}
+
+	if inspection.SignedAt.Valid {
+		return c.JSON(http.StatusConflict, envelope{"error": "inspection is already signed"})
+	}
+
+	n, err := app.store.SignInspection(c.Request().Context(), db.SignInspectionParams{
+		SignedBy:       uuid.NullUUID{UUID: admin.ID, Valid: true},
+		Signature:      input.Signature,
+		ID:             inspection.ID,
+		Version:        inspection.Version,
+		OrganizationID: organizationID(c),
+	})
+
+	if err != nil {
+		slog.Error("error signing inspection", "err", err)
+		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+	}
+
+	if n == 0 {
+		return c.JSON(http.StatusConflict, envelope{"error": "unable to complete request due to an edit conflict"})
+	}
+
+	return c.JSON(http.StatusOK, nil)
+}
+
+func (app *application) deleteInspectionHandler(c echo.Context) error {
+
+	uuid, err := db.ReadUUIDParam(c)
+
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": "invalid inspection id"})
+	}
+
+	inspection, err := app.store.GetInspectionById(c.Request().Context(), db.GetInspectionByIdParams{
+		ID:             uuid,
+		OrganizationID: organizationID(c),
+	})
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return c.JSON(http.StatusNotFound, envelope{"error": "inspection not found"})
+		default:
+			slog.Error("error fetching inspection by id for delete", "err", err)
+			return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+		}
+	}
+
+	n, err := app.store.DeleteInspection(c.Request().Context(), db.DeleteInspectionParams{
+		ID:             inspection.ID,
+		OrganizationID: organizationID(c),
+	})
+
+	if err != nil {
+		slog.Error("error deleting inspection", "err", err)
+		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+	}
+
+	if n == 0 {
+		return c.JSON(http.StatusConflict, envelope{"error": "signed inspections cannot be deleted"})
+	}
+
+	return c.JSON(http.StatusOK, nil)
+}
+
+type inspectionComparison struct {
+	Room     string                   `json:"room"`
+	Item     string                   `json:"item"`
+	MoveIn   *FormattedInspectionItem `json:"move_in"`
+	MoveOut  *FormattedInspectionItem `json:"move_out"`
+	Worsened bool                     `json:"worsened"`
+}
+
+// compareTenancyInspectionsHandler lines up the move-in and move-out
+// inspections of a tenancy item by item.
+func (app *application) compareTenancyInspectionsHandler(c echo.Context) error {
+
+	tenantID, err := db.ReadUUIDParam(c)
+
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": "invalid tenant id"})
+	}
+
+	inspections := make(map[string]*FormattedInspection, 2)
+
+	for _, typ := range []string{InspectionMoveIn, InspectionMoveOut} {
+
+		i, err := app.store.GetTenancyInspection(c.Request().Context(), db.GetTenancyInspectionParams{
+			TenantID:       uuid.NullUUID{UUID: tenantID, Valid: true},
+			Type:           typ,
+			OrganizationID: organizationID(c),
+		})
+
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				continue
+			}
+			slog.Error("error fetching tenancy inspection", "err", err)
+			return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+		}
+
+		detailed, err := app.detailedInspection(c, i)
+
+		if err != nil {
+			slog.Error("error fetching inspection details", "err", err)
+			return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+		}
+
+		inspections[typ] = &detailed
+	}
+
+	if len(inspections) == 0 {
+		return c.JSON(http.StatusNotFound, envelope{"error": "tenancy has no move-in or move-out inspection"})
+	}
+
+	var (
+		rows  []inspectionComparison
+		index = make(map[[2]string]int)
+	)
+
+	row := func(room, item string) *inspectionComparison {
+		key := [2]string{room, item}
+		if i, ok := index[key]; ok {
+			return &rows[i]
+		}
+		index[key] = len(rows)
+		rows = append(rows, inspectionComparison{Room: room, Item: item})
+		return &rows[len(rows)-1]
+	}
+
+	if in := inspections[InspectionMoveIn]; in != nil {
+		for i := range in.Items {
+			row(in.Items[i].Room, in.Items[i].Item).MoveIn = &in.Items[i]
+		}
+	}
+
+	if out := inspections[InspectionMoveOut]; out != nil {
+		for i := range out.Items {
+			row(out.Items[i].Room, out.Items[i].Item).MoveOut = &out.Items[i]
+		}
+	}
+
+	for i := range rows {
+		r := &rows[i]
+		r.Worsened = r.MoveIn != nil && r.MoveOut != nil && r.MoveOut.Condition < r.MoveIn.Condition
+	}
+
+	return c.JSON(http.StatusOK, envelope{
+		"move_in":  inspections[InspectionMoveIn],
+		"move_out": inspections[InspectionMoveOut],
+		"items":    rows,
+	})
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	db "github.com/Hopertz/rent/db/sqlc"
+	mailer "github.com/Hopertz/rent/pkg/Mailer"
+	"github.com/Hopertz/rent/pkg/templates"
+	"github.com/labstack/echo/v4"
+)
+
+const inviteTTL = 7 * 24 * time.Hour
+
+type FormattedInvite struct {
+	ID         string     `json:"id"`
+	Email      string     `json:"email"`
+	Role       string     `json:"role"`
+	InvitedBy  string     `json:"invited_by"`
+	Status     string     `json:"status"`
+	CreatedAt  time.Time  `json:"created_at"`
+	Expiry     time.Time  `json:"expiry"`
+	AcceptedAt *time.Time `json:"accepted_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
+
+// createInviteHandler invites email to join the organization the request
+// acts on with role.
+func (app *application) createInviteHandler(c echo.Context) error {
+
+	admin, ok := c.Get("admin").(db.GetHashTokenForAdminRow)
+
+	if !ok {
+		return c.JSON(http.StatusBadRequest, envelope{"error": "you are not authorized to perform this action"})
+	}
+
+	var input struct {
+		Email string `json:"email" validate:"required,email"`
+		Role  string `json:"role" validate:"required"`
+
+		// Language is that of the invitation email, the inviting admin's
+		// by default.
+		Language string `json:"language"`
+	}
+
+	if err := c.Bind(&input); err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": err.Error()})
+	}
+
+	if err := app.validator.Struct(input); err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": err.Error()})
+	}
+
+	if !validRole(input.Role) {
+		return c.JSON(http.StatusBadRequest, envelope{"error": "role must be one of owner, manager, accountant or caretaker"})
+	}
+
+	language, ok := app.readLanguage(input.Language)
+
+	if !ok {
+		return c.JSON(http.StatusBadRequest, envelope{"error": "language must be one of " + strings.Join(app.templates.Locales(), ", ")})
+	}
+
+	if language == "" {
+		language = admin.PreferredLanguage
+	}
+
+	email := strings.TrimSpace(input.Email)
+
+	_, err := app.store.GetAdminByEmail(c.Request().Context(), email)
+
+	switch {
+	case err == nil:
+		return c.JSON(http.StatusConflict, envelope{"error": "an admin with this email already exists"})
+	case !errors.Is(err, sql.ErrNoRows):
+		slog.Error("error fetching admin by email", "error", err)
+		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+	}
+
+	expiry := time.Now().Add(inviteTTL)
+
+	err = app.store.NewInvite(c.Request().Context(), email, input.Role, admin.ID, organizationID(c), expiry, func(token string) db.OutboxMessage {
+		data := mailer.InviteData{
+			Email:  email,
+			Role:   input.Role,
+			Token:  token,
+			Expiry: expiry.Format(time.RFC3339),
+		}
+		data.Content = app.mailContent(language, templates.Invite, data)
+
+		return db.OutboxMessage{
+			Kind:      mailer.PathInvite,
+			Recipient: email,
+			Payload:   data,
+		}
+	})
+
+	if err != nil {
+		slog.Error("error creating invite", "error", err)
+		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+	}
+
+	return c.JSON(http.StatusCreated, envelope{"email": email, "role": input.Role, "expiry": expiry})
+}
+
+func (app *application) listInvitesHandler(c echo.Context) error {
+
+	invites, err := app.store.GetInvites(c.Request().Context(), organizationID(c))
+
+	if err != nil {
+		slog.Error("error fetching invites", "error", err)
+		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+	}
+
+	now := time.Now()
+
+	data := make([]FormattedInvite, 0, len(invites))
+
+	for _, i := range invites {
+
+		f := FormattedInvite{
+			ID:        i.ID.String(),
+			Email:     i.Email,
+			Role:      i.Role,
+			InvitedBy: i.InvitedBy,
+			Status:    "pending",
+			CreatedAt: i.CreatedAt,
+			Expiry:    i.Expiry,
+		}
+
+		switch {
+		case i.AcceptedAt.Valid:
+			f.Status = "accepted"
+			f.AcceptedAt = &i.AcceptedAt.Time
+		case i.RevokedAt.Valid:
+			f.Status = "revoked"
+			f.RevokedAt = &i.RevokedAt.Time
+		case !i.Expiry.After(now):
+			f.Status = "expired"
+		}
+
+		data = append(data, f)
+	}
+
+	return c.JSON(http.StatusOK, data)
+}
+
+func (app *application) revokeInviteHandler(c echo.Context) error {
+
+	id, err := db.ReadUUIDParam(c)
+
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": "invalid invite id"})
+	}
+
+	n, err := app.store.RevokeInvite(c.Request().Context(), db.RevokeInviteParams{
+		ID:             id,
+		OrganizationID: organizationID(c),
+	})
+
+	if err != nil {
+		slog.Error("error revoking invite", "error", err)
+		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+	}
+
+	if n == 0 {
+		return c.JSON(http.StatusNotFound, envelope{"error": "invite not found or no longer pending"})
+	}
+
+	return c.JSON(http.StatusOK, nil)
+}
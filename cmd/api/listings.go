@@ -0,0 +1,163 @@
+package main
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+
+	db "github.com/Hopertz/rent/db/sqlc"
+	"github.com/labstack/echo/v4"
+)
+
+// PublicVacancy is the only view of a house exposed without authentication.
+type PublicVacancy struct {
+	Location string   `json:"location"`
+	Block    string   `json:"block"`
+	UnitType string   `json:"unit_type"`
+	Price    int32    `json:"price"`
+	Photos   []string `json:"photos"`
+}
+
+// listVacanciesHandler lists vacant, non archived houses whose location has
+// not opted out of public listing. It accepts the location, block, unit_type,
+// min_price, max_price, sort, limit and cursor parameters of the admin houses
+// list; everything else is ignored. Its cursor counts the vacancies already
+// listed rather than naming the last house, which keeps house ids private.
+func (app *application) listVacanciesHandler(c echo.Context) error {
+
+	args, err := readHouseFilters(c)
+
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": err.Error()})
+	}
+
+	if args.Sort != "price" && args.Sort != "location" {
+		return c.JSON(http.StatusBadRequest, envelope{"error": "sort must be price or location"})
+	}
+
+	vacant := false
+
+	args.Occupied = &vacant
+	args.Partition = nil
+	args.Archived = false
+	args.WithTenant = false
+	args.PublicOnly = true
+
+	args.Limit, err = readLimit(c)
+
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": err.Error()})
+	}
+
+	if cursor := c.QueryParam("cursor"); cursor != "" {
+		after, err := db.DecodeVacancyCursor(cursor)
+		if err != nil || after.Sort != args.Sort || after.Desc != args.Desc {
+			return c.JSON(http.StatusBadRequest, envelope{"error": "invalid cursor"})
+		}
+		args.Offset = after.Offset
+	}
+
+	limit := args.Limit
+	args.Limit++
+
+	houses, err := app.store.ListHouses(c.Request().Context(), args)
+
+	if err != nil {
+		slog.Error("error fetching public vacancies", "err", err)
+		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+	}
+
+	if len(houses) > limit {
+		houses = houses[:limit]
+		c.Response().Header().Set(headerNextCursor, db.EncodeVacancyCursor(args.Sort, args.Desc, args.Offset+limit))
+	}
+
+	vacancies := make([]PublicVacancy, 0, len(houses))
+
+	for _, h := range houses {
+		photos := h.Photos
+		if photos == nil {
+			photos = []string{}
+		}
+		vacancies = append(vacancies, PublicVacancy{
+			Location: h.Location,
+			Block:    h.Block,
+			UnitType: h.UnitType,
+			Price:    h.Price,
+			Photos:   photos,
+		})
+	}
+
+	return c.JSON(http.StatusOK, vacancies)
+}
+
+func (app *application) listListingOptOutsHandler(c echo.Context) error {
+
+	optOuts, err := app.store.GetListingOptOuts(c.Request().Context(), organizationID(c))
+
+	if err != nil {
+		slog.Error("error fetching listing opt outs", "err", err)
+		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+	}
+
+	return c.JSON(http.StatusOK, optOuts)
+}
+
+func (app *application) createListingOptOutHandler(c echo.Context) error {
+
+	admin, ok := c.Get("admin").(db.GetHashTokenForAdminRow)
+
+	if !ok {
+		return c.JSON(http.StatusBadRequest, envelope{"error": "you are not authorized to perform this action"})
+	}
+
+	var input struct {
+		Location string `json:"location" validate:"required"`
+	}
+
+	if err := c.Bind(&input); err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": "invalid request payload"})
+	}
+
+	if err := app.validator.Struct(input); err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": err.Error()})
+	}
+
+	err := app.store.CreateListingOptOut(c.Request().Context(), db.CreateListingOptOutParams{
+		Location:       strings.TrimSpace(input.Location),
+		CreatedBy:      admin.ID,
+		OrganizationID: organizationID(c),
+	})
+
+	if err != nil {
+		slog.Error("error creating listing opt out", "err", err)
+		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+	}
+
+	return c.JSON(http.StatusCreated, nil)
+}
+
+func (app *application) deleteListingOptOutHandler(c echo.Context) error {
+
+	location := strings.TrimSpace(c.QueryParam("location"))
+
+	if location == "" {
+		return c.JSON(http.StatusBadRequest, envelope{"error": "location is required"})
+	}
+
+	n, err := app.store.DeleteListingOptOut(c.Request().Context(), db.DeleteListingOptOutParams{
+		Location:       location,
+		OrganizationID: organizationID(c),
+	})
+
+	if err != nil {
+		slog.Error("error deleting listing opt out", "err", err)
+		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+	}
+
+	if n == 0 {
+		return c.JSON(http.StatusNotFound, envelope{"error": "location is not opted out"})
+	}
+
+	return c.JSON(http.StatusOK, nil)
+}
@@ -7,12 +7,17 @@ import (
 	"flag"
 	"log"
 	"log/slog"
+	"net"
 	"os"
 	"runtime"
 	"sync"
 	"time"
+	_ "time/tzdata"
 
 	db "github.com/Hopertz/rent/db/sqlc"
+	mailer "github.com/Hopertz/rent/pkg/Mailer"
+	"github.com/Hopertz/rent/pkg/sms"
+	"github.com/Hopertz/rent/pkg/templates"
 	_ "github.com/lib/pq"
 	"gopkg.in/go-playground/validator.v9"
 )
@@ -28,8 +33,47 @@ type config struct {
 		maxIdleConns int
 		maxIdleTime  string
 	}
-	mailer_url string
-	emails     string
+	mailer_url    string
+	defaultLocale string
+	timezone      string
+
+	// trustedProxies are the reverse proxies whose X-Forwarded-For is
+	// believed.
+	trustedProxies []*net.IPNet
+
+	auth struct {
+		accessTokenTTL   time.Duration
+		refreshTokenTTL  time.Duration
+		lockoutThreshold int
+		lockoutDuration  time.Duration
+
+		passwordMinLength int
+		passwordHistory   int
+
+		tokenSweepInterval time.Duration
+	}
+	outbox struct {
+		pollInterval time.Duration
+		maxAttempts  int
+		retention    time.Duration
+	}
+	sms struct {
+		url    string
+		token  string
+		sender string
+
+		inboundToken string
+	}
+	reminders struct {
+		interval time.Duration
+		offsets  []int
+	}
+	digest struct {
+		interval time.Duration
+	}
+	occupancy struct {
+		snapshotInterval time.Duration
+	}
 }
 
 type envelope map[string]interface{}
@@ -39,6 +83,10 @@ type application struct {
 	wg        sync.WaitGroup
 	store     db.Store
 	validator *validator.Validate
+	mailer    mailer.Sender
+	sms       sms.Provider
+	templates *templates.Set
+	location  *time.Location
 }
 
 func init() {
@@ -59,11 +107,58 @@ func main() {
 	flag.IntVar(&cfg.db.maxOpenConns, "db-max-open-conns", 25, "PostgreSQL max open connections")
 	flag.IntVar(&cfg.db.maxIdleConns, "db-max-idle-conns", 25, "PostgreSQL max ilde connections")
 	flag.StringVar(&cfg.db.maxIdleTime, "db-max-idle-time", "15m", "PostgreSQL max connection  connections")
-	flag.StringVar(&cfg.emails, "admin-emails", os.Getenv("ADMIN_EMAILS"), "admin emails ")
 	flag.StringVar(&cfg.mailer_url, "mail-url", os.Getenv("MAIL_URL"), "mail url ")
+	flag.StringVar(&cfg.defaultLocale, "default-locale", templates.DefaultLocale, "Language of notifications to recipients without a supported preferred language")
+	flag.StringVar(&cfg.timezone, "timezone", "Africa/Dar_es_Salaam", "Time zone tenants' quiet hours are in")
+	flag.DurationVar(&cfg.auth.accessTokenTTL, "access-token-ttl", 15*time.Minute, "Lifetime of access tokens")
+	flag.DurationVar(&cfg.auth.refreshTokenTTL, "refresh-token-ttl", 3*24*time.Hour, "Lifetime of refresh tokens")
+	flag.IntVar(&cfg.auth.lockoutThreshold, "login-lockout-threshold", 10, "Failed logins before an account is locked")
+	flag.DurationVar(&cfg.auth.lockoutDuration, "login-lockout-duration", 15*time.Minute, "How long a locked account stays locked")
+	flag.IntVar(&cfg.auth.passwordMinLength, "password-min-length", 10, "Minimum length of new admin passwords")
+	flag.IntVar(&cfg.auth.passwordHistory, "password-history", 5, "Number of recent passwords, the current one included, an admin may not reuse (0 disables)")
+	flag.DurationVar(&cfg.auth.tokenSweepInterval, "token-sweep-interval", time.Hour, "How often expired tokens are purged (0 disables)")
+	flag.DurationVar(&cfg.outbox.pollInterval, "outbox-poll-interval", 5*time.Second, "How often queued notifications are delivered (0 leaves delivery to other instances)")
+	flag.IntVar(&cfg.outbox.maxAttempts, "outbox-max-attempts", 8, "Delivery attempts before a notification is dead-lettered")
+	flag.DurationVar(&cfg.outbox.retention, "outbox-retention", 7*24*time.Hour, "How long delivered notifications are kept")
+	flag.StringVar(&cfg.sms.url, "sms-url", os.Getenv("SMS_URL"), "SMS gateway endpoint (empty disables text messages)")
+	flag.StringVar(&cfg.sms.token, "sms-token", os.Getenv("SMS_TOKEN"), "SMS gateway bearer token")
+	flag.StringVar(&cfg.sms.sender, "sms-sender", os.Getenv("SMS_SENDER"), "Name or number text messages come from")
+	flag.StringVar(&cfg.sms.inboundToken, "sms-inbound-token", os.Getenv("SMS_INBOUND_TOKEN"), "Bearer token the SMS gateway forwards tenants' replies with (empty disables them)")
+	flag.DurationVar(&cfg.reminders.interval, "reminder-interval", time.Hour, "How often due reminders are queued (0 disables)")
+
+	flag.DurationVar(&cfg.digest.interval, "digest-interval", 15*time.Minute, "How often admins' daily digests are checked for and queued (0 disables)")
+
+	flag.DurationVar(&cfg.occupancy.snapshotInterval, "occupancy-snapshot-interval", time.Hour, "How often each house's occupancy is recorded for the day (0 leaves it to other instances)")
+
+	cfg.reminders.offsets = []int{7, 3, 0}
+	flag.Func("reminder-offsets", "Days before a due date or end of stay to remind tenants, comma separated (default 7,3,0)", func(s string) error {
+		offsets, err := parseOffsets(s)
+		cfg.reminders.offsets = offsets
+		return err
+	})
+
+	flag.Func("trusted-proxies", "Addresses or CIDR ranges of reverse proxies whose X-Forwarded-For is trusted, comma separated (default none)", func(s string) error {
+		ranges, err := parseTrustedProxies(s)
+		cfg.trustedProxies = ranges
+		return err
+	})
+
+	var superUser string
+
+	flag.StringVar(&superUser, "create-superuser", "", "Create a super user with this email, reading their password from standard input, and exit")
 
 	flag.Parse()
 
+	tmpl, err := templates.New(cfg.defaultLocale)
+	if err != nil {
+		log.Fatal("error loading templates", err)
+	}
+
+	location, err := time.LoadLocation(cfg.timezone)
+	if err != nil {
+		log.Fatal("error loading timezone", err)
+	}
+
 	dbConn, err := openDB(cfg)
 	if err != nil {
 		log.Fatal("error opening db", err)
@@ -91,6 +186,25 @@ func main() {
 		config:    cfg,
 		store:     db.NewStore(dbConn),
 		validator: validator.New(),
+		mailer:    mailer.New(mailer.Config{BaseURL: cfg.mailer_url}),
+		templates: tmpl,
+		location:  location,
+	}
+
+	if superUser != "" {
+		if err := app.createSuperUser(context.Background(), superUser, os.Stdin); err != nil {
+			log.Fatal("error creating super user: ", err)
+		}
+		slog.Info("super user created", "email", superUser)
+		return
+	}
+
+	if cfg.sms.url != "" {
+		app.sms = sms.NewHTTP(sms.HTTPConfig{
+			URL:    cfg.sms.url,
+			Token:  cfg.sms.token,
+			Sender: cfg.sms.sender,
+		})
 	}
 
 	err = app.serve()
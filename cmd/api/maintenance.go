@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"log/slog"
+	"time"
+
+	db "github.com/Hopertz/rent/db/sqlc"
+)
+
+// tokenSweepBatch bounds how many rows one DELETE removes, so a large backlog
+// never holds locks on the token table for long.
+const tokenSweepBatch = 1000
+
+var tokenSweeper = expvar.NewMap("token_sweeper")
+
+// runTokenSweeper purges expired tokens, and sessions left without any, every
+// token-sweep-interval until ctx is cancelled.
+func (app *application) runTokenSweeper(ctx context.Context) {
+
+	interval := app.config.auth.tokenSweepInterval
+
+	if interval <= 0 {
+		slog.Info("token sweeper disabled")
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		app.sweepTokens(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (app *application) sweepTokens(ctx context.Context) {
+
+	now := time.Now()
+
+	var tokens int64
+
+	for ctx.Err() == nil {
+
+		n, err := app.store.DeleteExpiredTokens(ctx, db.DeleteExpiredTokensParams{
+			Expiry: now,
+			Limit:  tokenSweepBatch,
+		})
+
+		if err != nil {
+			if !errors.Is(err, context.Canceled) {
+				slog.Error("error purging expired tokens", "error", err)
+				tokenSweeper.Add("errors", 1)
+			}
+			break
+		}
+
+		tokens += n
+
+		if n < tokenSweepBatch {
+			break
+		}
+	}
+
+	sessions, err := app.store.DeleteEmptySessions(ctx, now)
+
+	if err != nil && !errors.Is(err, context.Canceled) {
+		slog.Error("error purging empty sessions", "error", err)
+		tokenSweeper.Add("errors", 1)
+	}
+
+	tokenSweeper.Add("runs", 1)
+	tokenSweeper.Add("tokens_purged", tokens)
+	tokenSweeper.Add("sessions_purged", sessions)
+	lastRun := new(expvar.String)
+	lastRun.Set(now.Format(time.RFC3339))
+	tokenSweeper.Set("last_run", lastRun)
+
+	if tokens > 0 || sessions > 0 {
+		slog.Info("purged expired tokens", "tokens", tokens, "sessions", sessions, "duration", time.Since(now).String())
+	}
+}
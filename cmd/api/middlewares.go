@@ -11,12 +11,17 @@ import (
 
 	db "github.com/Hopertz/rent/db/sqlc"
 	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
 )
 
 func (app *application) authenticate(next echo.HandlerFunc) echo.HandlerFunc {
 
 	return func(c echo.Context) error {
 
+		if key := c.Request().Header.Get(headerAPIKey); key != "" {
+			return app.authenticateAPIKey(c, key, next)
+		}
+
 		authorizationHeader := c.Request().Header.Get("Authorization")
 
 		if authorizationHeader == "" {
@@ -58,7 +63,15 @@ func (app *application) authenticate(next echo.HandlerFunc) echo.HandlerFunc {
 			}
 		}
 
+		if admin.SessionID.Valid {
+			err = app.store.TouchSession(c.Request().Context(), admin.SessionID.UUID)
+			if err != nil {
+				slog.Error("error touching session", "error", err)
+			}
+		}
+
 		c.Set("admin", admin)
+		c.Set("token_hash", tokenHash[:])
 
 		return next(c)
 
@@ -66,8 +79,22 @@ func (app *application) authenticate(next echo.HandlerFunc) echo.HandlerFunc {
 
 }
 
+// requireAuthenticatedAdmin guards routes that manage the admin's own
+// account, which only a person signed in with a bearer token may use.
 func (app *application) requireAuthenticatedAdmin(next echo.HandlerFunc) echo.HandlerFunc {
 
+	return app.requireActivatedAdmin(func(c echo.Context) error {
+
+		if _, ok := c.Get("api_key").(db.GetApiKeyByHashRow); ok {
+			return c.JSON(http.StatusForbidden, envelope{"error": "api keys cannot access this route"})
+		}
+
+		return next(c)
+	})
+}
+
+func (app *application) requireActivatedAdmin(next echo.HandlerFunc) echo.HandlerFunc {
+
 	return func(c echo.Context) error {
 
 		admin, ok := c.Get("admin").(db.GetHashTokenForAdminRow)
@@ -83,3 +110,28 @@ func (app *application) requireAuthenticatedAdmin(next echo.HandlerFunc) echo.Ha
 		return next(c)
 	}
 }
+
+// bodyLimit applies the default request body limit to every route except the
+// paths listed in overrides, which get their own (usually larger) limit.
+func bodyLimit(limit string, overrides map[string]string) echo.MiddlewareFunc {
+
+	fallback := middleware.BodyLimit(limit)
+
+	limits := make(map[string]echo.MiddlewareFunc, len(overrides))
+
+	for path, l := range overrides {
+		limits[path] = middleware.BodyLimit(l)
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+
+		return func(c echo.Context) error {
+
+			if mw, ok := limits[c.Path()]; ok {
+				return mw(next)(c)
+			}
+
+			return fallback(next)(c)
+		}
+	}
+}
@@ -0,0 +1,520 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	db "github.com/Hopertz/rent/db/sqlc"
+	mailer "github.com/Hopertz/rent/pkg/Mailer"
+	"github.com/Hopertz/rent/pkg/sms"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// Channels a tenant can be reached on.
+const (
+	ChannelSMS      = sms.ChannelSMS
+	ChannelWhatsApp = sms.ChannelWhatsApp
+	ChannelEmail    = "email"
+)
+
+// Categories of messages to tenants. Opting out stops all of them; marketing
+// also needs the tenant's consent.
+const (
+	categoryTransactional = "transactional"
+	categoryReminder      = "reminder"
+	categoryMarketing     = "marketing"
+)
+
+const (
+	consentAdmin   = "admin"
+	consentKeyword = "keyword"
+)
+
+// Keywords a tenant can text in, compared with the first word of the message.
+var (
+	optOutKeywords = []string{"STOP", "STOPALL", "UNSUBSCRIBE", "CANCEL", "END", "QUIT", "ACHA", "SITISHA"}
+	optInKeywords  = []string{"START", "UNSTOP", "ANZA"}
+)
+
+// outboxTenantMessage is the outbox kind of a message to a tenant with nothing
+// to record once sent, such as a payment receipt.
+const outboxTenantMessage = "tenant_message"
+
+// tenantMessage is the outbox payload of a message to a tenant. Which channel
+// it goes out on, and when, is decided when it is delivered, from the
+// tenant's preferences at that time.
+type tenantMessage struct {
+	TenantID       uuid.UUID `json:"tenant_id"`
+	OrganizationID uuid.UUID `json:"organization_id"`
+	Category       string    `json:"category"`
+	Subject        string    `json:"subject"`
+	Body           string    `json:"body"`
+}
+
+// errSkipped marks a message the recipient's preferences rule out. It is not
+// retried.
+var errSkipped = errors.New("skipped")
+
+// deferredError holds a message back, without counting an attempt, until the
+// recipient's quiet hours end.
+type deferredError struct {
+	until time.Time
+}
+
+func (e *deferredError) Error() string {
+	return "deferred until " + e.until.Format(time.RFC3339)
+}
+
+func (app *application) deliverTenantMessage(ctx context.Context, payload json.RawMessage) error {
+
+	var m tenantMessage
+
+	if err := json.Unmarshal(payload, &m); err != nil {
+		return fmt.Errorf("%w: %v", errUndeliverable, err)
+	}
+
+	_, err := app.sendToTenant(ctx, m, time.Now())
+
+	return err
+}
+
+// sendToTenant sends m on the first of the tenant's channels it can go out
+// on, and returns the provider's id for it when there is one.
+func (app *application) sendToTenant(ctx context.Context, m tenantMessage, now time.Time) (string, error) {
+
+	s, err := app.store.GetNotificationSettings(ctx, db.GetNotificationSettingsParams{
+		ID:             m.TenantID,
+		OrganizationID: m.OrganizationID,
+	})
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", fmt.Errorf("%w: tenant no longer exists", errSkipped)
+		}
+		return "", err
+	}
+
+	if s.OptedOutAt.Valid {
+		return "", fmt.Errorf("%w: tenant opted out", errSkipped)
+	}
+
+	if m.Category == categoryMarketing && !s.Marketing {
+		return "", fmt.Errorf("%w: no consent to marketing", errSkipped)
+	}
+
+	if until, ok := quietUntil(s.QuietStart, s.QuietEnd, now.In(app.location)); ok {
+		return "", &deferredError{until: until}
+	}
+
+	for _, channel := range s.Channels {
+
+		switch channel {
+
+		case ChannelSMS, ChannelWhatsApp:
+			if app.sms == nil || s.Phone == "" {
+				continue
+			}
+			return app.sendSMS(ctx, sms.Message{Channel: channel, To: s.Phone, Body: m.Body})
+
+		case ChannelEmail:
+			if s.Email == "" {
+				continue
+			}
+			return "", app.mailer.Notice(ctx, mailer.NoticeData{
+				Email:   s.Email,
+				Content: mailer.Content{Subject: m.Subject, Text: m.Body},
+			})
+		}
+	}
+
+	return "", fmt.Errorf("%w: tenant cannot be reached on %s", errSkipped, strings.Join(s.Channels, ", "))
+}
+
+// sendSMS sends a text message and returns the provider's id for it. A
+// message the provider refused is undeliverable.
+func (app *application) sendSMS(ctx context.Context, msg sms.Message) (string, error) {
+
+	id, err := app.sms.Send(ctx, msg)
+
+	if err != nil {
+
+		var smsErr *sms.Error
+		if errors.As(err, &smsErr) && !smsErr.Temporary() {
+			return "", fmt.Errorf("%w: %v", errUndeliverable, err)
+		}
+
+		return "", err
+	}
+
+	return id, nil
+}
+
+// quietUntil reports whether now, in local time, falls in the quiet hours
+// from start to end, given in minutes after midnight, and if so when they
+// end. A window whose end is before its start runs past midnight.
+func quietUntil(start, end sql.NullInt16, now time.Time) (time.Time, bool) {
+
+	if !start.Valid || !end.Valid || start.Int16 == end.Int16 {
+		return time.Time{}, false
+	}
+
+	minute := int16(now.Hour()*60 + now.Minute())
+
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	endsToday := midnight.Add(time.Duration(end.Int16) * time.Minute)
+
+	if start.Int16 < end.Int16 {
+		return endsToday, minute >= start.Int16 && minute < end.Int16
+	}
+
+	switch {
+	case minute < end.Int16:
+		return endsToday, true
+	case minute >= start.Int16:
+		return midnight.AddDate(0, 0, 1).Add(time.Duration(end.Int16) * time.Minute), true
+	}
+
+	return time.Time{}, false
+}
+
+// quietHours is a quiet window as the API reads and writes it, in local
+// time, such as {"start": "21:00", "end": "07:00"}.
+type quietHours struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+type notificationPreference struct {
+	Channels   []string    `json:"channels"`
+	QuietHours *quietHours `json:"quiet_hours"`
+	Marketing  bool        `json:"marketing"`
+	OptedOut   bool        `json:"opted_out"`
+	OptedOutAt *time.Time  `json:"opted_out_at,omitempty"`
+}
+
+func formatMinutes(m int16) string {
+	return fmt.Sprintf("%02d:%02d", m/60, m%60)
+}
+
+func parseMinutes(s string) (int16, bool) {
+
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, false
+	}
+
+	return int16(t.Hour()*60 + t.Minute()), true
+}
+
+func preferenceFromSettings(s db.GetNotificationSettingsRow) notificationPreference {
+
+	p := notificationPreference{
+		Channels:  s.Channels,
+		Marketing: s.Marketing,
+		OptedOut:  s.OptedOutAt.Valid,
+	}
+
+	if s.QuietStart.Valid && s.QuietEnd.Valid {
+		p.QuietHours = &quietHours{Start: formatMinutes(s.QuietStart.Int16), End: formatMinutes(s.QuietEnd.Int16)}
+	}
+
+	if s.OptedOutAt.Valid {
+		p.OptedOutAt = &s.OptedOutAt.Time
+	}
+
+	return p
+}
+
+// tenantNotificationSettings returns the settings of the tenant in the
+// request's path, answering the request itself when it cannot.
+func (app *application) tenantNotificationSettings(c echo.Context) (db.GetNotificationSettingsRow, bool, error) {
+
+	id, err := db.ReadUUIDParam(c)
+
+	if err != nil {
+		return db.GetNotificationSettingsRow{}, false, c.JSON(http.StatusBadRequest, envelope{"error": "invalid tenant id"})
+	}
+
+	s, err := app.store.GetNotificationSettings(c.Request().Context(), db.GetNotificationSettingsParams{
+		ID:             id,
+		OrganizationID: organizationID(c),
+	})
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return s, false, c.JSON(http.StatusNotFound, envelope{"error": "tenant not found"})
+		default:
+			slog.Error("error fetching notification settings", "error", err)
+			return s, false, c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+		}
+	}
+
+	return s, true, nil
+}
+
+func (app *application) showNotificationPreferenceHandler(c echo.Context) error {
+
+	s, ok, err := app.tenantNotificationSettings(c)
+
+	if !ok {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, preferenceFromSettings(s))
+}
+
+// updateNotificationPreferenceHandler replaces a tenant's preference, as the
+// tenant asked for it, and records who made the change.
+func (app *application) updateNotificationPreferenceHandler(c echo.Context) error {
+
+	s, ok, err := app.tenantNotificationSettings(c)
+
+	if !ok {
+		return err
+	}
+
+	var input struct {
+		Channels   []string    `json:"channels" validate:"required,min=1"`
+		QuietHours *quietHours `json:"quiet_hours"`
+		Marketing  bool        `json:"marketing"`
+		OptedOut   bool        `json:"opted_out"`
+	}
+
+	if err := c.Bind(&input); err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": err.Error()})
+	}
+
+	if err := app.validator.Struct(input); err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": err.Error()})
+	}
+
+	for i, channel := range input.Channels {
+
+		if channel != ChannelSMS && channel != ChannelWhatsApp && channel != ChannelEmail {
+			return c.JSON(http.StatusBadRequest, envelope{"error": "channels must be sms, whatsapp or email"})
+		}
+
+		if slices.Contains(input.Channels[:i], channel) {
+			return c.JSON(http.StatusBadRequest, envelope{"error": "channels must not repeat"})
+		}
+	}
+
+	args := db.UpsertNotificationPreferenceParams{
+		TenantID:       s.TenantID,
+		OrganizationID: s.OrganizationID,
+		Channels:       input.Channels,
+		Marketing:      input.Marketing,
+		OptedOutAt:     s.OptedOutAt,
+	}
+
+	if input.QuietHours != nil {
+
+		start, ok := parseMinutes(input.QuietHours.Start)
+		end, ok2 := parseMinutes(input.QuietHours.End)
+
+		if !ok || !ok2 || start == end {
+			return c.JSON(http.StatusBadRequest, envelope{"error": "quiet_hours must have a different start and end, as HH:MM"})
+		}
+
+		args.QuietStart = sql.NullInt16{Int16: start, Valid: true}
+		args.QuietEnd = sql.NullInt16{Int16: end, Valid: true}
+	}
+
+	switch {
+	case !input.OptedOut:
+		args.OptedOutAt = sql.NullTime{}
+	case !s.OptedOutAt.Valid:
+		args.OptedOutAt = sql.NullTime{Time: time.Now(), Valid: true}
+	}
+
+	consent := consentRecord(args, consentAdmin, "")
+
+	// a request made with an API key also carries the key's admin, so the key
+	// is looked for first
+	if key, ok := c.Get("api_key").(db.GetApiKeyByHashRow); ok {
+		consent.AdminID = uuid.NullUUID{UUID: key.AdminID, Valid: true}
+		consent.Actor = key.Email
+		consent.Detail = "api key " + key.Name
+	} else if admin, ok := c.Get("admin").(db.GetHashTokenForAdminRow); ok {
+		consent.AdminID = uuid.NullUUID{UUID: admin.ID, Valid: true}
+		consent.Actor = admin.Email
+	}
+
+	if err := app.store.TxnSetNotificationPreference(c.Request().Context(), args, consent); err != nil {
+		slog.Error("error saving notification preference", "error", err)
+		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+	}
+
+	s.Channels, s.QuietStart, s.QuietEnd, s.Marketing, s.OptedOutAt = args.Channels, args.QuietStart, args.QuietEnd, args.Marketing, args.OptedOutAt
+
+	return c.JSON(http.StatusOK, preferenceFromSettings(s))
+}
+
+// listNotificationConsentsHandler lists every change to a tenant's
+// preference, newest first: what it was set to, by whom and when.
+func (app *application) listNotificationConsentsHandler(c echo.Context) error {
+
+	s, ok, err := app.tenantNotificationSettings(c)
+
+	if !ok {
+		return err
+	}
+
+	consents, err := app.store.GetNotificationConsents(c.Request().Context(), db.GetNotificationConsentsParams{
+		TenantID:       s.TenantID,
+		OrganizationID: s.OrganizationID,
+	})
+
+	if err != nil {
+		slog.Error("error fetching notification consents", "error", err)
+		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+	}
+
+	return c.JSON(http.StatusOK, consents)
+}
+
+func consentRecord(args db.UpsertNotificationPreferenceParams, source, detail string) db.CreateNotificationConsentParams {
+	return db.CreateNotificationConsentParams{
+		TenantID:       args.TenantID,
+		OrganizationID: args.OrganizationID,
+		Source:         source,
+		Detail:         detail,
+		Channels:       args.Channels,
+		QuietStart:     args.QuietStart,
+		QuietEnd:       args.QuietEnd,
+		Marketing:      args.Marketing,
+		OptedOut:       args.OptedOutAt.Valid,
+	}
+}
+
+// inboundSMSHandler takes messages tenants send in, as forwarded by the SMS
+// gateway with the sms-inbound-token to the address of the organization whose
+// number they texted. A message starting with an opt out keyword such as STOP
+// opts every tenant of that organization with the sender's number out of all
+// messages, and one starting with START opts them back in. Anything else is
+// ignored.
+func (app *application) inboundSMSHandler(c echo.Context) error {
+
+	token := app.config.sms.inboundToken
+
+	if token == "" {
+		return c.JSON(http.StatusNotFound, envelope{"error": "not found"})
+	}
+
+	got := strings.TrimPrefix(c.Request().Header.Get("Authorization"), "Bearer ")
+
+	if subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+		return c.JSON(http.StatusUnauthorized, envelope{"error": "invalid token"})
+	}
+
+	orgID, err := db.ReadUUIDParam(c)
+
+	if err != nil {
+		return c.JSON(http.StatusNotFound, envelope{"error": "not found"})
+	}
+
+	var input struct {
+		From    string `json:"from" validate:"required"`
+		Message string `json:"message"`
+	}
+
+	if err := c.Bind(&input); err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": err.Error()})
+	}
+
+	if err := app.validator.Struct(input); err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": err.Error()})
+	}
+
+	var keyword string
+
+	if words := strings.Fields(strings.ToUpper(input.Message)); len(words) > 0 {
+		keyword = strings.Trim(words[0], ".!")
+	}
+
+	var optOut bool
+
+	switch {
+	case slices.Contains(optOutKeywords, keyword):
+		optOut = true
+	case slices.Contains(optInKeywords, keyword):
+		optOut = false
+	default:
+		return c.JSON(http.StatusOK, envelope{"action": "none"})
+	}
+
+	ctx := c.Request().Context()
+
+	ids, err := app.store.GetTenantsByPhone(ctx, db.GetTenantsByPhoneParams{
+		OrganizationID: orgID,
+		Phone:          input.From,
+	})
+
+	if err != nil {
+		slog.Error("error fetching tenants by phone", "error", err)
+		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+	}
+
+	var changed int
+
+	for _, id := range ids {
+
+		s, err := app.store.GetNotificationSettings(ctx, db.GetNotificationSettingsParams{
+			ID:             id,
+			OrganizationID: orgID,
+		})
+
+		if err != nil {
+			slog.Error("error fetching notification settings", "error", err, "tenant_id", id)
+			return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+		}
+
+		if s.OptedOutAt.Valid == optOut {
+			continue
+		}
+
+		args := db.UpsertNotificationPreferenceParams{
+			TenantID:       s.TenantID,
+			OrganizationID: s.OrganizationID,
+			Channels:       s.Channels,
+			QuietStart:     s.QuietStart,
+			QuietEnd:       s.QuietEnd,
+			Marketing:      s.Marketing,
+		}
+
+		if optOut {
+			args.OptedOutAt = sql.NullTime{Time: time.Now(), Valid: true}
+		}
+
+		consent := consentRecord(args, consentKeyword, keyword)
+		consent.Actor = input.From
+
+		if err := app.store.TxnSetNotificationPreference(ctx, args, consent); err != nil {
+			slog.Error("error saving notification preference", "error", err, "tenant_id", id)
+			return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+		}
+
+		changed++
+	}
+
+	action := "opt_in"
+	if optOut {
+		action = "opt_out"
+	}
+
+	slog.Info("tenant notification keyword", "action", action, "tenants", changed)
+
+	return c.JSON(http.StatusOK, envelope{"action": action, "tenants": changed})
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+)
+
+func TestQuietUntil(t *testing.T) {
+
+	eat := time.FixedZone("EAT", 3*60*60)
+
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, time.March, day, hour, minute, 0, 0, eat)
+	}
+
+	minutes := func(hour, minute int) sql.NullInt16 {
+		return sql.NullInt16{Int16: int16(hour*60 + minute), Valid: true}
+	}
+
+	tests := []struct {
+		name       string
+		start, end sql.NullInt16
+		now        time.Time
+		want       time.Time
+		quiet      bool
+	}{
+		{"no window", sql.NullInt16{}, sql.NullInt16{}, at(14, 13, 0), time.Time{}, false},
+		{"start only", minutes(13, 0), sql.NullInt16{}, at(14, 13, 0), time.Time{}, false},
+		{"empty window", minutes(13, 0), minutes(13, 0), at(14, 13, 0), time.Time{}, false},
+
+		{"before a daytime window", minutes(13, 0), minutes(15, 0), at(14, 12, 59), time.Time{}, false},
+		{"at the start of a daytime window", minutes(13, 0), minutes(15, 0), at(14, 13, 0), at(14, 15, 0), true},
+		{"inside a daytime window", minutes(13, 0), minutes(15, 0), at(14, 14, 30), at(14, 15, 0), true},
+		{"at the end of a daytime window", minutes(13, 0), minutes(15, 0), at(14, 15, 0), time.Time{}, false},
+
+		{"evening inside a window across midnight", minutes(21, 0), minutes(7, 0), at(14, 22, 15), at(15, 7, 0), true},
+		{"at the start of a window across midnight", minutes(21, 0), minutes(7, 0), at(14, 21, 0), at(15, 7, 0), true},
+		{"morning inside a window across midnight", minutes(21, 0), minutes(7, 0), at(15, 6, 59), at(15, 7, 0), true},
+		{"at midnight inside a window across it", minutes(21, 0), minutes(7, 0), at(15, 0, 0), at(15, 7, 0), true},
+		{"at the end of a window across midnight", minutes(21, 0), minutes(7, 0), at(15, 7, 0), time.Time{}, false},
+		{"daytime outside a window across midnight", minutes(21, 0), minutes(7, 0), at(15, 12, 0), time.Time{}, false},
+		{"across the end of a month", minutes(21, 0), minutes(7, 0), at(31, 23, 0), time.Date(2026, time.April, 1, 7, 0, 0, 0, eat), true},
+	}
+
+	for _, tt := range tests {
+
+		got, quiet := quietUntil(tt.start, tt.end, tt.now)
+
+		// the time only says something while it is quiet
+		if quiet != tt.quiet || (quiet && !got.Equal(tt.want)) {
+			t.Errorf("%s: quietUntil at %s = %s, %t, want %s, %t", tt.name, tt.now.Format("15:04"), got, quiet, tt.want, tt.quiet)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"log/slog"
+	"math"
+	"net/http"
+	"slices"
+	"time"
+
+	db "github.com/Hopertz/rent/db/sqlc"
+	"github.com/labstack/echo/v4"
+)
+
+// runOccupancySnapshots records whether each house is let every
+// occupancy-snapshot-interval until ctx is cancelled. Each run overwrites the
+// day's snapshot, so the day ends with the last state seen.
+func (app *application) runOccupancySnapshots(ctx context.Context) {
+
+	if app.config.occupancy.snapshotInterval <= 0 {
+		slog.Info("occupancy snapshots disabled")
+		return
+	}
+
+	ticker := time.NewTicker(app.config.occupancy.snapshotInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := app.store.SnapshotOccupancy(ctx, app.today(time.Now())); err != nil && !errors.Is(err, context.Canceled) {
+			slog.Error("error snapshotting occupancy", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// occupancyRow is occupancy over one month, for a location, a block or every
+// house, depending on what the report is grouped by.
+type occupancyRow struct {
+	Month    string `json:"month"`
+	Location string `json:"location,omitempty"`
+	Block    string `json:"block,omitempty"`
+
+	// Houses is the average number of houses snapshotted each day.
+	Houses float64 `json:"houses"`
+
+	// OccupancyRate is the share of house days the houses were let.
+	OccupancyRate *float64 `json:"occupancy_rate"`
+
+	MoveIns  int64 `json:"move_ins"`
+	MoveOuts int64 `json:"move_outs"`
+
+	// TurnoverRate is move outs per house.
+	TurnoverRate *float64 `json:"turnover_rate"`
+
+	// Vacancies is how many vacancies ended in the month, and
+	// AverageVacancyDays how long they lasted on average.
+	Vacancies          int64    `json:"vacancies"`
+	AverageVacancyDays *float64 `json:"average_vacancy_days"`
+
+	days         int64
+	houseDays    int64
+	occupiedDays int64
+	vacantDays   int64
+}
+
+func (r *occupancyRow) compute() {
+
+	if r.days > 0 {
+		r.Houses = math.Round(float64(r.houseDays)/float64(r.days)*100) / 100
+	}
+
+	r.OccupancyRate = ratio(float64(r.occupiedDays), float64(r.houseDays))
+	r.TurnoverRate = ratio(float64(r.MoveOuts), r.Houses)
+	r.AverageVacancyDays = ratio(float64(r.vacantDays), float64(r.Vacancies))
+}
+
+// occupancyAnalyticsHandler reports, from the daily occupancy snapshots, the
+// occupancy rate, move ins and outs, turnover and how long vacancies lasted
+// in each month from from to to, grouped by group_by: month, location or
+// block (the default).
+func (app *application) occupancyAnalyticsHandler(c echo.Context) error {
+
+	from, to, err := app.readMonthRange(c)
+
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": err.Error()})
+	}
+
+	groupBy := c.QueryParam("group_by")
+
+	switch groupBy {
+	case "":
+		groupBy = groupByBlock
+	case groupByMonth, groupByLocation, groupByBlock:
+	default:
+		return c.JSON(http.StatusBadRequest, envelope{"error": "group_by must be month, location or block"})
+	}
+
+	lastDay := to.AddDate(0, 1, -1)
+
+	occupancy, err := app.store.GetOccupancyByMonth(c.Request().Context(), db.GetOccupancyByMonthParams{
+		OrganizationID: organizationID(c),
+		FromDay:        from,
+		ToDay:          lastDay,
+	})
+
+	if err != nil {
+		slog.Error("error fetching occupancy", "error", err)
+		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+	}
+
+	vacancies, err := app.store.GetEndedVacanciesByMonth(c.Request().Context(), db.GetEndedVacanciesByMonthParams{
+		OrganizationID: organizationID(c),
+		ToDay:          lastDay,
+		FromDay:        from,
+	})
+
+	if err != nil {
+		slog.Error("error fetching vacancies", "error", err)
+		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+	}
+
+	type groupKey struct {
+		month, location, block string
+	}
+
+	var (
+		rows  = []occupancyRow{}
+		index = map[groupKey]int{}
+	)
+
+	row := func(month time.Time, location, block string) *occupancyRow {
+
+		r := occupancyRow{Month: month.Format(monthLayout)}
+
+		switch groupBy {
+		case groupByBlock:
+			r.Block = block
+			fallthrough
+		case groupByLocation:
+			r.Location = location
+		}
+
+		key := groupKey{r.Month, r.Location, r.Block}
+
+		i, ok := index[key]
+
+		if !ok {
+			rows = append(rows, r)
+			i = len(rows) - 1
+			index[key] = i
+		}
+
+		return &rows[i]
+	}
+
+	for _, o := range occupancy {
+		r := row(o.Month, o.Location, o.Block)
+		r.days = max(r.days, o.Days)
+		r.houseDays += o.HouseDays
+		r.occupiedDays += o.OccupiedDays
+		r.MoveIns += o.MoveIns
+		r.MoveOuts += o.MoveOuts
+	}
+
+	for _, v := range vacancies {
+		r := row(v.Month, v.Location, v.Block)
+		r.Vacancies += v.Vacancies
+		r.vacantDays += v.VacantDays
+	}
+
+	for i := range rows {
+		rows[i].compute()
+	}
+
+	slices.SortStableFunc(rows, func(a, b occupancyRow) int {
+		return cmp.Or(
+			cmp.Compare(a.Month, b.Month),
+			cmp.Compare(a.Location, b.Location),
+			cmp.Compare(a.Block, b.Block),
+		)
+	})
+
+	return c.JSON(http.StatusOK, envelope{
+		"from":     from.Format(monthLayout),
+		"to":       to.Format(monthLayout),
+		"group_by": groupBy,
+		"rows":     rows,
+	})
+}
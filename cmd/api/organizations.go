@@ -0,0 +1,265 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	db "github.com/Hopertz/rent/db/sqlc"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+const headerOrganization = "X-Organization-ID"
+
+// requireOrganization picks the organization a request acts on and puts it
+// in the context. It comes from the X-Organization-ID header, or is implied
+// when the admin belongs to exactly one organization. An organization the
+// admin is not a member of is reported as not found, so its existence is not
+// leaked. Super users may act on any organization.
+func (app *application) requireOrganization(next echo.HandlerFunc) echo.HandlerFunc {
+
+	return func(c echo.Context) error {
+
+		admin := c.Get("admin").(db.GetHashTokenForAdminRow)
+
+		header := c.Request().Header.Get(headerOrganization)
+
+		if header == "" {
+
+			orgs, err := app.store.GetOrganizationsForAdmin(c.Request().Context(), admin.ID)
+
+			if err != nil {
+				slog.Error("error fetching organizations for admin", "error", err)
+				return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+			}
+
+			switch len(orgs) {
+			case 0:
+				return c.JSON(http.StatusForbidden, envelope{"error": "you are not a member of any organization"})
+			case 1:
+				c.Set("organization", orgs[0])
+				return next(c)
+			default:
+				return c.JSON(http.StatusBadRequest, envelope{"error": headerOrganization + " header is required"})
+			}
+		}
+
+		id, err := uuid.Parse(header)
+
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, envelope{"error": "invalid " + headerOrganization + " header"})
+		}
+
+		org, err := app.store.GetOrganizationForAdmin(c.Request().Context(), db.GetOrganizationForAdminParams{
+			ID:          id,
+			IsSuperUser: admin.IsSuperUser,
+			AdminID:     admin.ID,
+		})
+
+		if err != nil {
+			switch {
+			case errors.Is(err, sql.ErrNoRows):
+				return c.JSON(http.StatusNotFound, envelope{"error": "organization not found"})
+			default:
+				slog.Error("error fetching organization", "error", err)
+				return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+			}
+		}
+
+		c.Set("organization", org)
+
+		return next(c)
+	}
+}
+
+// organizationID returns the organization resolved by requireOrganization.
+func organizationID(c echo.Context) uuid.UUID {
+	return c.Get("organization").(db.Organization).ID
+}
+
+// listOrganizationsHandler lists the organizations the admin can act on.
+func (app *application) listOrganizationsHandler(c echo.Context) error {
+
+	admin := c.Get("admin").(db.GetHashTokenForAdminRow)
+
+	var (
+		orgs []db.Organization
+		err  error
+	)
+
+	if admin.IsSuperUser {
+		orgs, err = app.store.GetOrganizations(c.Request().Context())
+	} else {
+		orgs, err = app.store.GetOrganizationsForAdmin(c.Request().Context(), admin.ID)
+	}
+
+	if err != nil {
+		slog.Error("error fetching organizations", "error", err)
+		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+	}
+
+	return c.JSON(http.StatusOK, orgs)
+}
+
+func (app *application) createOrganizationHandler(c echo.Context) error {
+
+	var input struct {
+		Name string `json:"name" validate:"required,max=100"`
+	}
+
+	if err := c.Bind(&input); err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": err.Error()})
+	}
+
+	if err := app.validator.Struct(input); err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": err.Error()})
+	}
+
+	org, err := app.store.CreateOrganization(c.Request().Context(), strings.TrimSpace(input.Name))
+
+	if err != nil {
+		switch {
+		case db.IsUniqueViolation(err):
+			return c.JSON(http.StatusConflict, envelope{"error": "an organization with this name already exists"})
+		default:
+			slog.Error("error creating organization", "error", err)
+			return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+		}
+	}
+
+	return c.JSON(http.StatusCreated, org)
+}
+
+func (app *application) updateOrganizationHandler(c echo.Context) error {
+
+	id, err := db.ReadUUIDParam(c)
+
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": "invalid organization id"})
+	}
+
+	var input struct {
+		Name    string    `json:"name" validate:"required,max=100"`
+		Version uuid.UUID `json:"version" validate:"required"`
+	}
+
+	if err := c.Bind(&input); err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": err.Error()})
+	}
+
+	if err := app.validator.Struct(input); err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": err.Error()})
+	}
+
+	n, err := app.store.UpdateOrganization(c.Request().Context(), db.UpdateOrganizationParams{
+		Name:    strings.TrimSpace(input.Name),
+		ID:      id,
+		Version: input.Version,
+	})
+
+	if err != nil {
+		switch {
+		case db.IsUniqueViolation(err):
+			return c.JSON(http.StatusConflict, envelope{"error": "an organization with this name already exists"})
+		default:
+			slog.Error("error updating organization", "error", err)
+			return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+		}
+	}
+
+	if n == 0 {
+		return c.JSON(http.StatusConflict, envelope{"error": "unable to complete request due to an edit conflict"})
+	}
+
+	return c.JSON(http.StatusOK, nil)
+}
+
+func (app *application) listOrganizationMembersHandler(c echo.Context) error {
+
+	id, err := db.ReadUUIDParam(c)
+
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": "invalid organization id"})
+	}
+
+	members, err := app.store.GetOrganizationMembers(c.Request().Context(), id)
+
+	if err != nil {
+		slog.Error("error fetching organization members", "error", err)
+		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+	}
+
+	return c.JSON(http.StatusOK, members)
+}
+
+func (app *application) addOrganizationMemberHandler(c echo.Context) error {
+
+	id, err := db.ReadUUIDParam(c)
+
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": "invalid organization id"})
+	}
+
+	var input struct {
+		AdminID uuid.UUID `json:"admin_id" validate:"required"`
+	}
+
+	if err := c.Bind(&input); err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": err.Error()})
+	}
+
+	if err := app.validator.Struct(input); err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": err.Error()})
+	}
+
+	err = app.store.AddOrganizationMember(c.Request().Context(), db.AddOrganizationMemberParams{
+		AdminID:        input.AdminID,
+		OrganizationID: id,
+	})
+
+	if err != nil {
+		switch {
+		case db.IsForeignKeyViolation(err):
+			return c.JSON(http.StatusNotFound, envelope{"error": "admin or organization not found"})
+		default:
+			slog.Error("error adding organization member", "error", err)
+			return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+		}
+	}
+
+	return c.JSON(http.StatusOK, nil)
+}
+
+func (app *application) removeOrganizationMemberHandler(c echo.Context) error {
+
+	id, err := db.ReadUUIDParam(c)
+
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": "invalid organization id"})
+	}
+
+	adminID, err := uuid.Parse(c.QueryParam("admin_id"))
+
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": "invalid admin_id"})
+	}
+
+	n, err := app.store.RemoveOrganizationMember(c.Request().Context(), db.RemoveOrganizationMemberParams{
+		AdminID:        adminID,
+		OrganizationID: id,
+	})
+
+	if err != nil {
+		slog.Error("error removing organization member", "error", err)
+		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+	}
+
+	if n == 0 {
+		return c.JSON(http.StatusNotFound, envelope{"error": "admin is not a member of this organization"})
+	}
+
+	return c.JSON(http.StatusOK, nil)
+}
@@ -0,0 +1,334 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	db "github.com/Hopertz/rent/db/sqlc"
+	mailer "github.com/Hopertz/rent/pkg/Mailer"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// outboxLease is how long a claimed message is left alone before another
+	// dispatcher may take it, in case this one dies mid send. Messages are
+	// claimed one at a time, so it only has to outlast a single send with all
+	// of the mailer's own retries.
+	outboxLease = 5 * time.Minute
+
+	outboxRetryBase = 30 * time.Second
+	outboxRetryMax  = time.Hour
+)
+
+var outboxStats = expvar.NewMap("outbox")
+
+// errUndeliverable marks a message no number of retries will deliver.
+var errUndeliverable = errors.New("undeliverable message")
+
+// runOutboxDispatcher delivers queued messages every outbox-poll-interval
+// until ctx is cancelled. A message that fails is retried with exponential
+// backoff; after outbox-max-attempts, or straight away if retrying cannot
+// help, it is dead-lettered for a super user to inspect and replay. A message
+// to a tenant waits out their quiet hours, and is skipped when their
+// preferences rule it out. Delivered and skipped messages are purged after
+// outbox-retention.
+func (app *application) runOutboxDispatcher(ctx context.Context) {
+
+	if app.config.outbox.pollInterval <= 0 {
+		slog.Info("outbox dispatcher disabled")
+		return
+	}
+
+	ticker := time.NewTicker(app.config.outbox.pollInterval)
+	defer ticker.Stop()
+
+	var purged time.Time
+
+	for {
+		app.dispatchOutbox(ctx)
+
+		if time.Since(purged) > time.Hour {
+			app.purgeOutbox(ctx)
+			purged = time.Now()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// dispatchOutbox delivers the messages due until there are none left. Each
+// is claimed just before it is sent, so its lease starts with its own send
+// and not with those of the messages ahead of it.
+func (app *application) dispatchOutbox(ctx context.Context) {
+
+	for ctx.Err() == nil {
+
+		messages, err := app.store.ClaimOutboxMessages(ctx, db.ClaimOutboxMessagesParams{
+			LeaseUntil: time.Now().Add(outboxLease),
+			Limit:      1,
+		})
+
+		if err != nil {
+			if !errors.Is(err, context.Canceled) {
+				slog.Error("error claiming outbox messages", "error", err)
+			}
+			return
+		}
+
+		if len(messages) == 0 {
+			return
+		}
+
+		app.deliverOutboxMessage(ctx, messages[0])
+	}
+}
+
+func (app *application) deliverOutboxMessage(ctx context.Context, m db.ClaimOutboxMessagesRow) {
+
+	err := app.deliver(ctx, m.Kind, m.Payload)
+
+	// shutting down: the lease runs out and the message is sent after restart
+	if ctx.Err() != nil {
+		return
+	}
+
+	if err == nil {
+		if err := app.store.MarkOutboxMessageSent(ctx, m.ID); err != nil {
+			slog.Error("error marking outbox message sent", "error", err, "id", m.ID)
+		}
+		outboxStats.Add("sent", 1)
+		return
+	}
+
+	var deferred *deferredError
+
+	if errors.As(err, &deferred) {
+
+		err = app.store.DeferOutboxMessage(ctx, db.DeferOutboxMessageParams{
+			NextAttemptAt: deferred.until,
+			ID:            m.ID,
+		})
+
+		if err != nil {
+			slog.Error("error deferring outbox message", "error", err, "id", m.ID)
+		}
+
+		outboxStats.Add("deferred", 1)
+		return
+	}
+
+	if errors.Is(err, errSkipped) {
+
+		slog.Info("outbox message skipped", "reason", err, "id", m.ID, "kind", m.Kind)
+
+		err = app.store.MarkOutboxMessageSkipped(ctx, db.MarkOutboxMessageSkippedParams{
+			LastError: err.Error(),
+			ID:        m.ID,
+		})
+
+		if err != nil {
+			slog.Error("error marking outbox message skipped", "error", err, "id", m.ID)
+		}
+
+		outboxStats.Add("skipped", 1)
+		return
+	}
+
+	var mailErr *mailer.Error
+
+	permanent := errors.Is(err, errUndeliverable) || (errors.As(err, &mailErr) && !mailErr.Temporary())
+
+	if permanent || int(m.Attempts) >= app.config.outbox.maxAttempts {
+
+		slog.Error("outbox message dead-lettered", "error", err, "id", m.ID, "kind", m.Kind, "attempts", m.Attempts)
+
+		err = app.store.MarkOutboxMessageDead(ctx, db.MarkOutboxMessageDeadParams{
+			LastError: err.Error(),
+			ID:        m.ID,
+		})
+
+		if err != nil {
+			slog.Error("error dead-lettering outbox message", "error", err, "id", m.ID)
+		}
+
+		outboxStats.Add("dead", 1)
+		return
+	}
+
+	slog.Warn("outbox message failed, will retry", "error", err, "id", m.ID, "kind", m.Kind, "attempts", m.Attempts)
+
+	err = app.store.MarkOutboxMessageFailed(ctx, db.MarkOutboxMessageFailedParams{
+		LastError:     err.Error(),
+		NextAttemptAt: time.Now().Add(outboxRetryDelay(int(m.Attempts))),
+		ID:            m.ID,
+	})
+
+	if err != nil {
+		slog.Error("error rescheduling outbox message", "error", err, "id", m.ID)
+	}
+
+	outboxStats.Add("failed", 1)
+}
+
+// outboxRetryDelay is the wait after the given number of failed attempts.
+func outboxRetryDelay(attempts int) time.Duration {
+
+	d := outboxRetryBase
+
+	for i := 1; i < attempts && d < outboxRetryMax; i++ {
+		d *= 2
+	}
+
+	return min(d, outboxRetryMax)
+}
+
+// deliver sends one message according to its kind.
+func (app *application) deliver(ctx context.Context, kind string, payload json.RawMessage) error {
+
+	switch kind {
+	case mailer.PathActivation:
+		return deliverMail(ctx, payload, app.mailer.Activation)
+	case mailer.PathPasswordReset:
+		return deliverMail(ctx, payload, app.mailer.PasswordReset)
+	case mailer.PathPasswordResetComplete:
+		return deliverMail(ctx, payload, app.mailer.PasswordResetComplete)
+	case mailer.PathInvite:
+		return deliverMail(ctx, payload, app.mailer.Invite)
+	case mailer.PathLockout:
+		return deliverMail(ctx, payload, app.mailer.Lockout)
+	case mailer.PathNotice:
+		return deliverMail(ctx, payload, app.mailer.Notice)
+	case outboxTenantMessage:
+		return app.deliverTenantMessage(ctx, payload)
+	case outboxReminder:
+		return app.deliverReminder(ctx, payload)
+	}
+
+	return fmt.Errorf("%w: unknown kind %q", errUndeliverable, kind)
+}
+
+func deliverMail[T any](ctx context.Context, payload json.RawMessage, send func(context.Context, T) error) error {
+
+	var data T
+
+	if err := json.Unmarshal(payload, &data); err != nil {
+		return fmt.Errorf("%w: %v", errUndeliverable, err)
+	}
+
+	return send(ctx, data)
+}
+
+func (app *application) purgeOutbox(ctx context.Context) {
+
+	n, err := app.store.DeleteSentOutboxMessages(ctx, sql.NullTime{
+		Time:  time.Now().Add(-app.config.outbox.retention),
+		Valid: true,
+	})
+
+	if err != nil {
+		if !errors.Is(err, context.Canceled) {
+			slog.Error("error purging sent outbox messages", "error", err)
+		}
+		return
+	}
+
+	if n > 0 {
+		slog.Info("purged sent outbox messages", "count", n)
+	}
+}
+
+// listOutboxHandler pages through queued messages newest first, optionally
+// only those with the given status. Payloads are not returned: they may carry
+// tokens.
+func (app *application) listOutboxHandler(c echo.Context) error {
+
+	var args db.GetOutboxMessagesParams
+
+	switch status := c.QueryParam("status"); status {
+	case "":
+	case db.OutboxPending, db.OutboxSent, db.OutboxSkipped, db.OutboxDead:
+		args.Status = sql.NullString{String: status, Valid: true}
+	default:
+		return c.JSON(http.StatusBadRequest, envelope{"error": "status must be pending, sent, skipped or dead"})
+	}
+
+	if cursor := c.QueryParam("cursor"); cursor != "" {
+		id, err := strconv.ParseInt(cursor, 10, 64)
+		if err != nil || id < 1 {
+			return c.JSON(http.StatusBadRequest, envelope{"error": "invalid cursor"})
+		}
+		args.BeforeID = sql.NullInt64{Int64: id, Valid: true}
+	}
+
+	limit, err := readLimit(c)
+
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": err.Error()})
+	}
+
+	args.Limit = int32(limit) + 1
+
+	messages, err := app.store.GetOutboxMessages(c.Request().Context(), args)
+
+	if err != nil {
+		slog.Error("error fetching outbox messages", "error", err)
+		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+	}
+
+	if len(messages) > limit {
+		messages = messages[:limit]
+		c.Response().Header().Set(headerNextCursor, strconv.FormatInt(messages[limit-1].ID, 10))
+	}
+
+	return c.JSON(http.StatusOK, messages)
+}
+
+// replayOutboxMessageHandler queues a dead message again with a fresh set of
+// attempts.
+func (app *application) replayOutboxMessageHandler(c echo.Context) error {
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+
+	if err != nil || id < 1 {
+		return c.JSON(http.StatusBadRequest, envelope{"error": "invalid message id"})
+	}
+
+	n, err := app.store.ReplayOutboxMessage(c.Request().Context(), id)
+
+	if err != nil {
+		slog.Error("error replaying outbox message", "error", err)
+		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+	}
+
+	if n == 0 {
+		return c.JSON(http.StatusNotFound, envelope{"error": "no dead message with this id"})
+	}
+
+	return c.JSON(http.StatusOK, nil)
+}
+
+// replayDeadOutboxHandler queues every dead message again, for after an outage
+// of the mail service.
+func (app *application) replayDeadOutboxHandler(c echo.Context) error {
+
+	n, err := app.store.ReplayDeadOutboxMessages(c.Request().Context())
+
+	if err != nil {
+		slog.Error("error replaying outbox messages", "error", err)
+		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+	}
+
+	return c.JSON(http.StatusOK, envelope{"replayed": n})
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	db "github.com/Hopertz/rent/db/sqlc"
+	"github.com/Hopertz/rent/pkg/password"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+func (app *application) passwordPolicy() password.Policy {
+	return password.Policy{MinLength: app.config.auth.passwordMinLength}
+}
+
+// passwordReused reports whether plaintext is the admin's current password or
+// one of the ones it replaced, counting back password-history passwords in
+// all.
+func (app *application) passwordReused(ctx context.Context, id uuid.UUID, currentHash []byte, plaintext string) (bool, error) {
+
+	n := app.config.auth.passwordHistory
+
+	if n <= 0 {
+		return false, nil
+	}
+
+	hashes := [][]byte{currentHash}
+
+	if n > 1 {
+		previous, err := app.store.GetPasswordHistory(ctx, db.GetPasswordHistoryParams{
+			AdminID: id,
+			Limit:   int32(n - 1),
+		})
+
+		if err != nil {
+			return false, err
+		}
+
+		hashes = append(hashes, previous...)
+	}
+
+	return password.Reused(plaintext, hashes)
+}
+
+// updateMyPasswordHandler changes the signed in admin's password. Every other
+// session is signed out; the one making the request stays signed in. Wrong
+// current passwords count as failed logins, so a stolen access token cannot
+// be used to guess the password.
+func (app *application) updateMyPasswordHandler(c echo.Context) error {
+
+	admin := c.Get("admin").(db.GetHashTokenForAdminRow)
+
+	var input struct {
+		CurrentPassword string `json:"current_password" validate:"required"`
+		NewPassword     string `json:"new_password" validate:"required"`
+	}
+
+	if err := c.Bind(&input); err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": err.Error()})
+	}
+
+	if err := app.validator.Struct(input); err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": err.Error()})
+	}
+
+	wait, err := app.checkLoginThrottle(c.Request().Context(), admin.Email, c.RealIP())
+
+	if err != nil {
+		slog.Error("error checking login throttle", "error", err)
+		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+	}
+
+	if wait > 0 {
+		return tooManyLoginAttempts(c, wait)
+	}
+
+	match, err := db.PasswordMatches(db.Password{Hash: admin.PasswordHash, Plaintext: input.CurrentPassword})
+
+	if err != nil {
+		slog.Error("error matching password", "error", err)
+		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+	}
+
+	if !match {
+		app.recordLoginAttempt(c, admin.Email, uuid.NullUUID{UUID: admin.ID, Valid: true}, false)
+		return c.JSON(http.StatusForbidden, envelope{"error": "current password is incorrect"})
+	}
+
+	if err := app.passwordPolicy().Check(input.NewPassword); err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": err.Error()})
+	}
+
+	reused, err := app.passwordReused(c.Request().Context(), admin.ID, admin.PasswordHash, input.NewPassword)
+
+	if err != nil {
+		slog.Error("error checking password history", "error", err)
+		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+	}
+
+	if reused {
+		return c.JSON(http.StatusBadRequest, envelope{"error": "password was used recently, choose a different one"})
+	}
+
+	pwd, err := db.SetPassword(input.NewPassword)
+
+	if err != nil {
+		slog.Error("error generating hash password", "error", err)
+		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+	}
+
+	err = app.store.TxnChangePassword(c.Request().Context(), db.UpdateAdminParams{
+		Email:        admin.Email,
+		PasswordHash: pwd.Hash,
+		Activated:    admin.Activated,
+		ID:           admin.ID,
+		Version:      admin.Version,
+	}, admin.PasswordHash, admin.SessionID, auditEntry(c, db.AuditEntry{
+		Action:     auditPasswordChange,
+		EntityType: auditAdmin,
+		EntityID:   admin.ID,
+		Snapshot:   adminSnapshot,
+	}))
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return c.JSON(http.StatusConflict, envelope{"error": "unable to complete request due to an edit conflict"})
+		default:
+			slog.Error("error changing password", "error", err)
+			return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+		}
+	}
+
+	return c.JSON(http.StatusOK, nil)
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"cmp"
 	"database/sql"
 	"errors"
 	"log/slog"
@@ -8,13 +9,14 @@ import (
 	"time"
 
 	db "github.com/Hopertz/rent/db/sqlc"
+	"github.com/Hopertz/rent/pkg/templates"
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 )
 
 func (app *application) listPaymentsHandler(c echo.Context) error {
 
-	payments, err := app.store.GetAllPayments(c.Request().Context())
+	payments, err := app.store.GetAllPayments(c.Request().Context(), organizationID(c))
 	if err != nil {
 		slog.Error("error fetching payments", "err", err)
 		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
@@ -31,7 +33,10 @@ func (app *application) showPaymentHandler(c echo.Context) error {
 		return err
 	}
 
-	payment, err := app.store.GetDetailedPaymentById(c.Request().Context(), uuid)
+	payment, err := app.store.GetDetailedPaymentById(c.Request().Context(), db.GetDetailedPaymentByIdParams{
+		ID:             uuid,
+		OrganizationID: organizationID(c),
+	})
 
 	if err != nil {
 		switch {
@@ -70,7 +75,10 @@ func (app *application) createPaymentHandler(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, envelope{"error": err.Error()})
 	}
 
-	tenant, err := app.store.GetTenantById(c.Request().Context(), input.TenantId)
+	tenant, err := app.store.GetTenantById(c.Request().Context(), db.GetTenantByIdParams{
+		ID:             input.TenantId,
+		OrganizationID: organizationID(c),
+	})
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -82,14 +90,51 @@ func (app *application) createPaymentHandler(c echo.Context) error {
 
 	}
 
-	err = app.store.CreatePayment(c.Request().Context(), db.CreatePaymentParams{
-		TenantID:  tenant.HouseID,
-		Amount:    input.Amount,
-		StartDate: input.StartDate,
-		EndDate:   input.EndDate,
-		CreatedBy: admin.ID,
+	var messages []db.OutboxMessage
+
+	if (app.sms != nil && tenant.Phone != "") || tenant.Email != "" {
+
+		msg, err := app.templates.Render(tenant.PreferredLanguage, templates.PaymentReceipt, map[string]any{
+			"Name":      tenant.Name,
+			"House":     houseName(tenant.Location, tenant.Block, tenant.Partition),
+			"Amount":    int64(input.Amount),
+			"StartDate": input.StartDate,
+			"EndDate":   input.EndDate,
+		})
+
+		// the payment matters more than its receipt
+		if err != nil {
+			slog.Error("error rendering payment receipt", "error", err)
+		} else {
+			messages = append(messages, db.OutboxMessage{
+				Kind:      outboxTenantMessage,
+				Recipient: cmp.Or(tenant.Phone, tenant.Email),
+				Payload: tenantMessage{
+					TenantID:       tenant.TenantID,
+					OrganizationID: organizationID(c),
+					Category:       categoryTransactional,
+					Subject:        msg.Subject,
+					Body:           msg.Text,
+				},
+			})
+		}
+	}
+
+	entry := auditEntry(c, db.AuditEntry{
+		Action:     auditCreate,
+		EntityType: auditPayment,
+		Snapshot:   paymentSnapshot(organizationID(c)),
 	})
 
+	_, err = app.store.TxnCreatePayment(c.Request().Context(), db.CreatePaymentParams{
+		TenantID:       tenant.TenantID,
+		Amount:         input.Amount,
+		StartDate:      input.StartDate,
+		EndDate:        input.EndDate,
+		CreatedBy:      admin.ID,
+		OrganizationID: organizationID(c),
+	}, entry, messages...)
+
 	if err != nil {
 		slog.Error("error creating payment", "err", err)
 		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
@@ -100,13 +145,16 @@ func (app *application) createPaymentHandler(c echo.Context) error {
 
 func (app *application) updatePaymentHandler(c echo.Context) error {
 
-	uuid, err := db.ReadUUIDParam(c)
+	id, err := db.ReadUUIDParam(c)
 
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, envelope{"error": "invalid payment id"})
 	}
 
-	payment, err := app.store.GetPaymentById(c.Request().Context(), uuid)
+	payment, err := app.store.GetPaymentById(c.Request().Context(), db.GetPaymentByIdParams{
+		ID:             id,
+		OrganizationID: organizationID(c),
+	})
 
 	if err != nil {
 		switch {
@@ -144,18 +192,40 @@ func (app *application) updatePaymentHandler(c echo.Context) error {
 	}
 
 	args := db.UpdatePaymentParams{
-		ID:        payment.ID,
-		Amount:    payment.Amount,
-		StartDate: payment.StartDate,
-		EndDate:   payment.EndDate,
-		Version:   payment.Version,
+		ID:             payment.ID,
+		Amount:         payment.Amount,
+		StartDate:      payment.StartDate,
+		EndDate:        payment.EndDate,
+		Version:        payment.Version,
+		OrganizationID: organizationID(c),
 	}
 
-	err = app.store.UpdatePayment(c.Request().Context(), args)
+	entry := auditEntry(c, db.AuditEntry{
+		Action:     auditUpdate,
+		EntityType: auditPayment,
+		EntityID:   payment.ID,
+		Snapshot:   paymentSnapshot(organizationID(c)),
+	})
+
+	_, err = app.store.TxnAudit(c.Request().Context(), entry, func(q *db.Queries) (uuid.UUID, error) {
+
+		n, err := q.UpdatePayment(c.Request().Context(), args)
+
+		if err == nil && n == 0 {
+			err = db.ErrEditConflict
+		}
+
+		return uuid.Nil, err
+	})
 
 	if err != nil {
-		slog.Error("error updating payment", "error", err)
-		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+		switch {
+		case errors.Is(err, db.ErrEditConflict):
+			return c.JSON(http.StatusConflict, envelope{"error": "unable to complete request due to an edit conflict"})
+		default:
+			slog.Error("error updating payment", "error", err)
+			return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+		}
 	}
 
 	return c.JSON(http.StatusOK, nil)
@@ -164,13 +234,16 @@ func (app *application) updatePaymentHandler(c echo.Context) error {
 
 func (app *application) deletePaymentHandler(c echo.Context) error {
 
-	uuid, err := db.ReadUUIDParam(c)
+	id, err := db.ReadUUIDParam(c)
 
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, envelope{"error": "invalid payment id"})
 	}
 
-	payment, err := app.store.GetPaymentById(c.Request().Context(), uuid)
+	payment, err := app.store.GetPaymentById(c.Request().Context(), db.GetPaymentByIdParams{
+		ID:             id,
+		OrganizationID: organizationID(c),
+	})
 
 	if err != nil {
 		switch {
@@ -185,7 +258,19 @@ func (app *application) deletePaymentHandler(c echo.Context) error {
 
 	}
 
-	err = app.store.DeletePayment(c.Request().Context(), payment.ID)
+	entry := auditEntry(c, db.AuditEntry{
+		Action:     auditDelete,
+		EntityType: auditPayment,
+		EntityID:   payment.ID,
+		Snapshot:   paymentSnapshot(organizationID(c)),
+	})
+
+	_, err = app.store.TxnAudit(c.Request().Context(), entry, func(q *db.Queries) (uuid.UUID, error) {
+		return uuid.Nil, q.DeletePayment(c.Request().Context(), db.DeletePaymentParams{
+			ID:             payment.ID,
+			OrganizationID: organizationID(c),
+		})
+	})
 
 	if err != nil {
 		slog.Error("error deleting payment", "error", err)
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+
+	db "github.com/Hopertz/rent/db/sqlc"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	PermHousesRead       = "houses:read"
+	PermHousesWrite      = "houses:write"
+	PermHousesDelete     = "houses:delete"
+	PermTenantsRead      = "tenants:read"
+	PermTenantsWrite     = "tenants:write"
+	PermPaymentsRead     = "payments:read"
+	PermPaymentsWrite    = "payments:write"
+	PermPaymentsDelete   = "payments:delete"
+	PermInspectionsRead  = "inspections:read"
+	PermInspectionsWrite = "inspections:write"
+	PermListingsWrite    = "listings:write"
+	PermAuditRead        = "audit:read"
+)
+
+// rolePermissions lists what each role may do. Super users are not bound by
+// their role and hold every permission. Owners hold every permission too, so
+// their list is also the list of all permissions.
+var rolePermissions = map[string][]string{
+	db.RoleOwner: {
+		PermHousesRead, PermHousesWrite, PermHousesDelete,
+		PermTenantsRead, PermTenantsWrite,
+		PermPaymentsRead, PermPaymentsWrite, PermPaymentsDelete,
+		PermInspectionsRead, PermInspectionsWrite,
+		PermListingsWrite,
+		PermAuditRead,
+	},
+	db.RoleManager: {
+		PermHousesRead, PermHousesWrite,
+		PermTenantsRead, PermTenantsWrite,
+		PermPaymentsRead, PermPaymentsWrite,
+		PermInspectionsRead, PermInspectionsWrite,
+		PermListingsWrite,
+	},
+	db.RoleAccountant: {
+		PermHousesRead,
+		PermTenantsRead,
+		PermPaymentsRead, PermPaymentsWrite, PermPaymentsDelete,
+	},
+	db.RoleCaretaker: {
+		PermHousesRead,
+		PermTenantsRead,
+		PermInspectionsRead, PermInspectionsWrite,
+	},
+}
+
+func validPermission(permission string) bool {
+	return slices.Contains(rolePermissions[db.RoleOwner], permission)
+}
+
+func validRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+func hasPermission(admin db.GetHashTokenForAdminRow, permission string) bool {
+	return roleHasPermission(admin.IsSuperUser, admin.Role, permission)
+}
+
+func roleHasPermission(isSuperUser bool, role, permission string) bool {
+	if isSuperUser {
+		return true
+	}
+	return slices.Contains(rolePermissions[role], permission)
+}
+
+// permitted reports whether the request may do what permission allows, by
+// the admin's role and, for API keys, the key's scopes.
+func permitted(c echo.Context, permission string) bool {
+
+	if key, ok := c.Get("api_key").(db.GetApiKeyByHashRow); ok && !slices.Contains(key.Scopes, permission) {
+		return false
+	}
+
+	return hasPermission(c.Get("admin").(db.GetHashTokenForAdminRow), permission)
+}
+
+// requirePermission checks that the admin's role grants permission and, for
+// API keys, that the key's scopes include it too. While the security policy
+// requires two-factor authentication, admins without it can only reach the
+// enrolment routes. Every route guarded by a permission acts on one
+// organization, resolved by requireOrganization.
+func (app *application) requirePermission(permission string) echo.MiddlewareFunc {
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+
+		return app.requireActivatedAdmin(func(c echo.Context) error {
+
+			admin := c.Get("admin").(db.GetHashTokenForAdminRow)
+
+			if key, ok := c.Get("api_key").(db.GetApiKeyByHashRow); ok && !slices.Contains(key.Scopes, permission) {
+				return c.JSON(http.StatusForbidden, envelope{"error": "api key scope does not permit this action"})
+			}
+
+			if admin.TotpRequired && !admin.TotpEnabled {
+				return c.JSON(http.StatusForbidden, envelope{"error": "two-factor authentication must be enabled for your account"})
+			}
+
+			if !hasPermission(admin, permission) {
+				return c.JSON(http.StatusForbidden, envelope{"error": "your role does not permit this action"})
+			}
+
+			return app.requireOrganization(next)(c)
+		})
+	}
+}
+
+func (app *application) requireSuperUser(next echo.HandlerFunc) echo.HandlerFunc {
+
+	return app.requireAuthenticatedAdmin(func(c echo.Context) error {
+
+		admin := c.Get("admin").(db.GetHashTokenForAdminRow)
+
+		if admin.TotpRequired && !admin.TotpEnabled {
+			return c.JSON(http.StatusForbidden, envelope{"error": "two-factor authentication must be enabled for your account"})
+		}
+
+		if !admin.IsSuperUser {
+			return c.JSON(http.StatusForbidden, envelope{"error": "super user access required"})
+		}
+
+		return next(c)
+	})
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	db "github.com/Hopertz/rent/db/sqlc"
+	"github.com/Hopertz/rent/pkg/templates"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	ReminderRentDue     = "rent_due"
+	ReminderLeaseExpiry = "lease_expiry"
+
+	// outboxReminder is the outbox kind of a reminder to a tenant.
+	outboxReminder = "reminder"
+)
+
+// reminderTemplates maps each kind of reminder to its template.
+var reminderTemplates = map[string]string{
+	ReminderRentDue:     templates.RentDue,
+	ReminderLeaseExpiry: templates.LeaseExpiry,
+}
+
+// reminderMessage is the outbox payload of a reminder, a message to a tenant
+// whose outcome is recorded on the reminder.
+type reminderMessage struct {
+	ReminderID uuid.UUID `json:"reminder_id"`
+	tenantMessage
+}
+
+// parseOffsets reads a comma separated list of days, such as "7,3,0", and
+// returns them largest first.
+func parseOffsets(s string) ([]int, error) {
+
+	var offsets []int
+
+	for _, f := range strings.Split(s, ",") {
+
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+
+		n, err := strconv.Atoi(f)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid offset %q: must be a whole number of days", f)
+		}
+
+		offsets = append(offsets, n)
+	}
+
+	slices.Sort(offsets)
+	offsets = slices.Compact(offsets)
+	slices.Reverse(offsets)
+
+	return offsets, nil
+}
+
+// runReminderScheduler queues due reminders every reminder-interval until ctx
+// is cancelled.
+func (app *application) runReminderScheduler(ctx context.Context) {
+
+	if app.config.reminders.interval <= 0 || len(app.config.reminders.offsets) == 0 {
+		slog.Info("reminder scheduler disabled")
+		return
+	}
+
+	ticker := time.NewTicker(app.config.reminders.interval)
+	defer ticker.Stop()
+
+	for {
+		app.scheduleReminders(ctx, time.Now())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// scheduleReminders queues a reminder for every active tenant whose paid
+// through date or end of stay is at most the largest offset away.
+//
+// Of the offsets already reached only the closest to the date is sent, so a
+// scheduler that was down over the 7 day mark does not send the 7 and the 3
+// day reminders together. Each reminder is recorded once per tenant, date and
+// offset, however often this runs.
+func (app *application) scheduleReminders(ctx context.Context, now time.Time) {
+
+	offsets := app.config.reminders.offsets
+
+	today := app.today(now)
+
+	tenants, err := app.store.GetReminderCandidates(ctx, db.GetReminderCandidatesParams{
+		FromDate: today,
+		ToDate:   today.AddDate(0, 0, offsets[0]),
+	})
+
+	if err != nil {
+		if !errors.Is(err, context.Canceled) {
+			slog.Error("error fetching reminder candidates", "error", err)
+		}
+		return
+	}
+
+	var queued int
+
+	for _, t := range tenants {
+
+		due := map[string]time.Time{ReminderLeaseExpiry: t.Eos}
+
+		if t.PaidThrough.Valid {
+			due[ReminderRentDue] = t.PaidThrough.Time
+		}
+
+		for kind, date := range due {
+
+			left := int(date.Sub(today).Hours() / 24)
+
+			offset, ok := reminderOffset(offsets, left)
+			if !ok {
+				continue
+			}
+
+			msg, err := app.templates.Render(t.PreferredLanguage, reminderTemplates[kind], map[string]any{
+				"Name":  t.Name,
+				"House": houseName(t.Location, t.Block, t.Partition),
+				"Date":  date,
+				"Left":  left,
+			})
+
+			if err != nil {
+				slog.Error("error rendering reminder", "error", err, "tenant_id", t.ID, "kind", kind)
+				continue
+			}
+
+			args := db.CreateReminderParams{
+				OrganizationID: t.OrganizationID,
+				TenantID:       t.ID,
+				Kind:           kind,
+				DueDate:        date,
+				DaysBefore:     int32(offset),
+				Phone:          t.Phone,
+				Body:           msg.Text,
+			}
+
+			created, err := app.store.TxnCreateReminder(ctx, args, func(id uuid.UUID) db.OutboxMessage {
+				return db.OutboxMessage{
+					Kind:      outboxReminder,
+					Recipient: cmp.Or(t.Phone, t.Email),
+					Payload: reminderMessage{
+						ReminderID: id,
+						tenantMessage: tenantMessage{
+							TenantID:       t.ID,
+							OrganizationID: t.OrganizationID,
+							Category:       categoryReminder,
+							Subject:        msg.Subject,
+							Body:           msg.Text,
+						},
+					},
+				}
+			})
+
+			if err != nil {
+				slog.Error("error queueing reminder", "error", err, "tenant_id", t.ID, "kind", kind)
+				continue
+			}
+
+			if created {
+				queued++
+			}
+		}
+	}
+
+	if queued > 0 {
+		slog.Info("queued reminders", "count", queued)
+	}
+}
+
+// reminderOffset returns the offset, from offsets sorted largest first, whose
+// reminder is due when left days remain: the smallest one not below left.
+func reminderOffset(offsets []int, left int) (int, bool) {
+
+	if left < 0 {
+		return 0, false
+	}
+
+	for i := len(offsets) - 1; i >= 0; i-- {
+		if offsets[i] >= left {
+			return offsets[i], true
+		}
+	}
+
+	return 0, false
+}
+
+// houseName is how a house is named in messages to its tenant.
+func houseName(location, block string, partition int16) string {
+	return fmt.Sprintf("%s %s%d", location, block, partition)
+}
+
+// deliverReminder sends a queued reminder and records the outcome on it. A
+// reminder held back for quiet hours is left as it is.
+func (app *application) deliverReminder(ctx context.Context, payload json.RawMessage) error {
+
+	var r reminderMessage
+
+	if err := json.Unmarshal(payload, &r); err != nil {
+		return fmt.Errorf("%w: %v", errUndeliverable, err)
+	}
+
+	id, err := app.sendToTenant(ctx, r.tenantMessage, time.Now())
+
+	var deferred *deferredError
+
+	switch {
+
+	case err == nil:
+		serr := app.store.MarkReminderSent(ctx, db.MarkReminderSentParams{
+			ProviderID: id,
+			ID:         r.ReminderID,
+		})
+		if serr != nil {
+			slog.Error("error recording reminder sent", "error", serr, "id", r.ReminderID)
+		}
+
+	case ctx.Err() != nil, errors.As(err, &deferred):
+
+	case errors.Is(err, errSkipped):
+		serr := app.store.MarkReminderSkipped(ctx, db.MarkReminderSkippedParams{
+			Error: err.Error(),
+			ID:    r.ReminderID,
+		})
+		if serr != nil {
+			slog.Error("error recording reminder skipped", "error", serr, "id", r.ReminderID)
+		}
+
+	default:
+		ferr := app.store.MarkReminderFailed(ctx, db.MarkReminderFailedParams{
+			Error: err.Error(),
+			ID:    r.ReminderID,
+		})
+		if ferr != nil {
+			slog.Error("error recording reminder failure", "error", ferr, "id", r.ReminderID)
+		}
+	}
+
+	return err
+}
+
+// listTenantRemindersHandler lists the reminders sent to a tenant, newest
+// first, with their delivery status.
+func (app *application) listTenantRemindersHandler(c echo.Context) error {
+
+	id, err := db.ReadUUIDParam(c)
+
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": "invalid tenant id"})
+	}
+
+	reminders, err := app.store.GetRemindersForTenant(c.Request().Context(), db.GetRemindersForTenantParams{
+		TenantID:       id,
+		OrganizationID: organizationID(c),
+	})
+
+	if err != nil {
+		slog.Error("error fetching reminders", "error", err)
+		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+	}
+
+	return c.JSON(http.StatusOK, reminders)
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	db "github.com/Hopertz/rent/db/sqlc"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	defaultReportMonths = 12
+	maxReportMonths     = 60
+
+	monthLayout = "2006-01"
+)
+
+// What an income report is grouped by within each month, from the coarsest.
+const (
+	groupByMonth    = "month"
+	groupByLocation = "location"
+	groupByBlock    = "block"
+	groupByHouse    = "house"
+)
+
+// incomeRow is the rent expected and collected in one month, for a location,
+// a block, a house or everything, depending on what the report is grouped by.
+type incomeRow struct {
+	Month     string     `json:"month,omitempty"`
+	Location  string     `json:"location,omitempty"`
+	Block     string     `json:"block,omitempty"`
+	HouseID   *uuid.UUID `json:"house_id,omitempty"`
+	House     string     `json:"house,omitempty"`
+	Expected  int64      `json:"expected"`
+	Collected int64      `json:"collected"`
+
+	// Shortfall is what was expected but not collected, negative when more
+	// was collected, such as rent paid in advance.
+	Shortfall int64 `json:"shortfall"`
+
+	// CollectionRate is collected over expected, null when nothing was
+	// expected.
+	CollectionRate *float64 `json:"collection_rate"`
+}
+
+func (r *incomeRow) add(expected, collected int64) {
+
+	r.Expected += expected
+	r.Collected += collected
+	r.Shortfall = r.Expected - r.Collected
+	r.CollectionRate = ratio(float64(r.Collected), float64(r.Expected))
+}
+
+// readMonthRange reads the from and to query parameters, months such as
+// 2025-01, both included. They default to the last 12 months up to the
+// current one.
+func (app *application) readMonthRange(c echo.Context) (time.Time, time.Time, error) {
+
+	today := app.today(time.Now())
+
+	to := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	if v := c.QueryParam("to"); v != "" {
+		t, err := time.Parse(monthLayout, v)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("to must be a month such as 2025-01")
+		}
+		to = t
+	}
+
+	from := to.AddDate(0, 1-defaultReportMonths, 0)
+
+	if v := c.QueryParam("from"); v != "" {
+		t, err := time.Parse(monthLayout, v)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("from must be a month such as 2025-01")
+		}
+		from = t
+	}
+
+	if from.After(to) {
+		return time.Time{}, time.Time{}, fmt.Errorf("from must not be after to")
+	}
+
+	if from.AddDate(0, maxReportMonths, 0).Before(to.AddDate(0, 1, 0)) {
+		return time.Time{}, time.Time{}, fmt.Errorf("a report may cover at most %d months", maxReportMonths)
+	}
+
+	return from, to, nil
+}
+
+// incomeReportHandler reports rent collected each month against the rent
+// expected from the prices of the houses let that month, grouped by
+// group_by: month, location, block (the default) or house. It is returned as
+// JSON, or as a CSV file with format=csv.
+func (app *application) incomeReportHandler(c echo.Context) error {
+
+	from, to, err := app.readMonthRange(c)
+
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": err.Error()})
+	}
+
+	groupBy := c.QueryParam("group_by")
+
+	switch groupBy {
+	case "":
+		groupBy = groupByBlock
+	case groupByMonth, groupByLocation, groupByBlock, groupByHouse:
+	default:
+		return c.JSON(http.StatusBadRequest, envelope{"error": "group_by must be month, location, block or house"})
+	}
+
+	format := c.QueryParam("format")
+
+	if format != "" && format != "json" && format != "csv" {
+		return c.JSON(http.StatusBadRequest, envelope{"error": "format must be json or csv"})
+	}
+
+	houses, err := app.store.GetIncomeByHouse(c.Request().Context(), db.GetIncomeByHouseParams{
+		FromMonth:      from,
+		ToMonth:        to,
+		OrganizationID: organizationID(c),
+		TimeZone:       app.location.String(),
+	})
+
+	if err != nil {
+		slog.Error("error fetching income report", "error", err)
+		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+	}
+
+	rows, total := groupIncome(houses, groupBy)
+
+	if format == "csv" {
+		return writeIncomeCSV(c, rows, groupBy, fmt.Sprintf("income-%s-%s.csv", from.Format(monthLayout), to.Format(monthLayout)))
+	}
+
+	return c.JSON(http.StatusOK, envelope{
+		"from":     from.Format(monthLayout),
+		"to":       to.Format(monthLayout),
+		"group_by": groupBy,
+		"rows":     rows,
+		"total":    total,
+	})
+}
+
+// groupIncome sums the income of each house and month into rows grouped by
+// groupBy, in the order the houses come in, and a total of them all.
+func groupIncome(houses []db.GetIncomeByHouseRow, groupBy string) ([]incomeRow, incomeRow) {
+
+	type groupKey struct {
+		month, location, block, house string
+	}
+
+	var (
+		rows  = []incomeRow{}
+		index = map[groupKey]int{}
+		total incomeRow
+	)
+
+	for _, h := range houses {
+
+		row := incomeRow{Month: h.Month.Format(monthLayout)}
+
+		switch groupBy {
+		case groupByHouse:
+			row.HouseID = &h.HouseID
+			row.House = houseName(h.Location, h.Block, h.Partition)
+			fallthrough
+		case groupByBlock:
+			row.Block = h.Block
+			fallthrough
+		case groupByLocation:
+			row.Location = h.Location
+		}
+
+		key := groupKey{row.Month, row.Location, row.Block, row.House}
+
+		i, ok := index[key]
+
+		if !ok {
+			rows = append(rows, row)
+			i = len(rows) - 1
+			index[key] = i
+		}
+
+		rows[i].add(h.Expected, h.Collected)
+		total.add(h.Expected, h.Collected)
+	}
+
+	return rows, total
+}
+
+func writeIncomeCSV(c echo.Context, rows []incomeRow, groupBy, filename string) error {
+
+	header := []any{"month"}
+
+	switch groupBy {
+	case groupByLocation:
+		header = append(header, "location")
+	case groupByBlock:
+		header = append(header, "location", "block")
+	case groupByHouse:
+		header = append(header, "location", "block", "house_id", "house")
+	}
+
+	header = append(header, "expected", "collected", "shortfall", "collection_rate")
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/csv; charset=utf-8")
+	res.Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", filename))
+	res.WriteHeader(http.StatusOK)
+
+	w := csvWriter{csv.NewWriter(res)}
+
+	if err := w.WriteRow(header...); err != nil {
+		return err
+	}
+
+	for _, r := range rows {
+
+		record := []any{r.Month}
+
+		switch groupBy {
+		case groupByLocation:
+			record = append(record, r.Location)
+		case groupByBlock:
+			record = append(record, r.Location, r.Block)
+		case groupByHouse:
+			record = append(record, r.Location, r.Block, r.HouseID.String(), r.House)
+		}
+
+		var rate any
+		if r.CollectionRate != nil {
+			rate = strconv.FormatFloat(*r.CollectionRate, 'f', -1, 64)
+		}
+
+		record = append(record, r.Expected, r.Collected, r.Shortfall, rate)
+
+		if err := w.WriteRow(record...); err != nil {
+			return err
+		}
+	}
+
+	return w.Flush()
+}
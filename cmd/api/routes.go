@@ -12,11 +12,13 @@ import (
 func (app *application) routes() http.Handler {
 
 	e := echo.New()
+	e.IPExtractor = app.ipExtractor()
 
 	DefaultCORSConfig := middleware.CORSConfig{
-		Skipper:      middleware.DefaultSkipper,
-		AllowOrigins: []string{"*"},
-		AllowMethods: []string{http.MethodGet, http.MethodHead, http.MethodPut, http.MethodPatch, http.MethodPost, http.MethodDelete},
+		Skipper:       middleware.DefaultSkipper,
+		AllowOrigins:  []string{"*"},
+		AllowMethods:  []string{http.MethodGet, http.MethodHead, http.MethodPut, http.MethodPatch, http.MethodPost, http.MethodDelete},
+		ExposeHeaders: []string{headerNextCursor},
 	}
 
 	config := middleware.RateLimiterConfig{
@@ -36,11 +38,24 @@ func (app *application) routes() http.Handler {
 		},
 	}
 
+	publicConfig := middleware.RateLimiterConfig{
+		Skipper: middleware.DefaultSkipper,
+		Store: middleware.NewRateLimiterMemoryStoreWithConfig(
+			middleware.RateLimiterMemoryStoreConfig{Rate: 1, Burst: 5, ExpiresIn: 3 * time.Minute},
+		),
+		IdentifierExtractor: config.IdentifierExtractor,
+		ErrorHandler:        config.ErrorHandler,
+		DenyHandler:         config.DenyHandler,
+	}
+
 	e.Use(middleware.Logger())
 	e.Use(middleware.Recover())
 	e.Use(middleware.RateLimiterWithConfig(config))
 	e.Use(middleware.CORSWithConfig(DefaultCORSConfig))
-	e.Use(middleware.BodyLimit("2K"))
+	e.Use(bodyLimit("2K", map[string]string{
+		"/v1/auth/bulk/houses":              "2M",
+		"/v1/auth/houses/:uuid/inspections": "64K",
+	}))
 
 	e.GET("/v1/ping", app.ping)
 
@@ -49,40 +64,136 @@ func (app *application) routes() http.Handler {
 	e.POST("/v1/admins", app.registerAdminHandler)
 	e.PUT("/v1/admins/activate", app.activateAdminHandler)
 	e.POST("/v1/login", app.createAuthenticationTokenHandler)
+	e.POST("/v1/login/totp", app.loginTotpHandler)
+	e.POST("/v1/tokens/refresh", app.refreshTokenHandler)
 	e.POST("/v1/tokens/resend/activation", app.resendActivationTokenHandler)
 
 	// password management
 	e.POST("/v1/tokens/password/reset", app.createPasswordResetTokenHandler)
 	e.PUT("/v1/admins/password/reset", app.updateAdminPasswordOnResetHandler)
 
+	// replies from tenants, forwarded by the SMS gateway
+	e.POST("/v1/sms/inbound/:uuid", app.inboundSMSHandler)
+
 	// metrics
 	e.GET("/v1/metrics", echo.WrapHandler(expvar.Handler()))
 
+	// public, read only
+	p := e.Group("/v1/public", middleware.RateLimiterWithConfig(publicConfig))
+
+	p.GET("/vacancies", app.listVacanciesHandler)
+
 	g := e.Group("/v1/auth")
 
 	g.Use(app.authenticate)
 
+	// sessions
+	g.DELETE("/logout", app.logoutHandler, app.requireAuthenticatedAdmin)
+	g.DELETE("/logout/all", app.logoutAllHandler, app.requireAuthenticatedAdmin)
+	g.GET("/sessions", app.listSessionsHandler, app.requireAuthenticatedAdmin)
+	g.DELETE("/sessions/:uuid", app.revokeSessionHandler, app.requireAuthenticatedAdmin)
+	g.GET("/sign-ins", app.listSignInsHandler, app.requireAuthenticatedAdmin)
+	g.PUT("/admins/me/password", app.updateMyPasswordHandler, app.requireAuthenticatedAdmin)
+	g.PUT("/admins/me/language", app.updateMyLanguageHandler, app.requireAuthenticatedAdmin)
+	g.GET("/admins/me/digest", app.showMyDigestHandler, app.requireAuthenticatedAdmin)
+	g.PUT("/admins/me/digest", app.updateMyDigestHandler, app.requireAuthenticatedAdmin)
+
+	// notification templates
+	g.GET("/templates", app.listTemplatesHandler, app.requireAuthenticatedAdmin)
+	g.GET("/templates/:name/preview", app.previewTemplateHandler, app.requireAuthenticatedAdmin)
+
+	// api keys
+	g.GET("/api-keys", app.listApiKeysHandler, app.requireAuthenticatedAdmin)
+	g.POST("/api-keys", app.createApiKeyHandler, app.requireAuthenticatedAdmin)
+	g.DELETE("/api-keys/:uuid", app.revokeApiKeyHandler, app.requireAuthenticatedAdmin)
+	g.GET("/api-keys/:uuid/usage", app.listApiKeyUsageHandler, app.requireAuthenticatedAdmin)
+
+	// two-factor authentication
+	g.GET("/totp", app.showTotpHandler, app.requireAuthenticatedAdmin)
+	g.POST("/totp", app.enrolTotpHandler, app.requireAuthenticatedAdmin)
+	g.POST("/totp/confirm", app.confirmTotpHandler, app.requireAuthenticatedAdmin)
+	g.POST("/totp/recovery-codes", app.regenerateRecoveryCodesHandler, app.requireAuthenticatedAdmin)
+	g.DELETE("/totp", app.disableTotpHandler, app.requireAuthenticatedAdmin)
+
+	// organizations
+	g.GET("/organizations", app.listOrganizationsHandler, app.requireAuthenticatedAdmin)
+
+	// dashboard, every section the caller may read
+	g.GET("/dashboard", app.showDashboardHandler, app.requirePermission(PermHousesRead))
+
 	// houses
-	g.GET("/houses", app.listHousesHandler, app.requireAuthenticatedAdmin)
-	g.POST("/houses", app.createHouseHandler, app.requireAuthenticatedAdmin)
-	g.POST("/bulk/houses", app.bulkHousesHandler, app.requireAuthenticatedAdmin)
-	g.GET("/houses/:uuid", app.showHouseHandler, app.requireAuthenticatedAdmin)
-	g.PUT("/houses/:uuid", app.updateHouseHandler, app.requireAuthenticatedAdmin)
-	g.DELETE("/houses/:uuid", app.deleteHousesHandler, app.requireAuthenticatedAdmin)
+	g.GET("/houses", app.listHousesHandler, app.requirePermission(PermHousesRead))
+	g.GET("/houses/export", app.exportHousesHandler, app.requirePermission(PermHousesRead))
+	g.POST("/houses", app.createHouseHandler, app.requirePermission(PermHousesWrite))
+	g.POST("/bulk/houses", app.bulkHousesHandler, app.requirePermission(PermHousesWrite))
+	g.GET("/houses/:uuid", app.showHouseHandler, app.requirePermission(PermHousesRead))
+	g.PUT("/houses/:uuid", app.updateHouseHandler, app.requirePermission(PermHousesWrite))
+	g.DELETE("/houses/:uuid", app.deleteHousesHandler, app.requirePermission(PermHousesDelete))
+	g.PUT("/houses/:uuid/archive", app.archiveHouseHandler, app.requirePermission(PermHousesWrite))
+	g.PUT("/houses/:uuid/unarchive", app.unarchiveHouseHandler, app.requirePermission(PermHousesWrite))
 
 	// tenants
-	g.GET("/tenants", app.listTenantsHandler, app.requireAuthenticatedAdmin)
-	g.POST("/tenants", app.createTenantHandler, app.requireAuthenticatedAdmin)
-	g.GET("/tenants/:uuid", app.showTenantHandler, app.requireAuthenticatedAdmin)
-	g.PUT("/tenants/:uuid", app.updateTenantsHandler, app.requireAuthenticatedAdmin)
-	g.DELETE("/tenants/:uuid", app.removeTenant, app.requireAuthenticatedAdmin)
+	g.GET("/tenants", app.listTenantsHandler, app.requirePermission(PermTenantsRead))
+	g.GET("/tenants/export", app.exportTenantsHandler, app.requirePermission(PermTenantsRead))
+	g.POST("/tenants", app.createTenantHandler, app.requirePermission(PermTenantsWrite))
+	g.GET("/tenants/:uuid", app.showTenantHandler, app.requirePermission(PermTenantsRead))
+	g.PUT("/tenants/:uuid", app.updateTenantsHandler, app.requirePermission(PermTenantsWrite))
+	g.DELETE("/tenants/:uuid", app.removeTenant, app.requirePermission(PermTenantsWrite))
+	g.GET("/tenants/:uuid/reminders", app.listTenantRemindersHandler, app.requirePermission(PermTenantsRead))
+	g.GET("/tenants/:uuid/notification-preferences", app.showNotificationPreferenceHandler, app.requirePermission(PermTenantsRead))
+	g.PUT("/tenants/:uuid/notification-preferences", app.updateNotificationPreferenceHandler, app.requirePermission(PermTenantsWrite))
+	g.GET("/tenants/:uuid/notification-preferences/history", app.listNotificationConsentsHandler, app.requirePermission(PermTenantsRead))
+
+	// inspections
+	g.GET("/inspections/checklist", app.listChecklistHandler, app.requirePermission(PermInspectionsRead))
+	g.POST("/inspections/checklist", app.createChecklistItemHandler, app.requirePermission(PermInspectionsWrite))
+	g.DELETE("/inspections/checklist/:uuid", app.deleteChecklistItemHandler, app.requirePermission(PermInspectionsWrite))
+	g.GET("/houses/:uuid/inspections", app.listHouseInspectionsHandler, app.requirePermission(PermInspectionsRead))
+	g.POST("/houses/:uuid/inspections", app.createInspectionHandler, app.requirePermission(PermInspectionsWrite))
+	g.GET("/inspections/:uuid", app.showInspectionHandler, app.requirePermission(PermInspectionsRead))
+	g.PUT("/inspections/:uuid/sign", app.signInspectionHandler, app.requirePermission(PermInspectionsWrite))
+	g.DELETE("/inspections/:uuid", app.deleteInspectionHandler, app.requirePermission(PermInspectionsWrite))
+	g.GET("/tenants/:uuid/inspections/compare", app.compareTenancyInspectionsHandler, app.requirePermission(PermInspectionsRead))
+
+	// public listing
+	g.GET("/listings/opt-outs", app.listListingOptOutsHandler, app.requirePermission(PermHousesRead))
+	g.POST("/listings/opt-outs", app.createListingOptOutHandler, app.requirePermission(PermListingsWrite))
+	g.DELETE("/listings/opt-outs", app.deleteListingOptOutHandler, app.requirePermission(PermListingsWrite))
 
 	// Payments
-	g.GET("/payments", app.listPaymentsHandler, app.requireAuthenticatedAdmin)
-	g.POST("/payments", app.createPaymentHandler, app.requireAuthenticatedAdmin)
-	g.GET("/payments/:uuid", app.showPaymentHandler, app.requireAuthenticatedAdmin)
-	g.PUT("/payments/:uuid", app.updatePaymentHandler, app.requireAuthenticatedAdmin)
-	g.DELETE("/payments/:uuid", app.deletePaymentHandler, app.requireAuthenticatedAdmin)
+	g.GET("/payments", app.listPaymentsHandler, app.requirePermission(PermPaymentsRead))
+	g.GET("/payments/export", app.exportPaymentsHandler, app.requirePermission(PermPaymentsRead))
+	g.POST("/payments", app.createPaymentHandler, app.requirePermission(PermPaymentsWrite))
+	g.GET("/payments/:uuid", app.showPaymentHandler, app.requirePermission(PermPaymentsRead))
+	g.PUT("/payments/:uuid", app.updatePaymentHandler, app.requirePermission(PermPaymentsWrite))
+	g.DELETE("/payments/:uuid", app.deletePaymentHandler, app.requirePermission(PermPaymentsDelete))
+
+	// reports and analytics
+	g.GET("/reports/income", app.incomeReportHandler, app.requirePermission(PermPaymentsRead))
+	g.GET("/analytics/occupancy", app.occupancyAnalyticsHandler, app.requirePermission(PermHousesRead))
+
+	// Audit log
+	g.GET("/audit", app.listAuditLogHandler, app.requirePermission(PermAuditRead))
+
+	// admins, super users only
+	g.GET("/admins", app.listAdminsHandler, app.requireSuperUser)
+	g.PUT("/admins/:uuid/role", app.updateAdminRoleHandler, app.requireSuperUser)
+	g.GET("/admins/:uuid/sessions", app.listAdminSessionsHandler, app.requireSuperUser)
+	g.DELETE("/admins/:uuid/sessions", app.revokeAdminSessionsHandler, app.requireSuperUser)
+	g.GET("/admins/:uuid/sign-ins", app.listAdminSignInsHandler, app.requireSuperUser)
+	g.GET("/security-policy", app.showSecurityPolicyHandler, app.requireSuperUser)
+	g.PUT("/security-policy", app.updateSecurityPolicyHandler, app.requireSuperUser)
+	g.GET("/invites", app.listInvitesHandler, app.requireSuperUser, app.requireOrganization)
+	g.POST("/invites", app.createInviteHandler, app.requireSuperUser, app.requireOrganization)
+	g.DELETE("/invites/:uuid", app.revokeInviteHandler, app.requireSuperUser, app.requireOrganization)
+	g.GET("/outbox", app.listOutboxHandler, app.requireSuperUser)
+	g.POST("/outbox/replay", app.replayDeadOutboxHandler, app.requireSuperUser)
+	g.POST("/outbox/:id/replay", app.replayOutboxMessageHandler, app.requireSuperUser)
+	g.POST("/organizations", app.createOrganizationHandler, app.requireSuperUser)
+	g.PUT("/organizations/:uuid", app.updateOrganizationHandler, app.requireSuperUser)
+	g.GET("/organizations/:uuid/members", app.listOrganizationMembersHandler, app.requireSuperUser)
+	g.POST("/organizations/:uuid/members", app.addOrganizationMemberHandler, app.requireSuperUser)
+	g.DELETE("/organizations/:uuid/members", app.removeOrganizationMemberHandler, app.requireSuperUser)
 
 	return e
 
@@ -25,6 +25,31 @@ func (app *application) serve() error {
 
 	shutdownError := make(chan error)
 
+	// cancelled once the server has stopped taking requests, to stop long
+	// running background jobs
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+
+	app.background(func() {
+		app.runTokenSweeper(ctx)
+	})
+
+	app.background(func() {
+		app.runOutboxDispatcher(ctx)
+	})
+
+	app.background(func() {
+		app.runReminderScheduler(ctx)
+	})
+
+	app.background(func() {
+		app.runDigestScheduler(ctx)
+	})
+
+	app.background(func() {
+		app.runOccupancySnapshots(ctx)
+	})
+
 	go func() {
 
 		quit := make(chan os.Signal, 1)
@@ -49,6 +74,7 @@ func (app *application) serve() error {
 			slog.String("addr", srv.Addr),
 		)
 
+		stop()
 		app.wg.Wait()
 		shutdownError <- nil
 
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	db "github.com/Hopertz/rent/db/sqlc"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+type FormattedSession struct {
+	ID         uuid.UUID `json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	Ip         string    `json:"ip"`
+	UserAgent  string    `json:"user_agent"`
+	Current    bool      `json:"current"`
+}
+
+// logoutHandler revokes the token used for this request along with the rest
+// of its session.
+func (app *application) logoutHandler(c echo.Context) error {
+
+	admin, ok := c.Get("admin").(db.GetHashTokenForAdminRow)
+
+	if !ok {
+		return c.JSON(http.StatusBadRequest, envelope{"error": "missing admin from context"})
+	}
+
+	hash, _ := c.Get("token_hash").([]byte)
+
+	var err error
+
+	if admin.SessionID.Valid {
+		_, err = app.store.DeleteSession(c.Request().Context(), db.DeleteSessionParams{
+			ID:      admin.SessionID.UUID,
+			AdminID: admin.ID,
+		})
+	} else {
+		err = app.store.DeleteToken(c.Request().Context(), hash)
+	}
+
+	if err != nil {
+		slog.Error("error deleting session", "error", err)
+		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+	}
+
+	return c.JSON(http.StatusOK, nil)
+}
+
+func (app *application) logoutAllHandler(c echo.Context) error {
+
+	admin, ok := c.Get("admin").(db.GetHashTokenForAdminRow)
+
+	if !ok {
+		return c.JSON(http.StatusBadRequest, envelope{"error": "missing admin from context"})
+	}
+
+	if err := app.revokeAllSessions(c, admin.ID); err != nil {
+		slog.Error("error revoking all sessions", "error", err)
+		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+	}
+
+	return c.JSON(http.StatusOK, nil)
+}
+
+func (app *application) listSessionsHandler(c echo.Context) error {
+
+	admin, ok := c.Get("admin").(db.GetHashTokenForAdminRow)
+
+	if !ok {
+		return c.JSON(http.StatusBadRequest, envelope{"error": "missing admin from context"})
+	}
+
+	sessions, err := app.store.GetSessionsForAdmin(c.Request().Context(), admin.ID)
+
+	if err != nil {
+		slog.Error("error fetching sessions", "error", err)
+		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+	}
+
+	return c.JSON(http.StatusOK, formatSessions(sessions, admin.SessionID))
+}
+
+func (app *application) revokeSessionHandler(c echo.Context) error {
+
+	admin, ok := c.Get("admin").(db.GetHashTokenForAdminRow)
+
+	if !ok {
+		return c.JSON(http.StatusBadRequest, envelope{"error": "missing admin from context"})
+	}
+
+	id, err := db.ReadUUIDParam(c)
+
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": "invalid session id"})
+	}
+
+	n, err := app.store.DeleteSession(c.Request().Context(), db.DeleteSessionParams{
+		ID:      id,
+		AdminID: admin.ID,
+	})
+
+	if err != nil {
+		slog.Error("error deleting session", "error", err)
+		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+	}
+
+	if n == 0 {
+		return c.JSON(http.StatusNotFound, envelope{"error": "session not found"})
+	}
+
+	return c.JSON(http.StatusOK, nil)
+}
+
+func (app *application) listAdminSessionsHandler(c echo.Context) error {
+
+	id, err := db.ReadUUIDParam(c)
+
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": "invalid admin id"})
+	}
+
+	sessions, err := app.store.GetSessionsForAdmin(c.Request().Context(), id)
+
+	if err != nil {
+		slog.Error("error fetching sessions", "error", err)
+		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+	}
+
+	return c.JSON(http.StatusOK, formatSessions(sessions, uuid.NullUUID{}))
+}
+
+func (app *application) revokeAdminSessionsHandler(c echo.Context) error {
+
+	id, err := db.ReadUUIDParam(c)
+
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": "invalid admin id"})
+	}
+
+	_, err = app.store.GetAdminById(c.Request().Context(), id)
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return c.JSON(http.StatusNotFound, envelope{"error": "admin not found"})
+		default:
+			slog.Error("error fetching admin by id", "error", err)
+			return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+		}
+	}
+
+	if err := app.revokeAllSessions(c, id); err != nil {
+		slog.Error("error revoking all sessions", "error", err)
+		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+	}
+
+	return c.JSON(http.StatusOK, nil)
+}
+
+// revokeAllSessions removes every session of the admin and any authentication
+// token issued before sessions existed.
+func (app *application) revokeAllSessions(c echo.Context, id uuid.UUID) error {
+
+	err := app.store.DeleteAllSessions(c.Request().Context(), id)
+
+	if err != nil {
+		return err
+	}
+
+	return app.store.DeleteAllToken(c.Request().Context(), db.DeleteAllTokenParams{
+		Scope: db.ScopeAuthentication,
+		ID:    id,
+	})
+}
+
+func formatSessions(sessions []db.GetSessionsForAdminRow, current uuid.NullUUID) []FormattedSession {
+
+	data := make([]FormattedSession, 0, len(sessions))
+
+	for _, s := range sessions {
+		data = append(data, FormattedSession{
+			ID:         s.ID,
+			CreatedAt:  s.CreatedAt,
+			LastUsedAt: s.LastUsedAt,
+			Ip:         s.Ip,
+			UserAgent:  s.UserAgent,
+			Current:    current.Valid && current.UUID == s.ID,
+		})
+	}
+
+	return data
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	db "github.com/Hopertz/rent/db/sqlc"
+	"github.com/google/uuid"
+)
+
+// createSuperUser creates an activated super user with the password on the
+// first line of r. Registering needs an invite, which only a super user can
+// send, so this is how a new database gets its first admin.
+func (app *application) createSuperUser(ctx context.Context, email string, r io.Reader) error {
+
+	email = strings.TrimSpace(email)
+
+	if err := app.validator.Var(email, "required,email"); err != nil {
+		return fmt.Errorf("invalid email %q", email)
+	}
+
+	line, err := bufio.NewReader(r).ReadString('\n')
+
+	if err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("reading password: %w", err)
+	}
+
+	plaintext := strings.TrimRight(line, "\r\n")
+
+	if err := app.passwordPolicy().Check(plaintext); err != nil {
+		return err
+	}
+
+	pwd, err := db.SetPassword(plaintext)
+
+	if err != nil {
+		return fmt.Errorf("hashing password: %w", err)
+	}
+
+	args := db.CreateSuperUserParams{
+		Email:             email,
+		PasswordHash:      pwd.Hash,
+		PreferredLanguage: app.config.defaultLocale,
+	}
+
+	_, err = app.store.TxnCreateSuperUser(ctx, args, func(created db.CreateSuperUserRow) db.AuditEntry {
+		return db.AuditEntry{
+			Action:     auditRegister,
+			EntityType: auditAdmin,
+			Snapshot:   adminSnapshot,
+			AdminID:    uuid.NullUUID{UUID: created.ID, Valid: true},
+			AdminEmail: email,
+		}
+	})
+
+	if err != nil && err.Error() == db.DuplicateEmail {
+		return errors.New("email is already in use")
+	}
+
+	return err
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	db "github.com/Hopertz/rent/db/sqlc"
+	mailer "github.com/Hopertz/rent/pkg/Mailer"
+	"github.com/Hopertz/rent/pkg/templates"
+	"github.com/labstack/echo/v4"
+)
+
+// mailContent renders the named notification for an email in locale. Every
+// template is checked at startup, so failing here is a bug: it is logged and
+// the content left empty, for the mail service to render its own.
+func (app *application) mailContent(locale, name string, data any) mailer.Content {
+
+	msg, err := app.templates.Render(locale, name, data)
+
+	if err != nil {
+		slog.Error("error rendering template", "error", err, "template", name, "locale", locale)
+		return mailer.Content{}
+	}
+
+	return mailer.Content{
+		Locale:  msg.Locale,
+		Subject: msg.Subject,
+		Text:    msg.Text,
+		HTML:    msg.HTML,
+	}
+}
+
+// readLanguage checks a preferred language given in a request, returning it
+// normalized. An empty one is allowed and means the default locale.
+func (app *application) readLanguage(language string) (string, bool) {
+
+	if strings.TrimSpace(language) == "" {
+		return "", true
+	}
+
+	if !app.templates.Supports(language) {
+		return "", false
+	}
+
+	return templates.Normalize(language), true
+}
+
+func (app *application) unsupportedLanguage(c echo.Context) error {
+	return c.JSON(http.StatusBadRequest, envelope{
+		"error": "preferred_language must be one of " + strings.Join(app.templates.Locales(), ", "),
+	})
+}
+
+// readLocale reads the locale query parameter, falling back to the signed in
+// admin's preferred language. An empty locale means the default one.
+func (app *application) readLocale(c echo.Context) (string, error) {
+
+	locale := c.QueryParam("locale")
+
+	if locale == "" {
+		if admin, ok := c.Get("admin").(db.GetHashTokenForAdminRow); ok {
+			locale = admin.PreferredLanguage
+		}
+		return locale, nil
+	}
+
+	if !app.templates.Supports(locale) {
+		return "", errors.New("locale must be one of " + strings.Join(app.templates.Locales(), ", "))
+	}
+
+	return locale, nil
+}
+
+// listTemplatesHandler lists the notifications and the locales they can be
+// rendered in.
+func (app *application) listTemplatesHandler(c echo.Context) error {
+
+	return c.JSON(http.StatusOK, envelope{
+		"templates":      app.templates.Names(),
+		"locales":        app.templates.Locales(),
+		"default_locale": app.templates.Default(),
+	})
+}
+
+// previewTemplateHandler renders a notification with sample data, in the
+// locale given, the signed in admin's preferred language or the default
+// locale, in that order.
+func (app *application) previewTemplateHandler(c echo.Context) error {
+
+	locale, err := app.readLocale(c)
+
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": err.Error()})
+	}
+
+	msg, err := app.templates.Preview(locale, c.Param("name"))
+
+	if err != nil {
+		switch {
+		case errors.Is(err, templates.ErrUnknownTemplate):
+			return c.JSON(http.StatusNotFound, envelope{"error": "template not found"})
+		default:
+			slog.Error("error previewing template", "error", err)
+			return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+		}
+	}
+
+	return c.JSON(http.StatusOK, msg)
+}
+
+// updateMyLanguageHandler sets the language the signed in admin's
+// notifications are sent in.
+func (app *application) updateMyLanguageHandler(c echo.Context) error {
+
+	admin := c.Get("admin").(db.GetHashTokenForAdminRow)
+
+	var input struct {
+		PreferredLanguage string `json:"preferred_language"`
+	}
+
+	if err := c.Bind(&input); err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": err.Error()})
+	}
+
+	language, ok := app.readLanguage(input.PreferredLanguage)
+
+	if !ok {
+		return app.unsupportedLanguage(c)
+	}
+
+	n, err := app.store.UpdateAdminLanguage(c.Request().Context(), db.UpdateAdminLanguageParams{
+		PreferredLanguage: language,
+		ID:                admin.ID,
+		Version:           admin.Version,
+	})
+
+	if err != nil {
+		slog.Error("error updating admin language", "error", err)
+		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+	}
+
+	if n == 0 {
+		return c.JSON(http.StatusConflict, envelope{"error": "unable to complete request due to an edit conflict"})
+	}
+
+	return c.JSON(http.StatusOK, envelope{"preferred_language": language})
+}
@@ -14,7 +14,7 @@ import (
 
 func (app *application) listTenantsHandler(c echo.Context) error {
 
-	tenants, err := app.store.GetTenants(c.Request().Context())
+	tenants, err := app.store.GetTenants(c.Request().Context(), organizationID(c))
 
 	if err != nil {
 		slog.Error("error fetching tenants", "err", err)
@@ -33,7 +33,10 @@ func (app *application) showTenantHandler(c echo.Context) error {
 		return err
 	}
 
-	tenant, err := app.store.GetTenantById(c.Request().Context(), uuid)
+	tenant, err := app.store.GetTenantById(c.Request().Context(), db.GetTenantByIdParams{
+		ID:             uuid,
+		OrganizationID: organizationID(c),
+	})
 
 	if err != nil {
 		switch {
@@ -55,12 +58,15 @@ func (app *application) createTenantHandler(c echo.Context) error {
 	var input struct {
 		Name           string     `json:"name" validate:"required"`
 		Phone          string     `json:"phone" validate:"required,len=10"`
+		Email          string     `json:"email" validate:"omitempty,email"`
 		HouseId        uuid.UUID  `json:"house_id" validate:"required"`
 		PersonalIdType string     `json:"personal_id_type" validate:"required"`
 		PersonalId     string     `json:"personal_id" validate:"required"`
 		Active         bool       `json:"active"`
 		Sos            time.Time  `json:"sos" validate:"required"`
 		Eos            *time.Time `json:"eos"`
+
+		PreferredLanguage string `json:"preferred_language"`
 	}
 
 	if err := c.Bind(&input); err != nil {
@@ -71,6 +77,12 @@ func (app *application) createTenantHandler(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, envelope{"error": err.Error()})
 	}
 
+	language, ok := app.readLanguage(input.PreferredLanguage)
+
+	if !ok {
+		return app.unsupportedLanguage(c)
+	}
+
 	var eos time.Time
 
 	if input.Eos == nil {
@@ -79,7 +91,10 @@ func (app *application) createTenantHandler(c echo.Context) error {
 		eos = *input.Eos
 	}
 
-	house, err := app.store.GetHouseById(c.Request().Context(), input.HouseId)
+	house, err := app.store.GetHouseById(c.Request().Context(), db.GetHouseByIdParams{
+		ID:             input.HouseId,
+		OrganizationID: organizationID(c),
+	})
 
 	if err != nil {
 
@@ -100,22 +115,43 @@ func (app *application) createTenantHandler(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, envelope{"error": "house is already occupied"})
 	}
 
+	if house.Archived {
+		return c.JSON(http.StatusBadRequest, envelope{"error": "house is archived"})
+	}
+
 	args := db.CreateTenantParams{
 		Name:           input.Name,
 		HouseID:        input.HouseId,
 		Phone:          input.Phone,
+		Email:          input.Email,
 		PersonalIDType: input.PersonalIdType,
 		PersonalID:     input.PersonalId,
 		Active:         input.Active,
 		Sos:            input.Sos,
 		Eos:            eos,
+		OrganizationID: organizationID(c),
+
+		PreferredLanguage: language,
 	}
 
-	err = app.store.TxnCreateTenant(c.Request().Context(), args)
+	entry := auditEntry(c, db.AuditEntry{
+		Action:     auditCreate,
+		EntityType: auditTenant,
+		Snapshot:   tenantSnapshot(organizationID(c)),
+	})
+
+	_, err = app.store.TxnCreateTenant(c.Request().Context(), args, entry)
 
 	if err != nil {
-		slog.Error("error creating tenant", "err", err)
-		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+		switch {
+		case errors.Is(err, db.ErrHouseOccupied), errors.Is(err, db.ErrHouseArchived):
+			return c.JSON(http.StatusBadRequest, envelope{"error": err.Error()})
+		case errors.Is(err, sql.ErrNoRows):
+			return c.JSON(http.StatusNotFound, envelope{"error": "house not found"})
+		default:
+			slog.Error("error creating tenant", "err", err)
+			return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+		}
 	}
 
 	return c.JSON(http.StatusCreated, nil)
@@ -130,7 +166,10 @@ func (app *application) updateTenantsHandler(c echo.Context) error {
 		return err
 	}
 
-	tenant, err := app.store.GetTenantById(c.Request().Context(), id)
+	tenant, err := app.store.GetTenantById(c.Request().Context(), db.GetTenantByIdParams{
+		ID:             id,
+		OrganizationID: organizationID(c),
+	})
 
 	if err != nil {
 		switch {
@@ -147,12 +186,15 @@ func (app *application) updateTenantsHandler(c echo.Context) error {
 	var input struct {
 		Name           *string    `json:"name"`
 		Phone          *string    `json:"phone"`
+		Email          *string    `json:"email"`
 		HouseId        *uuid.UUID `json:"house_id"`
 		PersonalIdType *string    `json:"personal_id_type"`
 		PersonalId     *string    `json:"personal_id"`
 		Active         *bool      `json:"active"`
 		Sos            *time.Time `json:"sos"`
 		Eos            *time.Time `json:"eos"`
+
+		PreferredLanguage *string `json:"preferred_language"`
 	}
 
 	if err := c.Bind(&input); err != nil {
@@ -169,7 +211,40 @@ func (app *application) updateTenantsHandler(c echo.Context) error {
 		tenant.Phone = *input.Phone
 	}
 
-	if input.HouseId != nil {
+	if input.Email != nil {
+
+		if err := app.validator.Var(*input.Email, "omitempty,email"); err != nil {
+			return c.JSON(http.StatusBadRequest, envelope{"error": "invalid email"})
+		}
+
+		tenant.Email = *input.Email
+	}
+
+	if input.HouseId != nil && *input.HouseId != tenant.HouseID {
+
+		house, err := app.store.GetHouseById(c.Request().Context(), db.GetHouseByIdParams{
+			ID:             *input.HouseId,
+			OrganizationID: organizationID(c),
+		})
+
+		if err != nil {
+			switch {
+			case errors.Is(err, sql.ErrNoRows):
+				return c.JSON(http.StatusNotFound, envelope{"error": "house not found"})
+			default:
+				slog.Error("error fetching house by id for update tenant", "err", err)
+				return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+			}
+		}
+
+		if house.Archived {
+			return c.JSON(http.StatusBadRequest, envelope{"error": "house is archived"})
+		}
+
+		if house.Occupied {
+			return c.JSON(http.StatusBadRequest, envelope{"error": "house is already occupied"})
+		}
+
 		tenant.HouseID = *input.HouseId
 	}
 
@@ -193,24 +268,55 @@ func (app *application) updateTenantsHandler(c echo.Context) error {
 		tenant.Eos = *input.Eos
 	}
 
+	if input.PreferredLanguage != nil {
+
+		language, ok := app.readLanguage(*input.PreferredLanguage)
+
+		if !ok {
+			return app.unsupportedLanguage(c)
+		}
+
+		tenant.PreferredLanguage = language
+	}
+
 	arg := db.UpdateTenantParams{
 		Name:           tenant.Name,
 		HouseID:        tenant.HouseID,
 		Phone:          tenant.Phone,
+		Email:          tenant.Email,
 		PersonalIDType: tenant.PersonalIDType,
 		PersonalID:     tenant.PersonalID,
 		Active:         tenant.Active,
 		Sos:            tenant.Sos,
 		Eos:            tenant.Eos,
-		ID:             tenant.HouseID,
+		ID:             tenant.TenantID,
 		Version:        tenant.Version,
+		OrganizationID: organizationID(c),
+
+		PreferredLanguage: tenant.PreferredLanguage,
 	}
 
-	err = app.store.TxnUpdateTenantHouse(c.Request().Context(), arg, prev_house_id)
+	entry := auditEntry(c, db.AuditEntry{
+		Action:     auditUpdate,
+		EntityType: auditTenant,
+		EntityID:   tenant.TenantID,
+		Snapshot:   tenantSnapshot(organizationID(c)),
+	})
+
+	err = app.store.TxnUpdateTenantHouse(c.Request().Context(), arg, prev_house_id, entry)
 
 	if err != nil {
-		slog.Error("error updating tenant and house", "err", err)
-		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+		switch {
+		case errors.Is(err, db.ErrEditConflict):
+			return c.JSON(http.StatusConflict, envelope{"error": "unable to complete request due to an edit conflict"})
+		case errors.Is(err, db.ErrHouseOccupied), errors.Is(err, db.ErrHouseArchived):
+			return c.JSON(http.StatusBadRequest, envelope{"error": err.Error()})
+		case errors.Is(err, sql.ErrNoRows):
+			return c.JSON(http.StatusNotFound, envelope{"error": "house not found"})
+		default:
+			slog.Error("error updating tenant and house", "err", err)
+			return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+		}
 	}
 
 	return c.JSON(http.StatusOK, nil)
@@ -225,7 +331,10 @@ func (app *application) removeTenant(c echo.Context) error {
 		return err
 	}
 
-	tenant, err := app.store.GetTenantById(c.Request().Context(), uuid)
+	tenant, err := app.store.GetTenantById(c.Request().Context(), db.GetTenantByIdParams{
+		ID:             uuid,
+		OrganizationID: organizationID(c),
+	})
 
 	if err != nil {
 		switch {
@@ -244,20 +353,36 @@ func (app *application) removeTenant(c echo.Context) error {
 		Name:           tenant.Name,
 		HouseID:        tenant.HouseID,
 		Phone:          tenant.Phone,
+		Email:          tenant.Email,
 		PersonalIDType: tenant.PersonalIDType,
 		PersonalID:     tenant.PersonalID,
 		Active:         tenant.Active,
 		Sos:            tenant.Sos,
 		Eos:            tenant.Eos,
-		ID:             tenant.HouseID,
+		ID:             tenant.TenantID,
 		Version:        tenant.Version,
+		OrganizationID: organizationID(c),
+
+		PreferredLanguage: tenant.PreferredLanguage,
 	}
 
-	err = app.store.TxnRemoveTenantHouse(c.Request().Context(), args)
+	entry := auditEntry(c, db.AuditEntry{
+		Action:     auditDelete,
+		EntityType: auditTenant,
+		EntityID:   tenant.TenantID,
+		Snapshot:   tenantSnapshot(organizationID(c)),
+	})
+
+	err = app.store.TxnRemoveTenantHouse(c.Request().Context(), args, entry)
 
 	if err != nil {
-		slog.Error("failed deactiving tenant & disabling house", "err", err)
-		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+		switch {
+		case errors.Is(err, db.ErrEditConflict):
+			return c.JSON(http.StatusConflict, envelope{"error": "unable to complete request due to an edit conflict"})
+		default:
+			slog.Error("failed deactiving tenant & disabling house", "err", err)
+			return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+		}
 	}
 
 	return c.JSON(http.StatusOK, nil)
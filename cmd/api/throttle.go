@@ -0,0 +1,263 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	db "github.com/Hopertz/rent/db/sqlc"
+	mailer "github.com/Hopertz/rent/pkg/Mailer"
+	"github.com/Hopertz/rent/pkg/templates"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// failures an account gets for free before backoff starts
+	loginFreeFailures = 3
+	maxLoginBackoff   = 5 * time.Minute
+
+	// failed logins for one account are counted over this window, reset by a
+	// successful login
+	loginFailureWindow = 24 * time.Hour
+
+	// an address failing this many times within the lockout duration is
+	// blocked regardless of which emails it tries
+	ipFailureLimit = 100
+
+	signInHistoryLimit = 50
+)
+
+// parseTrustedProxies reads the trusted-proxies flag: addresses or CIDR
+// ranges, comma separated.
+func parseTrustedProxies(s string) ([]*net.IPNet, error) {
+
+	var ranges []*net.IPNet
+
+	for _, f := range strings.Split(s, ",") {
+
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+
+		if !strings.Contains(f, "/") {
+			ip := net.ParseIP(f)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid proxy address %q", f)
+			}
+			bits := 8 * len(ip.To16())
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			ranges = append(ranges, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, ipNet, err := net.ParseCIDR(f)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy range %q", f)
+		}
+
+		ranges = append(ranges, ipNet)
+	}
+
+	return ranges, nil
+}
+
+// ipExtractor decides the client address c.RealIP returns, which login
+// throttling, API key allow-lists and the audit log rely on. X-Forwarded-For
+// is only believed as far back as the trusted proxies it passed through;
+// with none configured it is ignored and the peer address is used, so a
+// client cannot name its own address.
+func (app *application) ipExtractor() echo.IPExtractor {
+
+	if len(app.config.trustedProxies) == 0 {
+		return echo.ExtractIPDirect()
+	}
+
+	options := []echo.TrustOption{
+		echo.TrustLoopback(false),
+		echo.TrustLinkLocal(false),
+		echo.TrustPrivateNet(false),
+	}
+
+	for _, r := range app.config.trustedProxies {
+		options = append(options, echo.TrustIPRange(r))
+	}
+
+	return echo.ExtractIPFromXFFHeader(options...)
+}
+
+// loginBackoff is how long an account must wait after its last failure,
+// given failures consecutive failed attempts: nothing for the first few,
+// then doubling from one second up to maxLoginBackoff, then the full lockout
+// once the threshold is reached.
+func (app *application) loginBackoff(failures int64) time.Duration {
+
+	switch {
+	case failures >= int64(app.config.auth.lockoutThreshold):
+		return app.config.auth.lockoutDuration
+	case failures < loginFreeFailures:
+		return 0
+	}
+
+	d := time.Duration(math.Pow(2, float64(failures-loginFreeFailures))) * time.Second
+
+	return min(d, maxLoginBackoff)
+}
+
+// checkLoginThrottle returns how long the caller must wait before another
+// login attempt for email from ip is considered, or zero if it may proceed.
+func (app *application) checkLoginThrottle(ctx context.Context, email, ip string) (time.Duration, error) {
+
+	now := time.Now()
+
+	byIP, err := app.store.GetLoginFailuresByIp(ctx, db.GetLoginFailuresByIpParams{
+		Ip:        ip,
+		CreatedAt: now.Add(-app.config.auth.lockoutDuration),
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	if byIP.Failures >= ipFailureLimit && byIP.LastFailure.Valid {
+		if wait := byIP.LastFailure.Time.Add(app.config.auth.lockoutDuration).Sub(now); wait > 0 {
+			return wait, nil
+		}
+	}
+
+	byEmail, err := app.store.GetLoginFailuresByEmail(ctx, db.GetLoginFailuresByEmailParams{
+		Email:     email,
+		CreatedAt: now.Add(-loginFailureWindow),
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	if !byEmail.LastFailure.Valid {
+		return 0, nil
+	}
+
+	wait := byEmail.LastFailure.Time.Add(app.loginBackoff(byEmail.Failures)).Sub(now)
+
+	return max(wait, 0), nil
+}
+
+func tooManyLoginAttempts(c echo.Context, wait time.Duration) error {
+
+	c.Response().Header().Set(echo.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+
+	return c.JSON(http.StatusTooManyRequests, envelope{"error": "too many failed login attempts, try again later"})
+}
+
+// recordLoginAttempt stores the outcome of a login. When a failure is the one
+// that locks the account, the owner is told by email.
+func (app *application) recordLoginAttempt(c echo.Context, email string, adminID uuid.NullUUID, success bool) {
+
+	ctx := c.Request().Context()
+
+	userAgent := c.Request().UserAgent()
+	if len(userAgent) > 255 {
+		userAgent = userAgent[:255]
+	}
+
+	err := app.store.CreateLoginAttempt(ctx, db.CreateLoginAttemptParams{
+		Email:     email,
+		Ip:        c.RealIP(),
+		UserAgent: userAgent,
+		Success:   success,
+		AdminID:   adminID,
+	})
+
+	if err != nil {
+		slog.Error("error recording login attempt", "error", err)
+		return
+	}
+
+	if success || !adminID.Valid {
+		return
+	}
+
+	failures, err := app.store.GetLoginFailuresByEmail(ctx, db.GetLoginFailuresByEmailParams{
+		Email:     email,
+		CreatedAt: time.Now().Add(-loginFailureWindow),
+	})
+
+	if err != nil {
+		slog.Error("error counting login failures", "error", err)
+		return
+	}
+
+	if failures.Failures != int64(app.config.auth.lockoutThreshold) {
+		return
+	}
+
+	slog.Warn("admin account locked after failed logins", "email", email, "ip", c.RealIP())
+
+	var language string
+
+	if admin, err := app.store.GetAdminById(ctx, adminID.UUID); err == nil {
+		language = admin.PreferredLanguage
+	} else {
+		slog.Error("error fetching admin for lockout notice", "error", err)
+	}
+
+	data := mailer.LockoutData{
+		Email:       email,
+		IP:          c.RealIP(),
+		LockedUntil: time.Now().Add(app.config.auth.lockoutDuration).Format(time.RFC3339),
+	}
+	data.Content = app.mailContent(language, templates.Lockout, data)
+
+	err = app.store.Enqueue(ctx, db.OutboxMessage{
+		Kind:      mailer.PathLockout,
+		Recipient: email,
+		Payload:   data,
+	})
+
+	if err != nil {
+		slog.Error("error queueing lockout notice", "error", err)
+	}
+}
+
+func (app *application) listSignInsHandler(c echo.Context) error {
+
+	admin := c.Get("admin").(db.GetHashTokenForAdminRow)
+
+	return app.writeSignIns(c, admin.ID)
+}
+
+func (app *application) listAdminSignInsHandler(c echo.Context) error {
+
+	id, err := db.ReadUUIDParam(c)
+
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": "invalid admin id"})
+	}
+
+	return app.writeSignIns(c, id)
+}
+
+func (app *application) writeSignIns(c echo.Context, id uuid.UUID) error {
+
+	attempts, err := app.store.GetSignInsForAdmin(c.Request().Context(), db.GetSignInsForAdminParams{
+		ID:    id,
+		Limit: signInHistoryLimit,
+	})
+
+	if err != nil {
+		slog.Error("error fetching sign ins", "error", err)
+		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+	}
+
+	return c.JSON(http.StatusOK, attempts)
+}
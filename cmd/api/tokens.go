@@ -1,30 +1,20 @@
 package main
 
 import (
-	"bytes"
+	"crypto/sha256"
 	"database/sql"
-	"encoding/json"
 	"errors"
-	"fmt"
-	"io"
 	"log/slog"
 	"net/http"
 	"time"
 
 	db "github.com/Hopertz/rent/db/sqlc"
+	mailer "github.com/Hopertz/rent/pkg/Mailer"
+	"github.com/Hopertz/rent/pkg/templates"
+	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 )
 
-type ActivateData struct {
-	Email string `json:"email"`
-	Token string `json:"token"`
-}
-
-type ResetData struct {
-	Email string `json:"email"`
-	Token string `json:"token"`
-}
-
 func (app *application) createAuthenticationTokenHandler(c echo.Context) error {
 
 	var input struct {
@@ -40,12 +30,24 @@ func (app *application) createAuthenticationTokenHandler(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, envelope{"error": err.Error()})
 	}
 
+	wait, err := app.checkLoginThrottle(c.Request().Context(), input.Email, c.RealIP())
+
+	if err != nil {
+		slog.Error("error checking login throttle", "error", err)
+		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+	}
+
+	if wait > 0 {
+		return tooManyLoginAttempts(c, wait)
+	}
+
 	admin, err := app.store.GetAdminByEmail(c.Request().Context(), input.Email)
 
 	if err != nil {
 		switch {
 		case errors.Is(err, sql.ErrNoRows):
 			slog.Error("error fetching admin by email", "error", err)
+			app.recordLoginAttempt(c, input.Email, uuid.NullUUID{}, false)
 			return c.JSON(http.StatusNotFound, envelope{"error": "invalid email number or password"})
 		default:
 			slog.Error("error fetching admin by phone number", "error", err)
@@ -72,17 +74,113 @@ func (app *application) createAuthenticationTokenHandler(c echo.Context) error {
 
 	if !match {
 		slog.Error("error matching password", "error", err)
+		app.recordLoginAttempt(c, admin.Email, uuid.NullUUID{UUID: admin.ID, Valid: true}, false)
 		return c.JSON(http.StatusUnauthorized, envelope{"error": "invalid phone number or password"})
 	}
 
-	expiry := time.Now().Add(3 * 24 * time.Hour)
-	token, err := app.store.NewToken(admin.ID, expiry, db.ScopeAuthentication)
+	mfa, err := app.store.GetAdminTotp(c.Request().Context(), admin.ID)
+
+	switch {
+	case err == nil && mfa.ConfirmedAt.Valid:
+		expiry := time.Now().Add(mfaChallengeTTL)
+		token, err := app.store.NewToken(admin.ID, expiry, db.ScopeMFA)
+		if err != nil {
+			slog.Error("error generating mfa token", "error", err)
+			return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+		}
+		return c.JSON(http.StatusAccepted, envelope{"mfa_required": true, "mfa_token": token.Plaintext, "expiry": expiry.Unix()})
+
+	case err != nil && !errors.Is(err, sql.ErrNoRows):
+		slog.Error("error fetching admin totp", "error", err)
+		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+	}
+
+	policy, err := app.store.GetSecurityPolicy(c.Request().Context())
+
+	if err != nil {
+		slog.Error("error fetching security policy", "error", err)
+		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+	}
+
+	res, err := app.startSession(c, admin.ID)
 	if err != nil {
 		slog.Error("error generating new token", "error", err)
 		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
 	}
 
-	return c.JSON(http.StatusCreated, envelope{"token": token.Plaintext, "expiry": expiry.Unix(), "is_super_user" : admin.IsSuperUser})
+	app.recordLoginAttempt(c, admin.Email, uuid.NullUUID{UUID: admin.ID, Valid: true}, true)
+
+	res["is_super_user"] = admin.IsSuperUser
+	res["role"] = admin.Role
+	res["totp_setup_required"] = policy.RequireTotp
+
+	return c.JSON(http.StatusCreated, res)
+}
+
+// startSession opens a session for the admin from the current request and
+// returns the token pair to send back.
+func (app *application) startSession(c echo.Context, id uuid.UUID) (envelope, error) {
+
+	userAgent := c.Request().UserAgent()
+	if len(userAgent) > 255 {
+		userAgent = userAgent[:255]
+	}
+
+	now := time.Now()
+
+	tokens, err := app.store.NewSession(c.Request().Context(), id, now.Add(app.config.auth.accessTokenTTL), now.Add(app.config.auth.refreshTokenTTL), c.RealIP(), userAgent)
+	if err != nil {
+		return nil, err
+	}
+
+	return sessionTokensEnvelope(tokens), nil
+}
+
+// refreshTokenHandler rotates a refresh token into a new access and refresh
+// pair. Reusing an already rotated refresh token revokes its whole session.
+func (app *application) refreshTokenHandler(c echo.Context) error {
+
+	var input struct {
+		RefreshToken string `json:"refresh_token" validate:"required,len=26"`
+	}
+
+	if err := c.Bind(&input); err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": err.Error()})
+	}
+
+	if err := app.validator.Struct(input); err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": err.Error()})
+	}
+
+	tokenHash := sha256.Sum256([]byte(input.RefreshToken))
+
+	now := time.Now()
+
+	tokens, err := app.store.RefreshSession(c.Request().Context(), tokenHash[:], now.Add(app.config.auth.accessTokenTTL), now.Add(app.config.auth.refreshTokenTTL))
+
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrRefreshTokenInvalid):
+			return c.JSON(http.StatusUnauthorized, envelope{"error": "invalid or expired refresh token"})
+		case errors.Is(err, db.ErrRefreshTokenReused):
+			slog.Warn("refresh token reuse detected, session revoked", "ip", c.RealIP())
+			return c.JSON(http.StatusUnauthorized, envelope{"error": "refresh token already used, session revoked"})
+		default:
+			slog.Error("error refreshing session", "error", err)
+			return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+		}
+	}
+
+	return c.JSON(http.StatusCreated, sessionTokensEnvelope(tokens))
+}
+
+func sessionTokensEnvelope(tokens *db.SessionTokens) envelope {
+	return envelope{
+		"token":          tokens.Access.Plaintext,
+		"expiry":         tokens.Access.Expiry.Unix(),
+		"refresh_token":  tokens.Refresh.Plaintext,
+		"refresh_expiry": tokens.Refresh.Expiry.Unix(),
+	}
 }
 
 func (app *application) createPasswordResetTokenHandler(c echo.Context) error {
@@ -118,54 +216,22 @@ func (app *application) createPasswordResetTokenHandler(c echo.Context) error {
 
 	expiry := time.Now().Add(45 * time.Minute)
 
-	token, err := app.store.NewToken(admin.ID, expiry, db.ScopePasswordReset)
+	_, err = app.store.NewTokenWithMessage(c.Request().Context(), admin.ID, expiry, db.ScopePasswordReset, func(token string) db.OutboxMessage {
+		data := mailer.PasswordResetData{Email: admin.Email, Token: token}
+		data.Content = app.mailContent(admin.PreferredLanguage, templates.PasswordReset, data)
+
+		return db.OutboxMessage{
+			Kind:      mailer.PathPasswordReset,
+			Recipient: admin.Email,
+			Payload:   data,
+		}
+	})
+
 	if err != nil {
 		slog.Error("error generating token :createPasswordResetTokenHandler", "error", err)
 		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
 	}
 
-	app.background(func() {
-
-		dt := ResetData{
-			Email: admin.Email,
-			Token: token.Plaintext,
-		}
-
-		jsonData, err := json.Marshal(dt)
-		if err != nil {
-			slog.Error("Error marshaling JSON", "Error", err)
-		}
-
-		client := &http.Client{
-			Timeout: 10 * time.Second,
-		}
-
-		req, err := http.NewRequest("POST", fmt.Sprintf("%s/resetpwd", app.config.mailer_url), bytes.NewBuffer(jsonData))
-		if err != nil {
-			slog.Error("Error creating request", "Error", err)
-		}
-
-		req.Header.Set("Content-Type", "application/json")
-
-		resp, err := client.Do(req)
-		if err != nil {
-			slog.Error("Error sending request", "Error", err)
-		}
-		defer resp.Body.Close()
-
-		respBody, err := io.ReadAll(resp.Body)
-		if err != nil {
-			slog.Error("Error reading response", "Error", err)
-		}
-
-		if resp.StatusCode != http.StatusOK {
-			slog.Error(fmt.Sprintf("Request failed with status code %d: %s", resp.StatusCode, string(respBody)))
-		}
-
-		slog.Info(fmt.Sprintf("Email sent successfully to %s\n", admin.Email))
-
-	})
-
 	return c.JSON(http.StatusOK, nil)
 }
 
@@ -202,53 +268,21 @@ func (app *application) resendActivationTokenHandler(c echo.Context) error {
 
 	expiry := time.Now().Add(3 * 24 * time.Hour)
 
-	token, err := app.store.NewToken(admin.ID, expiry, db.ScopeActivation)
+	_, err = app.store.NewTokenWithMessage(c.Request().Context(), admin.ID, expiry, db.ScopeActivation, func(token string) db.OutboxMessage {
+		data := mailer.ActivationData{Email: admin.Email, Token: token}
+		data.Content = app.mailContent(admin.PreferredLanguage, templates.Activation, data)
+
+		return db.OutboxMessage{
+			Kind:      mailer.PathActivation,
+			Recipient: admin.Email,
+			Payload:   data,
+		}
+	})
+
 	if err != nil {
 		slog.Error("error generating new token", "error", err)
 		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
 	}
 
-	app.background(func() {
-
-		dt := ActivateData{
-			Email: admin.Email,
-			Token: token.Plaintext,
-		}
-
-		jsonData, err := json.Marshal(dt)
-		if err != nil {
-			slog.Error("Error marshaling JSON", "Error", err)
-		}
-
-		client := &http.Client{
-			Timeout: 10 * time.Second,
-		}
-
-		req, err := http.NewRequest("POST", fmt.Sprintf("%s/activate", app.config.mailer_url), bytes.NewBuffer(jsonData))
-		if err != nil {
-			slog.Error("Error creating request", "Error", err)
-		}
-
-		req.Header.Set("Content-Type", "application/json")
-
-		resp, err := client.Do(req)
-		if err != nil {
-			slog.Error("Error sending request", "Error", err)
-		}
-		defer resp.Body.Close()
-
-		respBody, err := io.ReadAll(resp.Body)
-		if err != nil {
-			slog.Error("Error reading response", "Error", err)
-		}
-
-		if resp.StatusCode != http.StatusOK {
-			slog.Error(fmt.Sprintf("Request failed with status code %d: %s", resp.StatusCode, string(respBody)))
-		}
-
-		slog.Info(fmt.Sprintf("Email sent successfully to %s\n", admin.Email))
-
-	})
-
 	return c.JSON(http.StatusAccepted, nil)
 }
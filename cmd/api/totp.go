@@ -0,0 +1,468 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base32"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	db "github.com/Hopertz/rent/db/sqlc"
+	"github.com/Hopertz/rent/pkg/totp"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	totpIssuer        = "Rent"
+	mfaChallengeTTL   = 5 * time.Minute
+	recoveryCodeCount = 10
+)
+
+// loginTotpHandler is the second login step for admins with two-factor
+// authentication. It trades the mfa token from the first step and a TOTP or
+// recovery code for a session.
+func (app *application) loginTotpHandler(c echo.Context) error {
+
+	var input struct {
+		MfaToken string `json:"mfa_token" validate:"required,len=26"`
+		Code     string `json:"code" validate:"required"`
+	}
+
+	if err := c.Bind(&input); err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": err.Error()})
+	}
+
+	if err := app.validator.Struct(input); err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": err.Error()})
+	}
+
+	tokenHash := sha256.Sum256([]byte(input.MfaToken))
+
+	admin, err := app.store.GetHashTokenForAdmin(c.Request().Context(), db.GetHashTokenForAdminParams{
+		Scope:  db.ScopeMFA,
+		Hash:   tokenHash[:],
+		Expiry: time.Now(),
+	})
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return c.JSON(http.StatusUnauthorized, envelope{"error": "invalid or expired mfa token"})
+		default:
+			slog.Error("error fetching mfa token admin", "error", err)
+			return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+		}
+	}
+
+	wait, err := app.checkLoginThrottle(c.Request().Context(), admin.Email, c.RealIP())
+
+	if err != nil {
+		slog.Error("error checking login throttle", "error", err)
+		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+	}
+
+	if wait > 0 {
+		return tooManyLoginAttempts(c, wait)
+	}
+
+	ok, err := app.verifySecondFactor(c.Request().Context(), admin.ID, input.Code)
+
+	if err != nil {
+		slog.Error("error verifying second factor", "error", err)
+		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+	}
+
+	if !ok {
+		app.recordLoginAttempt(c, admin.Email, uuid.NullUUID{UUID: admin.ID, Valid: true}, false)
+		return c.JSON(http.StatusUnauthorized, envelope{"error": "invalid code"})
+	}
+
+	if err := app.store.DeleteToken(c.Request().Context(), tokenHash[:]); err != nil {
+		slog.Error("error deleting mfa token", "error", err)
+	}
+
+	res, err := app.startSession(c, admin.ID)
+	if err != nil {
+		slog.Error("error generating new token", "error", err)
+		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+	}
+
+	app.recordLoginAttempt(c, admin.Email, uuid.NullUUID{UUID: admin.ID, Valid: true}, true)
+
+	res["is_super_user"] = admin.IsSuperUser
+	res["role"] = admin.Role
+	res["totp_setup_required"] = false
+
+	return c.JSON(http.StatusCreated, res)
+}
+
+func (app *application) showTotpHandler(c echo.Context) error {
+
+	admin := c.Get("admin").(db.GetHashTokenForAdminRow)
+
+	status := envelope{
+		"enabled":                  false,
+		"pending":                  false,
+		"required":                 admin.TotpRequired,
+		"recovery_codes_remaining": 0,
+	}
+
+	mfa, err := app.store.GetAdminTotp(c.Request().Context(), admin.ID)
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return c.JSON(http.StatusOK, status)
+		default:
+			slog.Error("error fetching admin totp", "error", err)
+			return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+		}
+	}
+
+	status["enabled"] = mfa.ConfirmedAt.Valid
+	status["pending"] = !mfa.ConfirmedAt.Valid
+
+	if mfa.ConfirmedAt.Valid {
+		n, err := app.store.CountRecoveryCodes(c.Request().Context(), admin.ID)
+		if err != nil {
+			slog.Error("error counting recovery codes", "error", err)
+			return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+		}
+		status["recovery_codes_remaining"] = n
+	}
+
+	return c.JSON(http.StatusOK, status)
+}
+
+// enrolTotpHandler starts enrolment with a fresh secret. Calling it again
+// before confirming replaces the secret.
+func (app *application) enrolTotpHandler(c echo.Context) error {
+
+	admin := c.Get("admin").(db.GetHashTokenForAdminRow)
+
+	if admin.TotpEnabled {
+		return c.JSON(http.StatusConflict, envelope{"error": "two-factor authentication is already enabled"})
+	}
+
+	secret, err := totp.GenerateSecret()
+
+	if err != nil {
+		slog.Error("error generating totp secret", "error", err)
+		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+	}
+
+	n, err := app.store.UpsertAdminTotp(c.Request().Context(), db.UpsertAdminTotpParams{
+		AdminID: admin.ID,
+		Secret:  secret,
+	})
+
+	if err != nil {
+		slog.Error("error saving totp secret", "error", err)
+		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+	}
+
+	if n == 0 {
+		return c.JSON(http.StatusConflict, envelope{"error": "two-factor authentication is already enabled"})
+	}
+
+	return c.JSON(http.StatusCreated, envelope{
+		"secret":           secret,
+		"provisioning_uri": totp.ProvisioningURI(totpIssuer, admin.Email, secret),
+	})
+}
+
+// confirmTotpHandler finishes enrolment once the admin proves their app
+// produces valid codes. The recovery codes are only ever shown here.
+func (app *application) confirmTotpHandler(c echo.Context) error {
+
+	admin := c.Get("admin").(db.GetHashTokenForAdminRow)
+
+	var input struct {
+		Code string `json:"code" validate:"required,len=6,numeric"`
+	}
+
+	if err := c.Bind(&input); err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": err.Error()})
+	}
+
+	if err := app.validator.Struct(input); err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": err.Error()})
+	}
+
+	mfa, err := app.store.GetAdminTotp(c.Request().Context(), admin.ID)
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return c.JSON(http.StatusNotFound, envelope{"error": "no pending two-factor enrolment"})
+		default:
+			slog.Error("error fetching admin totp", "error", err)
+			return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+		}
+	}
+
+	if mfa.ConfirmedAt.Valid {
+		return c.JSON(http.StatusConflict, envelope{"error": "two-factor authentication is already enabled"})
+	}
+
+	step, ok := totp.Validate(mfa.Secret, input.Code, time.Now())
+
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, envelope{"error": "invalid code"})
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+
+	if err != nil {
+		slog.Error("error generating recovery codes", "error", err)
+		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+	}
+
+	err = app.store.TxnConfirmTotp(c.Request().Context(), admin.ID, step, hashes)
+
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrTotpNotPending):
+			return c.JSON(http.StatusConflict, envelope{"error": "two-factor authentication is already enabled"})
+		default:
+			slog.Error("error confirming totp", "error", err)
+			return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+		}
+	}
+
+	return c.JSON(http.StatusOK, envelope{"recovery_codes": codes})
+}
+
+func (app *application) regenerateRecoveryCodesHandler(c echo.Context) error {
+
+	admin := c.Get("admin").(db.GetHashTokenForAdminRow)
+
+	var input struct {
+		Code string `json:"code" validate:"required"`
+	}
+
+	if err := c.Bind(&input); err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": err.Error()})
+	}
+
+	if err := app.validator.Struct(input); err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": err.Error()})
+	}
+
+	if !admin.TotpEnabled {
+		return c.JSON(http.StatusConflict, envelope{"error": "two-factor authentication is not enabled"})
+	}
+
+	ok, err := app.verifySecondFactor(c.Request().Context(), admin.ID, input.Code)
+
+	if err != nil {
+		slog.Error("error verifying second factor", "error", err)
+		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+	}
+
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, envelope{"error": "invalid code"})
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+
+	if err != nil {
+		slog.Error("error generating recovery codes", "error", err)
+		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+	}
+
+	if err := app.store.TxnReplaceRecoveryCodes(c.Request().Context(), admin.ID, hashes); err != nil {
+		slog.Error("error replacing recovery codes", "error", err)
+		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+	}
+
+	return c.JSON(http.StatusOK, envelope{"recovery_codes": codes})
+}
+
+func (app *application) disableTotpHandler(c echo.Context) error {
+
+	admin := c.Get("admin").(db.GetHashTokenForAdminRow)
+
+	var input struct {
+		Password string `json:"password" validate:"required"`
+		Code     string `json:"code" validate:"required"`
+	}
+
+	if err := c.Bind(&input); err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": err.Error()})
+	}
+
+	if err := app.validator.Struct(input); err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": err.Error()})
+	}
+
+	if !admin.TotpEnabled {
+		return c.JSON(http.StatusConflict, envelope{"error": "two-factor authentication is not enabled"})
+	}
+
+	if admin.TotpRequired {
+		return c.JSON(http.StatusConflict, envelope{"error": "two-factor authentication is required by the security policy"})
+	}
+
+	match, err := db.PasswordMatches(db.Password{
+		Hash:      admin.PasswordHash,
+		Plaintext: input.Password,
+	})
+
+	if err != nil {
+		slog.Error("error matching password", "error", err)
+		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+	}
+
+	if !match {
+		return c.JSON(http.StatusUnauthorized, envelope{"error": "invalid password"})
+	}
+
+	ok, err := app.verifySecondFactor(c.Request().Context(), admin.ID, input.Code)
+
+	if err != nil {
+		slog.Error("error verifying second factor", "error", err)
+		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+	}
+
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, envelope{"error": "invalid code"})
+	}
+
+	if err := app.store.TxnDisableTotp(c.Request().Context(), admin.ID); err != nil {
+		slog.Error("error disabling totp", "error", err)
+		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+	}
+
+	return c.JSON(http.StatusOK, nil)
+}
+
+func (app *application) showSecurityPolicyHandler(c echo.Context) error {
+
+	policy, err := app.store.GetSecurityPolicy(c.Request().Context())
+
+	if err != nil {
+		slog.Error("error fetching security policy", "error", err)
+		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+	}
+
+	return c.JSON(http.StatusOK, envelope{"require_totp": policy.RequireTotp, "updated_at": policy.UpdatedAt})
+}
+
+func (app *application) updateSecurityPolicyHandler(c echo.Context) error {
+
+	admin := c.Get("admin").(db.GetHashTokenForAdminRow)
+
+	var input struct {
+		RequireTotp *bool `json:"require_totp" validate:"required"`
+	}
+
+	if err := c.Bind(&input); err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": err.Error()})
+	}
+
+	if err := app.validator.Struct(input); err != nil {
+		return c.JSON(http.StatusBadRequest, envelope{"error": err.Error()})
+	}
+
+	// requiring 2FA before having it would lock the caller out of this very
+	// endpoint
+	if *input.RequireTotp && !admin.TotpEnabled {
+		return c.JSON(http.StatusConflict, envelope{"error": "enable two-factor authentication on your own account first"})
+	}
+
+	err := app.store.UpdateSecurityPolicy(c.Request().Context(), db.UpdateSecurityPolicyParams{
+		RequireTotp: *input.RequireTotp,
+		UpdatedBy:   uuid.NullUUID{UUID: admin.ID, Valid: true},
+	})
+
+	if err != nil {
+		slog.Error("error updating security policy", "error", err)
+		return c.JSON(http.StatusInternalServerError, envelope{"error": "internal server error"})
+	}
+
+	return c.JSON(http.StatusOK, nil)
+}
+
+// verifySecondFactor accepts either a current TOTP code or an unused recovery
+// code. A TOTP code is single use: once a step has been accepted it and any
+// earlier step are refused.
+func (app *application) verifySecondFactor(ctx context.Context, id uuid.UUID, code string) (bool, error) {
+
+	code = strings.TrimSpace(code)
+
+	if len(code) == totp.Digits {
+
+		mfa, err := app.store.GetAdminTotp(ctx, id)
+
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return false, nil
+			}
+			return false, err
+		}
+
+		if !mfa.ConfirmedAt.Valid {
+			return false, nil
+		}
+
+		step, ok := totp.Validate(mfa.Secret, code, time.Now())
+
+		if !ok {
+			return false, nil
+		}
+
+		n, err := app.store.UpdateTotpLastStep(ctx, db.UpdateTotpLastStepParams{
+			AdminID:  id,
+			LastStep: step,
+		})
+
+		return n == 1, err
+	}
+
+	n, err := app.store.UseRecoveryCode(ctx, db.UseRecoveryCodeParams{
+		AdminID: id,
+		Hash:    recoveryCodeHash(code),
+	})
+
+	return n == 1, err
+}
+
+// generateRecoveryCodes returns codes formatted as XXXX-XXXX for display and
+// their hashes for storage.
+func generateRecoveryCodes() ([]string, [][]byte, error) {
+
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([][]byte, 0, recoveryCodeCount)
+
+	for i := 0; i < recoveryCodeCount; i++ {
+
+		b := make([]byte, 5)
+
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+
+		s := base32.StdEncoding.EncodeToString(b)
+
+		codes = append(codes, s[:4]+"-"+s[4:])
+		hashes = append(hashes, recoveryCodeHash(s))
+	}
+
+	return codes, hashes, nil
+}
+
+func recoveryCodeHash(code string) []byte {
+
+	code = strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(code))
+
+	hash := sha256.Sum256([]byte(code))
+
+	return hash[:]
+}
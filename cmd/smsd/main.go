@@ -0,0 +1,117 @@
+// Command smsd is a local stand-in for an SMS gateway. It accepts messages the
+// way sms.HTTPProvider sends them, logs them instead of delivering them, and
+// lists the most recent ones at GET /messages.
+//
+//	go run ./cmd/smsd -port 5050
+//	go run ./cmd/api -sms-url http://localhost:5050/send
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// keep is how many messages GET /messages can return.
+const keep = 200
+
+type message struct {
+	ID         string    `json:"id"`
+	From       string    `json:"from"`
+	Channel    string    `json:"channel"`
+	To         string    `json:"to"`
+	Message    string    `json:"message"`
+	ReceivedAt time.Time `json:"received_at"`
+}
+
+type gateway struct {
+	token string
+
+	mu       sync.Mutex
+	seq      int
+	messages []message
+}
+
+func main() {
+
+	var (
+		port  int
+		token string
+	)
+
+	flag.IntVar(&port, "port", 5050, "Port to listen on")
+	flag.StringVar(&token, "token", os.Getenv("SMS_TOKEN"), "Bearer token clients must present (empty accepts any)")
+	flag.Parse()
+
+	slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stdout, nil)))
+
+	g := &gateway{token: token}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /send", g.send)
+	mux.HandleFunc("GET /messages", g.list)
+
+	addr := fmt.Sprintf(":%d", port)
+
+	slog.Info("sms stand-in listening", "addr", addr)
+
+	log.Fatal(http.ListenAndServe(addr, mux))
+}
+
+func (g *gateway) send(w http.ResponseWriter, r *http.Request) {
+
+	if g.token != "" && r.Header.Get("Authorization") != "Bearer "+g.token {
+		http.Error(w, "invalid token", http.StatusUnauthorized)
+		return
+	}
+
+	var m message
+
+	if err := json.NewDecoder(r.Body).Decode(&m); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if m.To == "" || m.Message == "" {
+		http.Error(w, "to and message are required", http.StatusBadRequest)
+		return
+	}
+
+	if m.Channel == "" {
+		m.Channel = "sms"
+	}
+
+	g.mu.Lock()
+	g.seq++
+	m.ID = fmt.Sprintf("local-%d", g.seq)
+	m.ReceivedAt = time.Now()
+	g.messages = append(g.messages, m)
+	if len(g.messages) > keep {
+		g.messages = g.messages[len(g.messages)-keep:]
+	}
+	g.mu.Unlock()
+
+	slog.Info("sms", "id", m.ID, "channel", m.Channel, "from", m.From, "to", m.To, "message", m.Message)
+
+	writeJSON(w, map[string]string{"id": m.ID})
+}
+
+func (g *gateway) list(w http.ResponseWriter, r *http.Request) {
+
+	g.mu.Lock()
+	messages := append([]message{}, g.messages...)
+	g.mu.Unlock()
+
+	writeJSON(w, messages)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS house_unit_key;
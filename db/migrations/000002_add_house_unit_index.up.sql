@@ -0,0 +1,26 @@
+-- Merge houses entered more than once for the same unit into one, the one a
+-- tenant lives in if any, moving the others' tenants onto it, so that the
+-- unique index can be built.
+CREATE TEMPORARY TABLE house_duplicate AS
+SELECT id, keep_id, occupied FROM (
+    SELECT h.id, h.occupied, first_value(h.id) OVER (
+        PARTITION BY h.location, h.block, h.partition
+        ORDER BY EXISTS (SELECT 1 FROM tenant t WHERE t.house_id = h.id AND t.active) DESC, h.occupied DESC, h.id
+    ) AS keep_id
+    FROM house h
+) ranked
+WHERE id <> keep_id;
+
+UPDATE tenant t SET house_id = d.keep_id
+FROM house_duplicate d
+WHERE t.house_id = d.id;
+
+UPDATE house h SET occupied = true, version = uuid_generate_v4()
+FROM house_duplicate d
+WHERE h.id = d.keep_id AND d.occupied AND NOT h.occupied;
+
+DELETE FROM house h USING house_duplicate d WHERE h.id = d.id;
+
+DROP TABLE house_duplicate;
+
+CREATE UNIQUE INDEX IF NOT EXISTS house_unit_key ON house (location, block, partition);
@@ -0,0 +1,9 @@
+ALTER TABLE payment
+    DROP CONSTRAINT IF EXISTS payment_tenant_id_fkey,
+    ADD CONSTRAINT payment_tenant_id_fkey FOREIGN KEY (tenant_id) REFERENCES tenant(id) ON DELETE CASCADE;
+
+ALTER TABLE tenant
+    DROP CONSTRAINT IF EXISTS tenant_house_id_fkey,
+    ADD CONSTRAINT tenant_house_id_fkey FOREIGN KEY (house_id) REFERENCES house(id) ON DELETE CASCADE;
+
+ALTER TABLE house DROP COLUMN IF EXISTS archived;
@@ -0,0 +1,9 @@
+ALTER TABLE house ADD COLUMN IF NOT EXISTS archived BOOL NOT NULL DEFAULT false;
+
+ALTER TABLE tenant
+    DROP CONSTRAINT IF EXISTS tenant_house_id_fkey,
+    ADD CONSTRAINT tenant_house_id_fkey FOREIGN KEY (house_id) REFERENCES house(id) ON DELETE RESTRICT;
+
+ALTER TABLE payment
+    DROP CONSTRAINT IF EXISTS payment_tenant_id_fkey,
+    ADD CONSTRAINT payment_tenant_id_fkey FOREIGN KEY (tenant_id) REFERENCES tenant(id) ON DELETE RESTRICT;
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS inspection_photo;
+DROP TABLE IF EXISTS inspection_item;
+DROP TABLE IF EXISTS inspection;
+DROP TABLE IF EXISTS inspection_checklist_item;
@@ -0,0 +1,45 @@
+CREATE TABLE IF NOT EXISTS inspection_checklist_item (
+    id UUID DEFAULT uuid_generate_v4() PRIMARY KEY,
+    room TEXT NOT NULL,
+    item TEXT NOT NULL,
+    position INT NOT NULL DEFAULT 0,
+    UNIQUE (room, item)
+);
+
+CREATE TABLE IF NOT EXISTS inspection (
+    id UUID DEFAULT uuid_generate_v4() PRIMARY KEY,
+    house_id UUID NOT NULL REFERENCES house(id) ON DELETE RESTRICT,
+    tenant_id UUID REFERENCES tenant(id) ON DELETE RESTRICT,
+    type TEXT NOT NULL CHECK (type IN ('move-in', 'move-out', 'routine')),
+    notes TEXT NOT NULL DEFAULT '',
+    inspected_on DATE NOT NULL,
+    created_by UUID NOT NULL REFERENCES admin(id),
+    created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    signed_by UUID REFERENCES admin(id),
+    signature TEXT NOT NULL DEFAULT '',
+    signed_at TIMESTAMP(0) WITH TIME ZONE,
+    version UUID NOT NULL DEFAULT uuid_generate_v4(),
+    CHECK (type = 'routine' OR tenant_id IS NOT NULL)
+);
+
+-- a tenancy has at most one move-in and one move-out inspection
+CREATE UNIQUE INDEX IF NOT EXISTS inspection_tenancy_key ON inspection (tenant_id, type) WHERE type <> 'routine';
+
+CREATE INDEX IF NOT EXISTS inspection_house_idx ON inspection (house_id);
+
+CREATE TABLE IF NOT EXISTS inspection_item (
+    id UUID DEFAULT uuid_generate_v4() PRIMARY KEY,
+    inspection_id UUID NOT NULL REFERENCES inspection(id) ON DELETE CASCADE,
+    room TEXT NOT NULL,
+    item TEXT NOT NULL,
+    condition SMALLINT NOT NULL CHECK (condition BETWEEN 1 AND 5),
+    notes TEXT NOT NULL DEFAULT '',
+    UNIQUE (inspection_id, room, item)
+);
+
+CREATE TABLE IF NOT EXISTS inspection_photo (
+    id UUID DEFAULT uuid_generate_v4() PRIMARY KEY,
+    inspection_id UUID NOT NULL REFERENCES inspection(id) ON DELETE CASCADE,
+    item_id UUID REFERENCES inspection_item(id) ON DELETE CASCADE,
+    url TEXT NOT NULL
+);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS listing_opt_out;
+
+ALTER TABLE house
+    DROP COLUMN IF EXISTS photos,
+    DROP COLUMN IF EXISTS unit_type;
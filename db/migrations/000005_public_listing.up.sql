@@ -0,0 +1,10 @@
+ALTER TABLE house
+    ADD COLUMN IF NOT EXISTS unit_type TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS photos TEXT[] NOT NULL DEFAULT '{}';
+
+-- locations listed here are hidden from the public vacancy listing
+CREATE TABLE IF NOT EXISTS listing_opt_out (
+    location CITEXT PRIMARY KEY,
+    created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    created_by UUID NOT NULL REFERENCES admin(id)
+);
@@ -0,0 +1,3 @@
+ALTER TABLE admin DROP COLUMN IF EXISTS role;
+
+ALTER TABLE admin ALTER COLUMN is_super_user DROP DEFAULT;
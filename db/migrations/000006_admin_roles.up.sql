@@ -0,0 +1,8 @@
+ALTER TABLE admin ALTER COLUMN is_super_user SET DEFAULT false;
+
+-- existing admins keep the full access they had, new admins start with the
+-- narrowest role until a super user assigns another
+ALTER TABLE admin ADD COLUMN IF NOT EXISTS role TEXT NOT NULL DEFAULT 'owner'
+    CHECK (role IN ('owner', 'manager', 'accountant', 'caretaker'));
+
+ALTER TABLE admin ALTER COLUMN role SET DEFAULT 'caretaker';
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS admin_invite (
+    id UUID DEFAULT uuid_generate_v4() PRIMARY KEY,
+    email CITEXT NOT NULL,
+    role TEXT NOT NULL CHECK (role IN ('owner', 'manager', 'accountant', 'caretaker')),
+    hash BYTEA UNIQUE NOT NULL,
+    invited_by UUID NOT NULL REFERENCES admin(id) ON DELETE CASCADE,
+    created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    expiry TIMESTAMP(0) WITH TIME ZONE NOT NULL,
+    accepted_at TIMESTAMP(0) WITH TIME ZONE,
+    revoked_at TIMESTAMP(0) WITH TIME ZONE
+);
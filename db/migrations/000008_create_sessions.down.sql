@@ -0,0 +1,3 @@
+ALTER TABLE token DROP COLUMN IF EXISTS session_id;
+
+DROP TABLE IF EXISTS session;
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS session (
+    id UUID DEFAULT uuid_generate_v4() PRIMARY KEY,
+    admin_id UUID NOT NULL REFERENCES admin(id) ON DELETE CASCADE,
+    created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    last_used_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    ip TEXT NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS session_admin_id_idx ON session (admin_id);
+
+ALTER TABLE token ADD COLUMN session_id UUID REFERENCES session(id) ON DELETE CASCADE;
@@ -0,0 +1 @@
+ALTER TABLE token ADD COLUMN rotated_at TIMESTAMP(0) WITH TIME ZONE;
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS security_policy;
+
+DROP TABLE IF EXISTS admin_recovery_code;
+
+DROP TABLE IF EXISTS admin_totp;
@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS admin_totp (
+    admin_id UUID PRIMARY KEY REFERENCES admin(id) ON DELETE CASCADE,
+    secret TEXT NOT NULL,
+    confirmed_at TIMESTAMP(0) WITH TIME ZONE,
+    last_step BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS admin_recovery_code (
+    id UUID DEFAULT uuid_generate_v4() PRIMARY KEY,
+    admin_id UUID NOT NULL REFERENCES admin(id) ON DELETE CASCADE,
+    hash BYTEA NOT NULL,
+    used_at TIMESTAMP(0) WITH TIME ZONE,
+    UNIQUE (admin_id, hash)
+);
+
+-- single row table
+CREATE TABLE IF NOT EXISTS security_policy (
+    id BOOLEAN PRIMARY KEY DEFAULT true CHECK (id),
+    require_totp BOOLEAN NOT NULL DEFAULT false,
+    updated_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_by UUID REFERENCES admin(id) ON DELETE SET NULL
+);
+
+INSERT INTO security_policy (id) VALUES (true) ON CONFLICT DO NOTHING;
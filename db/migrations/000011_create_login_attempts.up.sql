@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS login_attempt (
+    id UUID DEFAULT uuid_generate_v4() PRIMARY KEY,
+    email CITEXT NOT NULL,
+    ip TEXT NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+    success BOOLEAN NOT NULL,
+    admin_id UUID REFERENCES admin(id) ON DELETE SET NULL,
+    created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS login_attempt_email_created_at_idx ON login_attempt (email, created_at);
+
+CREATE INDEX IF NOT EXISTS login_attempt_ip_created_at_idx ON login_attempt (ip, created_at);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS api_key_usage;
+
+DROP TABLE IF EXISTS api_key;
@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS api_key (
+    id UUID DEFAULT uuid_generate_v4() PRIMARY KEY,
+    admin_id UUID NOT NULL REFERENCES admin(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    prefix TEXT NOT NULL,
+    hash BYTEA UNIQUE NOT NULL,
+    scopes TEXT[] NOT NULL,
+    allowed_ips TEXT[] NOT NULL DEFAULT '{}',
+    expires_at TIMESTAMP(0) WITH TIME ZONE,
+    created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    last_used_at TIMESTAMP(0) WITH TIME ZONE,
+    revoked_at TIMESTAMP(0) WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS api_key_admin_id_idx ON api_key (admin_id);
+
+CREATE TABLE IF NOT EXISTS api_key_usage (
+    id BIGSERIAL PRIMARY KEY,
+    api_key_id UUID NOT NULL REFERENCES api_key(id) ON DELETE CASCADE,
+    method TEXT NOT NULL,
+    path TEXT NOT NULL,
+    ip TEXT NOT NULL,
+    status INT NOT NULL,
+    created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS api_key_usage_api_key_id_created_at_idx ON api_key_usage (api_key_id, created_at);
@@ -0,0 +1,44 @@
+DROP INDEX IF EXISTS audit_log_organization_id_idx;
+DROP INDEX IF EXISTS payment_organization_id_idx;
+DROP INDEX IF EXISTS tenant_organization_id_idx;
+
+DROP INDEX IF EXISTS house_unit_key;
+CREATE UNIQUE INDEX IF NOT EXISTS house_unit_key ON house (location, block, partition);
+
+ALTER TABLE listing_opt_out
+    DROP CONSTRAINT IF EXISTS listing_opt_out_pkey,
+    ADD PRIMARY KEY (location),
+    DROP COLUMN IF EXISTS organization_id;
+
+ALTER TABLE inspection_checklist_item
+    DROP CONSTRAINT IF EXISTS inspection_checklist_item_room_item_key,
+    ADD CONSTRAINT inspection_checklist_item_room_item_key UNIQUE (room, item),
+    DROP COLUMN IF EXISTS organization_id;
+
+ALTER TABLE inspection
+    DROP CONSTRAINT IF EXISTS inspection_tenant_id_fkey,
+    ADD CONSTRAINT inspection_tenant_id_fkey FOREIGN KEY (tenant_id) REFERENCES tenant(id) ON DELETE RESTRICT,
+    DROP CONSTRAINT IF EXISTS inspection_house_id_fkey,
+    ADD CONSTRAINT inspection_house_id_fkey FOREIGN KEY (house_id) REFERENCES house(id) ON DELETE RESTRICT,
+    DROP COLUMN IF EXISTS organization_id;
+
+ALTER TABLE payment
+    DROP CONSTRAINT IF EXISTS payment_tenant_id_fkey,
+    ADD CONSTRAINT payment_tenant_id_fkey FOREIGN KEY (tenant_id) REFERENCES tenant(id) ON DELETE RESTRICT,
+    DROP COLUMN IF EXISTS organization_id;
+
+ALTER TABLE tenant
+    DROP CONSTRAINT IF EXISTS tenant_house_id_fkey,
+    ADD CONSTRAINT tenant_house_id_fkey FOREIGN KEY (house_id) REFERENCES house(id) ON DELETE RESTRICT,
+    DROP CONSTRAINT IF EXISTS tenant_id_organization_id_key,
+    DROP COLUMN IF EXISTS organization_id;
+
+ALTER TABLE house
+    DROP CONSTRAINT IF EXISTS house_id_organization_id_key,
+    DROP COLUMN IF EXISTS organization_id;
+
+ALTER TABLE audit_log DROP COLUMN IF EXISTS organization_id;
+
+DROP TABLE IF EXISTS admin_organization;
+
+DROP TABLE IF EXISTS organization;
@@ -0,0 +1,78 @@
+CREATE TABLE IF NOT EXISTS organization (
+    id UUID DEFAULT uuid_generate_v4() PRIMARY KEY,
+    name CITEXT UNIQUE NOT NULL,
+    created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    version UUID NOT NULL DEFAULT uuid_generate_v4()
+);
+
+CREATE TABLE IF NOT EXISTS admin_organization (
+    admin_id UUID NOT NULL REFERENCES admin(id) ON DELETE CASCADE,
+    organization_id UUID NOT NULL REFERENCES organization(id) ON DELETE CASCADE,
+    created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (admin_id, organization_id)
+);
+
+CREATE INDEX IF NOT EXISTS admin_organization_organization_id_idx ON admin_organization (organization_id);
+
+-- everything recorded so far belongs to a single landlord, and every existing
+-- admin works for it
+INSERT INTO organization (name) VALUES ('Default');
+
+INSERT INTO admin_organization (admin_id, organization_id)
+SELECT a.id, o.id FROM admin a CROSS JOIN organization o;
+
+ALTER TABLE house ADD COLUMN IF NOT EXISTS organization_id UUID REFERENCES organization(id) ON DELETE RESTRICT;
+ALTER TABLE tenant ADD COLUMN IF NOT EXISTS organization_id UUID;
+ALTER TABLE payment ADD COLUMN IF NOT EXISTS organization_id UUID;
+ALTER TABLE inspection ADD COLUMN IF NOT EXISTS organization_id UUID;
+ALTER TABLE inspection_checklist_item ADD COLUMN IF NOT EXISTS organization_id UUID REFERENCES organization(id) ON DELETE CASCADE;
+ALTER TABLE listing_opt_out ADD COLUMN IF NOT EXISTS organization_id UUID REFERENCES organization(id) ON DELETE CASCADE;
+ALTER TABLE audit_log ADD COLUMN IF NOT EXISTS organization_id UUID;
+
+UPDATE house SET organization_id = (SELECT id FROM organization);
+UPDATE tenant SET organization_id = (SELECT id FROM organization);
+UPDATE payment SET organization_id = (SELECT id FROM organization);
+UPDATE inspection SET organization_id = (SELECT id FROM organization);
+UPDATE inspection_checklist_item SET organization_id = (SELECT id FROM organization);
+UPDATE listing_opt_out SET organization_id = (SELECT id FROM organization);
+
+ALTER TABLE house
+    ALTER COLUMN organization_id SET NOT NULL,
+    ADD CONSTRAINT house_id_organization_id_key UNIQUE (id, organization_id);
+
+-- the composite keys below make it impossible to attach a row to a house or
+-- tenant of another organization, whatever the application does
+ALTER TABLE tenant
+    ALTER COLUMN organization_id SET NOT NULL,
+    ADD CONSTRAINT tenant_id_organization_id_key UNIQUE (id, organization_id),
+    DROP CONSTRAINT IF EXISTS tenant_house_id_fkey,
+    ADD CONSTRAINT tenant_house_id_fkey FOREIGN KEY (house_id, organization_id) REFERENCES house(id, organization_id) ON DELETE RESTRICT;
+
+ALTER TABLE payment
+    ALTER COLUMN organization_id SET NOT NULL,
+    DROP CONSTRAINT IF EXISTS payment_tenant_id_fkey,
+    ADD CONSTRAINT payment_tenant_id_fkey FOREIGN KEY (tenant_id, organization_id) REFERENCES tenant(id, organization_id) ON DELETE RESTRICT;
+
+ALTER TABLE inspection
+    ALTER COLUMN organization_id SET NOT NULL,
+    DROP CONSTRAINT IF EXISTS inspection_house_id_fkey,
+    ADD CONSTRAINT inspection_house_id_fkey FOREIGN KEY (house_id, organization_id) REFERENCES house(id, organization_id) ON DELETE RESTRICT,
+    DROP CONSTRAINT IF EXISTS inspection_tenant_id_fkey,
+    ADD CONSTRAINT inspection_tenant_id_fkey FOREIGN KEY (tenant_id, organization_id) REFERENCES tenant(id, organization_id) ON DELETE RESTRICT;
+
+ALTER TABLE inspection_checklist_item
+    ALTER COLUMN organization_id SET NOT NULL,
+    DROP CONSTRAINT IF EXISTS inspection_checklist_item_room_item_key,
+    ADD CONSTRAINT inspection_checklist_item_room_item_key UNIQUE (organization_id, room, item);
+
+ALTER TABLE listing_opt_out
+    ALTER COLUMN organization_id SET NOT NULL,
+    DROP CONSTRAINT IF EXISTS listing_opt_out_pkey,
+    ADD PRIMARY KEY (organization_id, location);
+
+DROP INDEX IF EXISTS house_unit_key;
+CREATE UNIQUE INDEX IF NOT EXISTS house_unit_key ON house (organization_id, location, block, partition);
+
+CREATE INDEX IF NOT EXISTS tenant_organization_id_idx ON tenant (organization_id);
+CREATE INDEX IF NOT EXISTS payment_organization_id_idx ON payment (organization_id);
+CREATE INDEX IF NOT EXISTS audit_log_organization_id_idx ON audit_log (organization_id);
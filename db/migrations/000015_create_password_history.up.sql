@@ -0,0 +1,10 @@
+-- hashes of passwords an admin has replaced, newest last, so a new password
+-- can be checked against recent ones
+CREATE TABLE IF NOT EXISTS password_history (
+    id BIGSERIAL PRIMARY KEY,
+    admin_id UUID NOT NULL REFERENCES admin(id) ON DELETE CASCADE,
+    password_hash BYTEA NOT NULL,
+    created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS password_history_admin_id_idx ON password_history (admin_id, id);
@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS token_expiry_idx ON token (expiry);
@@ -0,0 +1,20 @@
+-- one row per reminder sent, or being sent, to a tenant. The unique key is
+-- what stops a tenant getting the same reminder twice.
+CREATE TABLE IF NOT EXISTS reminder (
+    id UUID DEFAULT uuid_generate_v4() PRIMARY KEY,
+    organization_id UUID NOT NULL REFERENCES organization(id),
+    tenant_id UUID NOT NULL REFERENCES tenant(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL CHECK (kind IN ('rent_due', 'lease_expiry')),
+    due_date DATE NOT NULL,
+    days_before INTEGER NOT NULL,
+    phone TEXT NOT NULL,
+    body TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'sent', 'failed')),
+    provider_id TEXT NOT NULL DEFAULT '',
+    error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    sent_at TIMESTAMP(0) WITH TIME ZONE,
+    UNIQUE (tenant_id, kind, due_date, days_before)
+);
+
+CREATE INDEX IF NOT EXISTS reminder_organization_id_idx ON reminder (organization_id);
@@ -0,0 +1,3 @@
+ALTER TABLE tenant DROP COLUMN IF EXISTS preferred_language;
+
+ALTER TABLE admin DROP COLUMN IF EXISTS preferred_language;
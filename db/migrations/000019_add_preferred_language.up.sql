@@ -0,0 +1,3 @@
+ALTER TABLE admin ADD COLUMN preferred_language TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE tenant ADD COLUMN preferred_language TEXT NOT NULL DEFAULT '';
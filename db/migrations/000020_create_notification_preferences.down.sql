@@ -0,0 +1,12 @@
+UPDATE reminder SET status = 'failed' WHERE status = 'skipped';
+ALTER TABLE reminder DROP CONSTRAINT IF EXISTS reminder_status_check;
+ALTER TABLE reminder ADD CONSTRAINT reminder_status_check CHECK (status IN ('pending', 'sent', 'failed'));
+
+UPDATE outbox SET status = 'sent' WHERE status = 'skipped';
+ALTER TABLE outbox DROP CONSTRAINT IF EXISTS outbox_status_check;
+ALTER TABLE outbox ADD CONSTRAINT outbox_status_check CHECK (status IN ('pending', 'sent', 'dead'));
+
+DROP TABLE IF EXISTS notification_consent;
+DROP TABLE IF EXISTS notification_preference;
+
+ALTER TABLE tenant DROP COLUMN IF EXISTS email;
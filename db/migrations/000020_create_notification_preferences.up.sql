@@ -0,0 +1,43 @@
+ALTER TABLE tenant ADD COLUMN IF NOT EXISTS email TEXT NOT NULL DEFAULT '';
+
+-- how a tenant wants to be reached. A tenant without a row gets the defaults:
+-- text messages at any hour, no marketing.
+CREATE TABLE IF NOT EXISTS notification_preference (
+    tenant_id UUID PRIMARY KEY REFERENCES tenant(id) ON DELETE CASCADE,
+    organization_id UUID NOT NULL REFERENCES organization(id),
+    -- in order of preference, the first one the tenant can be reached on is used
+    channels TEXT[] NOT NULL DEFAULT '{sms}' CHECK (channels <@ ARRAY['sms', 'whatsapp', 'email']),
+    -- minutes after local midnight; the window may run past midnight
+    quiet_start SMALLINT CHECK (quiet_start BETWEEN 0 AND 1439),
+    quiet_end SMALLINT CHECK (quiet_end BETWEEN 0 AND 1439),
+    marketing BOOLEAN NOT NULL DEFAULT false,
+    opted_out_at TIMESTAMP(0) WITH TIME ZONE,
+    updated_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CHECK ((quiet_start IS NULL) = (quiet_end IS NULL))
+);
+
+-- every change to a preference, kept as evidence of consent
+CREATE TABLE IF NOT EXISTS notification_consent (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id UUID NOT NULL REFERENCES tenant(id) ON DELETE CASCADE,
+    organization_id UUID NOT NULL REFERENCES organization(id),
+    source TEXT NOT NULL CHECK (source IN ('admin', 'keyword')),
+    -- the admin who made the change, or for a keyword the number it came from
+    admin_id UUID REFERENCES admin(id) ON DELETE SET NULL,
+    actor TEXT NOT NULL,
+    detail TEXT NOT NULL DEFAULT '',
+    channels TEXT[] NOT NULL,
+    quiet_start SMALLINT,
+    quiet_end SMALLINT,
+    marketing BOOLEAN NOT NULL,
+    opted_out BOOLEAN NOT NULL,
+    created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS notification_consent_tenant_id_idx ON notification_consent (tenant_id, id);
+
+ALTER TABLE outbox DROP CONSTRAINT IF EXISTS outbox_status_check;
+ALTER TABLE outbox ADD CONSTRAINT outbox_status_check CHECK (status IN ('pending', 'sent', 'skipped', 'dead'));
+
+ALTER TABLE reminder DROP CONSTRAINT IF EXISTS reminder_status_check;
+ALTER TABLE reminder ADD CONSTRAINT reminder_status_check CHECK (status IN ('pending', 'sent', 'skipped', 'failed'));
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS admin_digest;
+
+ALTER TABLE house DROP COLUMN IF EXISTS vacated_at;
@@ -0,0 +1,14 @@
+-- when the house was last left empty, for the daily digest; NULL while occupied
+ALTER TABLE house ADD COLUMN IF NOT EXISTS vacated_at TIMESTAMP(0) WITH TIME ZONE;
+
+-- how an admin wants the daily digest. An admin without a row gets the
+-- defaults: sent from 07:00 local time, with stays ending in the next 14 days.
+CREATE TABLE IF NOT EXISTS admin_digest (
+    admin_id UUID PRIMARY KEY REFERENCES admin(id) ON DELETE CASCADE,
+    enabled BOOLEAN NOT NULL DEFAULT true,
+    send_hour SMALLINT NOT NULL DEFAULT 7 CHECK (send_hour BETWEEN 0 AND 23),
+    eos_days SMALLINT NOT NULL DEFAULT 14 CHECK (eos_days BETWEEN 1 AND 90),
+    -- the local date of the last digest, so that each day gets one
+    last_sent_on DATE,
+    updated_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,23 @@
+-- whether each house was let on each day, as it stood at the last snapshot of
+-- the day
+CREATE TABLE IF NOT EXISTS occupancy_snapshot (
+    house_id UUID NOT NULL REFERENCES house(id) ON DELETE CASCADE,
+    day DATE NOT NULL,
+    organization_id UUID NOT NULL REFERENCES organization(id),
+    occupied BOOLEAN NOT NULL,
+    PRIMARY KEY (house_id, day)
+);
+
+CREATE INDEX IF NOT EXISTS occupancy_snapshot_organization_id_day_idx ON occupancy_snapshot (organization_id, day);
+
+-- the two years before snapshots began, derived from tenancies: a house is
+-- taken to be let from a tenant's sos to their eos, and is only counted from
+-- its first tenancy on
+INSERT INTO occupancy_snapshot (house_id, day, organization_id, occupied)
+SELECT h.id, d::date, h.organization_id,
+EXISTS (SELECT 1 FROM tenant t WHERE t.house_id = h.id AND d::date BETWEEN t.sos AND t.eos)
+FROM house h
+JOIN LATERAL (SELECT MIN(sos) AS first_sos FROM tenant WHERE tenant.house_id = h.id) f ON f.first_sos IS NOT NULL
+CROSS JOIN LATERAL generate_series(GREATEST(f.first_sos, CURRENT_DATE - 730), CURRENT_DATE - 1, interval '1 day') d
+WHERE NOT h.archived
+ON CONFLICT DO NOTHING;
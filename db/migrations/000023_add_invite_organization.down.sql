@@ -0,0 +1 @@
+ALTER TABLE admin_invite DROP COLUMN IF EXISTS organization_id;
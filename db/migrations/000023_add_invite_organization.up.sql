@@ -0,0 +1,28 @@
+-- an invite brings the invitee into the organization it was sent from
+ALTER TABLE admin_invite ADD COLUMN IF NOT EXISTS organization_id UUID REFERENCES organization(id) ON DELETE CASCADE;
+
+-- invites sent so far carried no organization. Those from an admin of exactly
+-- one organization are taken to be for it, and whoever accepted one is made a
+-- member of it; any other invite still pending is revoked, since there is no
+-- telling which organization it was meant for
+UPDATE admin_invite i SET organization_id = ao.organization_id
+FROM admin_organization ao
+WHERE ao.admin_id = i.invited_by
+AND (SELECT COUNT(*) FROM admin_organization o WHERE o.admin_id = i.invited_by) = 1;
+
+INSERT INTO admin_organization (admin_id, organization_id)
+SELECT a.id, i.organization_id
+FROM admin_invite i
+JOIN admin a ON a.email = i.email
+WHERE i.accepted_at IS NOT NULL AND i.organization_id IS NOT NULL
+ON CONFLICT DO NOTHING;
+
+UPDATE admin_invite SET revoked_at = NOW()
+WHERE organization_id IS NULL AND accepted_at IS NULL AND revoked_at IS NULL;
+
+UPDATE admin_invite SET organization_id = (SELECT id FROM organization ORDER BY created_at LIMIT 1)
+WHERE organization_id IS NULL;
+
+ALTER TABLE admin_invite ALTER COLUMN organization_id SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS admin_invite_organization_id_idx ON admin_invite (organization_id);
@@ -0,0 +1,2 @@
+UPDATE outbox SET payload = payload - 'organization_id'
+WHERE kind IN ('tenant_message', 'reminder');
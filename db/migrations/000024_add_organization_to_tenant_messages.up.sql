@@ -0,0 +1,7 @@
+-- messages to tenants now name the tenant's organization, which their
+-- preferences are looked up in; add it to those still waiting to go out
+UPDATE outbox o SET payload = o.payload || jsonb_build_object('organization_id', t.organization_id)
+FROM tenant t
+WHERE o.kind IN ('tenant_message', 'reminder')
+AND o.status = 'pending'
+AND t.id = (o.payload->>'tenant_id')::uuid;
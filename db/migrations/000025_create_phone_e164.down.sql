@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS tenant_organization_id_phone_idx;
+DROP FUNCTION IF EXISTS phone_e164(TEXT);
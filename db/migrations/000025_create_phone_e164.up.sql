@@ -0,0 +1,15 @@
+-- phone_e164 writes a phone number in E.164 form: tenants' numbers are kept
+-- as dialled in Tanzania, 0 and nine digits, while the SMS gateway sends them
+-- with the country code. Anything else that is not a full international
+-- number gives NULL, which matches nothing.
+CREATE OR REPLACE FUNCTION phone_e164(phone TEXT) RETURNS TEXT
+LANGUAGE sql IMMUTABLE STRICT AS $$
+    SELECT CASE
+        WHEN digits ~ '^0[1-9][0-9]{8}$' THEN '+255' || substr(digits, 2)
+        WHEN digits ~ '^00[1-9][0-9]{7,14}$' THEN '+' || substr(digits, 3)
+        WHEN digits ~ '^[1-9][0-9]{7,14}$' AND (btrim(phone) LIKE '+%' OR digits LIKE '255%') THEN '+' || digits
+    END
+    FROM (SELECT regexp_replace(phone, '\D', '', 'g') AS digits) d
+$$;
+
+CREATE INDEX IF NOT EXISTS tenant_organization_id_phone_idx ON tenant (organization_id, phone_e164(phone));
@@ -1,12 +1,17 @@
 -- name: GetAdminByEmail :one
-SELECT id, created_at, email, password_hash, activated, is_super_user , version
+SELECT id, created_at, email, password_hash, activated, is_super_user , version, role, preferred_language
 FROM admin
 WHERE email = $1;
 
 
 -- name: CreateAdmin :one
-INSERT INTO admin (email, password_hash, activated)
-VALUES ($1, $2, $3 )
+INSERT INTO admin (email, password_hash, activated, role, preferred_language)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, created_at, version;
+
+-- name: CreateSuperUser :one
+INSERT INTO admin (email, password_hash, activated, is_super_user, role, preferred_language)
+VALUES ($1, $2, true, true, 'owner', $3)
 RETURNING id, created_at, version;
 
 
@@ -18,10 +23,42 @@ RETURNING version;
 
 
 -- name: GetHashTokenForAdmin :one
-SELECT admin.id, admin.created_at,admin.email, admin.password_hash,admin.version, admin.activated
+SELECT admin.id, admin.created_at,admin.email, admin.password_hash,admin.version, admin.activated,
+admin.is_super_user, admin.role, admin.preferred_language, token.session_id,
+EXISTS (SELECT 1 FROM admin_totp WHERE admin_totp.admin_id = admin.id AND admin_totp.confirmed_at IS NOT NULL) AS totp_enabled,
+COALESCE((SELECT require_totp FROM security_policy), false)::boolean AS totp_required
 FROM admin
 INNER JOIN token
 ON admin.id = token.id
 WHERE token.hash = $1
 AND token.scope = $2
-AND token.expiry > $3;
\ No newline at end of file
+AND token.expiry > $3;
+
+-- name: GetAdmins :many
+SELECT id, created_at, email, activated, is_super_user, role, preferred_language
+FROM admin
+ORDER BY created_at;
+
+
+-- name: GetAdminById :one
+SELECT id, created_at, email, password_hash, activated, is_super_user, version, role, preferred_language
+FROM admin
+WHERE id = $1;
+
+-- name: LockAdmin :exec
+-- LockAdmin holds the admin for the rest of the transaction.
+SELECT 1 FROM admin
+WHERE id = $1
+FOR UPDATE;
+
+
+-- name: UpdateAdminRole :execrows
+UPDATE admin
+SET role = $1, version = uuid_generate_v4()
+WHERE id = $2 AND version = $3;
+
+
+-- name: UpdateAdminLanguage :execrows
+UPDATE admin
+SET preferred_language = $1, version = uuid_generate_v4()
+WHERE id = $2 AND version = $3;
@@ -0,0 +1,38 @@
+-- name: CreateApiKey :one
+INSERT INTO api_key (admin_id, name, prefix, hash, scopes, allowed_ips, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id, created_at;
+
+-- name: GetApiKeyByHash :one
+SELECT k.id, k.name, k.scopes, k.allowed_ips,
+a.id AS admin_id, a.created_at, a.email, a.password_hash, a.version, a.activated, a.is_super_user, a.role
+FROM api_key k
+INNER JOIN admin a ON a.id = k.admin_id
+WHERE k.hash = $1
+AND k.revoked_at IS NULL
+AND (k.expires_at IS NULL OR k.expires_at > $2);
+
+-- name: GetApiKeysForAdmin :many
+SELECT id, name, prefix, scopes, allowed_ips, expires_at, created_at, last_used_at, revoked_at
+FROM api_key
+WHERE admin_id = $1
+ORDER BY created_at DESC;
+
+-- name: RevokeApiKey :execrows
+UPDATE api_key SET revoked_at = NOW()
+WHERE id = $1 AND admin_id = $2 AND revoked_at IS NULL;
+
+-- name: RecordApiKeyUsage :exec
+WITH touched AS (
+  UPDATE api_key SET last_used_at = NOW() WHERE api_key.id = $1
+)
+INSERT INTO api_key_usage (api_key_id, method, path, ip, status)
+VALUES ($1, $2, $3, $4, $5);
+
+-- name: GetApiKeyUsage :many
+SELECT u.id, u.method, u.path, u.ip, u.status, u.created_at
+FROM api_key_usage u
+INNER JOIN api_key k ON k.id = u.api_key_id
+WHERE u.api_key_id = $1 AND k.admin_id = $2
+ORDER BY u.created_at DESC
+LIMIT $3;
@@ -0,0 +1,15 @@
+-- name: CreateAuditLog :exec
+INSERT INTO audit_log (admin_id, admin_email, api_key_id, action, entity_type, entity_id, before, after, ip, organization_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10);
+
+-- name: GetAuditLog :many
+SELECT * FROM audit_log
+WHERE (organization_id = sqlc.arg('organization_id') OR (organization_id IS NULL AND sqlc.arg('include_global')::boolean))
+AND (sqlc.narg('entity_type')::text IS NULL OR entity_type = sqlc.narg('entity_type'))
+AND (sqlc.narg('entity_id')::uuid IS NULL OR entity_id = sqlc.narg('entity_id'))
+AND (sqlc.narg('admin_id')::uuid IS NULL OR admin_id = sqlc.narg('admin_id'))
+AND (sqlc.narg('from')::timestamptz IS NULL OR created_at >= sqlc.narg('from'))
+AND (sqlc.narg('to')::timestamptz IS NULL OR created_at < sqlc.narg('to'))
+AND (sqlc.narg('before_id')::bigint IS NULL OR id < sqlc.narg('before_id'))
+ORDER BY id DESC
+LIMIT sqlc.arg('limit');
@@ -0,0 +1,35 @@
+-- name: GetRecentPayments :many
+SELECT p.id, t.name AS tenant_name, t.id AS tenant_id, p.amount, p.start_date, p.end_date,
+a.email AS admin_email, h.location, h.block, h.partition, p.created_at
+FROM payment p
+JOIN tenant t ON p.tenant_id = t.id
+JOIN house h ON t.house_id = h.id
+JOIN admin a ON p.created_by = a.id
+WHERE p.organization_id = sqlc.arg(organization_id) AND p.created_at >= sqlc.arg(since)
+ORDER BY p.created_at DESC;
+
+-- name: GetTenantsEnding :many
+SELECT t.id, t.name, t.phone, h.location, h.block, h.partition, t.eos
+FROM tenant t
+JOIN house h ON t.house_id = h.id
+WHERE t.organization_id = sqlc.arg(organization_id) AND t.active
+AND t.eos BETWEEN sqlc.arg(from_date) AND sqlc.arg(to_date)
+ORDER BY t.eos, t.name;
+
+-- name: GetTenantsInArrears :many
+SELECT t.id, t.name, t.phone, h.location, h.block, h.partition, h.price, t.sos, p.paid_through
+FROM tenant t
+JOIN house h ON t.house_id = h.id
+LEFT JOIN LATERAL (
+    SELECT MAX(end_date) AS paid_through FROM payment WHERE payment.tenant_id = t.id
+) p ON true
+WHERE t.organization_id = sqlc.arg(organization_id) AND t.active
+AND COALESCE(p.paid_through, t.sos - 1) < sqlc.arg(as_of)
+ORDER BY p.paid_through NULLS FIRST, t.sos, t.name;
+
+-- name: GetVacatedHouses :many
+SELECT id, location, block, partition, price, vacated_at
+FROM house
+WHERE organization_id = sqlc.arg(organization_id) AND NOT occupied AND NOT archived
+AND vacated_at >= sqlc.arg(since)
+ORDER BY vacated_at DESC;
@@ -0,0 +1,29 @@
+-- name: GetDigestSettings :one
+SELECT a.id AS admin_id, COALESCE(d.enabled, true)::boolean AS enabled,
+COALESCE(d.send_hour, 7)::smallint AS send_hour, COALESCE(d.eos_days, 14)::smallint AS eos_days,
+d.last_sent_on
+FROM admin a
+LEFT JOIN admin_digest d ON d.admin_id = a.id
+WHERE a.id = $1;
+
+-- name: UpsertDigestSettings :exec
+INSERT INTO admin_digest (admin_id, enabled, send_hour, eos_days)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (admin_id) DO UPDATE
+SET enabled = EXCLUDED.enabled, send_hour = EXCLUDED.send_hour, eos_days = EXCLUDED.eos_days, updated_at = NOW();
+
+-- name: GetDueDigests :many
+SELECT a.id, a.email, a.role, a.is_super_user, a.preferred_language,
+COALESCE(d.eos_days, 14)::smallint AS eos_days
+FROM admin a
+LEFT JOIN admin_digest d ON d.admin_id = a.id
+WHERE a.activated AND COALESCE(d.enabled, true)
+AND COALESCE(d.send_hour, 7) <= sqlc.arg(hour)::smallint
+AND (d.last_sent_on IS NULL OR d.last_sent_on < sqlc.arg(today)::date);
+
+-- name: MarkDigestSent :execrows
+INSERT INTO admin_digest (admin_id, last_sent_on)
+VALUES (sqlc.arg(admin_id), sqlc.arg(today)::date)
+ON CONFLICT (admin_id) DO UPDATE
+SET last_sent_on = EXCLUDED.last_sent_on
+WHERE admin_digest.last_sent_on IS NULL OR admin_digest.last_sent_on < EXCLUDED.last_sent_on;
@@ -1,14 +1,16 @@
 -- name: CreateHouse :one
-INSERT INTO house (location, block, partition, price, occupied) VALUES ($1,$2,$3,$4,$5) RETURNING id;
+INSERT INTO house (location, block, partition, price, occupied, unit_type, photos, organization_id) VALUES ($1,$2,$3,$4,$5,$6,$7,$8) RETURNING id;
 
 -- name: GetHouses :many
-SELECT id,location, block, partition, price , occupied FROM house;
+SELECT id,location, block, partition, price , occupied FROM house
+WHERE archived = false AND organization_id = $1;
 
--- name: UpdateHouseById :exec
+-- name: UpdateHouseById :execrows
 UPDATE house
 SET location = $1, block = $2, partition = $3, occupied = $4, price = $5, 
+unit_type = $6, photos = $7, vacated_at = CASE WHEN $4 THEN NULL WHEN occupied THEN NOW() ELSE vacated_at END,
 version = uuid_generate_v4()
-WHERE id = $6 AND version = $7;
+WHERE id = $8 AND version = $9 AND organization_id = $10;
 
 -- name: GetHouseById :one
 SELECT 
@@ -20,15 +22,76 @@ SELECT
   h.Occupied, 
   t.name, 
   t.id AS tenant_id,
-  h.version
+  h.version,
+  h.archived,
+  h.unit_type,
+  h.photos
 FROM house h
-LEFT JOIN tenant t ON h.id = t.house_id
-WHERE h.id = $1;
+LEFT JOIN tenant t ON h.id = t.house_id AND t.active
+WHERE h.id = $1 AND h.organization_id = $2;
 
 -- name: DeleteHouseById :exec    
-DELETE FROM house WHERE id = $1;
+DELETE FROM house WHERE id = $1 AND organization_id = $2;
 
+-- name: GetHouseUnits :many
+-- GetHouseUnits lists every house with what an import may not change on it:
+-- whether it is archived and whether an active tenant lives in it.
+SELECT id, location, block, partition, archived,
+EXISTS (SELECT 1 FROM tenant WHERE tenant.house_id = house.id AND tenant.active)::bool AS tenanted
+FROM house
+WHERE organization_id = $1;
 
+-- name: UpsertHouse :one
+-- A null occupied creates a vacant house and leaves an existing one as it is.
+-- An archived house, or one an active tenant lives in that would be vacated,
+-- is left alone and no row is returned.
+INSERT INTO house (location, block, partition, price, occupied, unit_type, organization_id)
+VALUES (@location, @block, @partition, @price, COALESCE(sqlc.narg('occupied')::bool, false), @unit_type, @organization_id)
+ON CONFLICT (organization_id, location, block, partition)
+DO UPDATE SET price = EXCLUDED.price,
+occupied = COALESCE(sqlc.narg('occupied')::bool, house.occupied),
+vacated_at = CASE WHEN sqlc.narg('occupied')::bool THEN NULL WHEN NOT sqlc.narg('occupied')::bool AND house.occupied THEN NOW() ELSE house.vacated_at END,
+unit_type = COALESCE(NULLIF(EXCLUDED.unit_type, ''), house.unit_type),
+version = uuid_generate_v4()
+WHERE NOT house.archived
+AND (sqlc.narg('occupied')::bool IS NOT FALSE OR NOT EXISTS (SELECT 1 FROM tenant WHERE tenant.house_id = house.id AND tenant.active))
+RETURNING id;
+
+-- name: SetHouseArchived :execrows
+UPDATE house
+SET archived = $1, version = uuid_generate_v4()
+WHERE id = $2 AND version = $3 AND organization_id = $4;
+
+-- name: GetHouseHistoryCount :one
+SELECT
+  (SELECT COUNT(*) FROM tenant t WHERE t.house_id = $1 AND t.organization_id = $2) AS tenants,
+  (SELECT COUNT(*) FROM payment p JOIN tenant t ON p.tenant_id = t.id WHERE t.house_id = $1 AND p.organization_id = $2) AS payments;
+
+-- name: OccupyHouse :execrows
+-- OccupyHouse lets a house that is vacant and not archived. It changes no row
+-- otherwise, and the row lock it takes keeps two tenants from moving in at
+-- once.
+UPDATE house
+SET occupied = true, vacated_at = NULL, version = uuid_generate_v4()
+WHERE id = $1 AND organization_id = $2 AND NOT occupied AND NOT archived;
+
+-- name: SetHouseOccupied :exec
+UPDATE house
+SET occupied = $1, vacated_at = CASE WHEN $1 THEN NULL WHEN occupied THEN NOW() ELSE vacated_at END,
+version = uuid_generate_v4()
+WHERE id = $2 AND organization_id = $3;
 
+-- name: GetHousesByIds :many
+SELECT * FROM house
+WHERE id = ANY(sqlc.arg(ids)::uuid[]) AND organization_id = sqlc.arg(organization_id);
 
+-- name: LockHouse :exec
+-- LockHouse holds the house for the rest of the transaction.
+SELECT 1 FROM house
+WHERE id = $1 AND organization_id = $2
+FOR UPDATE;
 
+-- name: GetHouseIdByUnit :one
+SELECT id FROM house
+WHERE organization_id = $1 AND location = $2 AND block = $3 AND partition = $4
+FOR UPDATE;
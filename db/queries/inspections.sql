@@ -0,0 +1,55 @@
+-- name: GetChecklistItems :many
+SELECT id, room, item, position FROM inspection_checklist_item
+WHERE organization_id = $1
+ORDER BY position, room, item;
+
+-- name: CreateChecklistItem :one
+INSERT INTO inspection_checklist_item (room, item, position, organization_id) VALUES ($1, $2, $3, $4) RETURNING id;
+
+-- name: DeleteChecklistItem :execrows
+DELETE FROM inspection_checklist_item WHERE id = $1 AND organization_id = $2;
+
+-- name: CreateInspection :one
+INSERT INTO inspection (house_id, tenant_id, type, notes, inspected_on, created_by, organization_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id;
+
+-- name: CreateInspectionItem :one
+INSERT INTO inspection_item (inspection_id, room, item, condition, notes)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id;
+
+-- name: CreateInspectionPhoto :exec
+INSERT INTO inspection_photo (inspection_id, item_id, url) VALUES ($1, $2, $3);
+
+-- name: GetInspectionById :one
+SELECT * FROM inspection
+WHERE id = $1 AND organization_id = $2;
+
+-- name: GetInspectionsByHouse :many
+SELECT * FROM inspection
+WHERE house_id = $1 AND organization_id = $2
+ORDER BY inspected_on DESC, created_at DESC;
+
+-- name: GetTenancyInspection :one
+SELECT * FROM inspection
+WHERE tenant_id = $1 AND type = $2 AND organization_id = $3;
+
+-- name: GetInspectionItems :many
+SELECT ii.id, ii.room, ii.item, ii.condition, ii.notes FROM inspection_item ii
+JOIN inspection i ON ii.inspection_id = i.id
+WHERE ii.inspection_id = $1 AND i.organization_id = $2
+ORDER BY ii.room, ii.item;
+
+-- name: GetInspectionPhotos :many
+SELECT ip.id, ip.item_id, ip.url FROM inspection_photo ip
+JOIN inspection i ON ip.inspection_id = i.id
+WHERE ip.inspection_id = $1 AND i.organization_id = $2;
+
+-- name: SignInspection :execrows
+UPDATE inspection
+SET signed_by = $1, signature = $2, signed_at = NOW(), version = uuid_generate_v4()
+WHERE id = $3 AND version = $4 AND organization_id = $5 AND signed_at IS NULL;
+
+-- name: DeleteInspection :execrows
+DELETE FROM inspection WHERE id = $1 AND organization_id = $2 AND signed_at IS NULL;
@@ -0,0 +1,27 @@
+-- name: CreateInvite :one
+INSERT INTO admin_invite (email, role, hash, invited_by, expiry, organization_id)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id;
+
+-- name: GetInvites :many
+SELECT i.id, i.email, i.role, a.email AS invited_by, i.created_at, i.expiry, i.accepted_at, i.revoked_at
+FROM admin_invite i
+JOIN admin a ON i.invited_by = a.id
+WHERE i.organization_id = $1
+ORDER BY i.created_at DESC;
+
+-- name: GetPendingInviteByHash :one
+SELECT id, email, role FROM admin_invite
+WHERE hash = $1
+AND expiry > $2
+AND accepted_at IS NULL
+AND revoked_at IS NULL;
+
+-- name: AcceptInvite :one
+UPDATE admin_invite SET accepted_at = NOW()
+WHERE id = $1 AND accepted_at IS NULL AND revoked_at IS NULL
+RETURNING organization_id;
+
+-- name: RevokeInvite :execrows
+UPDATE admin_invite SET revoked_at = NOW()
+WHERE id = $1 AND organization_id = $2 AND accepted_at IS NULL AND revoked_at IS NULL;
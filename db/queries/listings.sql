@@ -0,0 +1,11 @@
+-- name: GetListingOptOuts :many
+SELECT * FROM listing_opt_out
+WHERE organization_id = $1
+ORDER BY location;
+
+-- name: CreateListingOptOut :exec
+INSERT INTO listing_opt_out (location, created_by, organization_id) VALUES ($1, $2, $3)
+ON CONFLICT (organization_id, location) DO NOTHING;
+
+-- name: DeleteListingOptOut :execrows
+DELETE FROM listing_opt_out WHERE location = $1 AND organization_id = $2;
@@ -0,0 +1,28 @@
+-- name: CreateLoginAttempt :exec
+INSERT INTO login_attempt (email, ip, user_agent, success, admin_id)
+VALUES ($1, $2, $3, $4, $5);
+
+-- name: GetLoginFailuresByEmail :one
+SELECT COUNT(*) AS failures, MAX(created_at)::timestamptz AS last_failure
+FROM login_attempt
+WHERE email = $1
+AND success = false
+AND created_at > $2
+AND created_at > COALESCE(
+  (SELECT MAX(s.created_at) FROM login_attempt s WHERE s.email = $1 AND s.success),
+  '-infinity'
+);
+
+-- name: GetLoginFailuresByIp :one
+SELECT COUNT(*) AS failures, MAX(created_at)::timestamptz AS last_failure
+FROM login_attempt
+WHERE ip = $1
+AND success = false
+AND created_at > $2;
+
+-- name: GetSignInsForAdmin :many
+SELECT id, ip, user_agent, success, created_at
+FROM login_attempt
+WHERE email = (SELECT email FROM admin WHERE admin.id = $1)
+ORDER BY created_at DESC
+LIMIT $2;
@@ -0,0 +1,31 @@
+-- name: GetNotificationSettings :one
+SELECT t.id AS tenant_id, t.organization_id, t.phone, t.email,
+COALESCE(p.channels, '{sms}')::text[] AS channels, p.quiet_start, p.quiet_end,
+COALESCE(p.marketing, false)::boolean AS marketing, p.opted_out_at
+FROM tenant t
+LEFT JOIN notification_preference p ON p.tenant_id = t.id
+WHERE t.id = $1 AND t.organization_id = $2;
+
+-- name: UpsertNotificationPreference :exec
+INSERT INTO notification_preference (tenant_id, organization_id, channels, quiet_start, quiet_end, marketing, opted_out_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (tenant_id) DO UPDATE
+SET channels = EXCLUDED.channels, quiet_start = EXCLUDED.quiet_start, quiet_end = EXCLUDED.quiet_end,
+marketing = EXCLUDED.marketing, opted_out_at = EXCLUDED.opted_out_at, updated_at = NOW();
+
+-- name: CreateNotificationConsent :exec
+INSERT INTO notification_consent
+(tenant_id, organization_id, source, admin_id, actor, detail, channels, quiet_start, quiet_end, marketing, opted_out)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11);
+
+-- name: GetNotificationConsents :many
+SELECT id, source, admin_id, actor, detail, channels, quiet_start, quiet_end, marketing, opted_out, created_at
+FROM notification_consent
+WHERE tenant_id = $1 AND organization_id = $2
+ORDER BY id DESC;
+
+-- name: GetTenantsByPhone :many
+-- Numbers are compared in full once both are in E.164 form.
+SELECT id FROM tenant
+WHERE organization_id = sqlc.arg(organization_id)
+AND phone_e164(phone) = phone_e164(sqlc.arg(phone)::text);
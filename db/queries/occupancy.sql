@@ -0,0 +1,50 @@
+-- name: SnapshotOccupancy :execrows
+INSERT INTO occupancy_snapshot (house_id, day, organization_id, occupied)
+SELECT id, sqlc.arg(day)::date, organization_id, occupied FROM house
+WHERE NOT archived
+ON CONFLICT (house_id, day) DO UPDATE SET occupied = EXCLUDED.occupied;
+
+-- name: GetOccupancyByMonth :many
+WITH s AS (
+    SELECT o.house_id, o.day, o.occupied,
+    LAG(o.occupied) OVER (PARTITION BY o.house_id ORDER BY o.day) AS prev_occupied
+    FROM occupancy_snapshot o
+    WHERE o.organization_id = sqlc.arg(organization_id)
+    AND o.day BETWEEN sqlc.arg(from_day)::date - 1 AND sqlc.arg(to_day)::date
+)
+SELECT date_trunc('month', s.day)::date AS month, h.location, h.block,
+COUNT(DISTINCT s.day) AS days,
+COUNT(*) AS house_days,
+COUNT(*) FILTER (WHERE s.occupied) AS occupied_days,
+COUNT(*) FILTER (WHERE NOT s.prev_occupied AND s.occupied) AS move_ins,
+COUNT(*) FILTER (WHERE s.prev_occupied AND NOT s.occupied) AS move_outs
+FROM s
+JOIN house h ON h.id = s.house_id
+WHERE s.day >= sqlc.arg(from_day)::date
+GROUP BY 1, 2, 3
+ORDER BY 1, 2, 3;
+
+-- name: GetEndedVacanciesByMonth :many
+-- Vacancies that ended, with the house let again, from from_day to to_day,
+-- by the month they ended in. A vacancy runs from the first snapshot of a
+-- house found empty to the first found let again.
+WITH s AS (
+    SELECT house_id, day, occupied,
+    ROW_NUMBER() OVER (PARTITION BY house_id ORDER BY day)
+    - ROW_NUMBER() OVER (PARTITION BY house_id, occupied ORDER BY day) AS run
+    FROM occupancy_snapshot
+    WHERE organization_id = sqlc.arg(organization_id) AND day <= sqlc.arg(to_day)::date
+),
+runs AS (
+    SELECT house_id, occupied, MIN(day) AS start_day,
+    LEAD(MIN(day)) OVER (PARTITION BY house_id ORDER BY MIN(day)) AS next_start
+    FROM s
+    GROUP BY house_id, occupied, run
+)
+SELECT date_trunc('month', r.next_start)::date AS month, h.location, h.block,
+COUNT(*) AS vacancies, SUM(r.next_start - r.start_day)::bigint AS vacant_days
+FROM runs r
+JOIN house h ON h.id = r.house_id
+WHERE NOT r.occupied AND r.next_start BETWEEN sqlc.arg(from_day)::date AND sqlc.arg(to_day)::date
+GROUP BY 1, 2, 3
+ORDER BY 1, 2, 3;
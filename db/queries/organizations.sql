@@ -0,0 +1,44 @@
+-- name: CreateOrganization :one
+INSERT INTO organization (name) VALUES ($1)
+RETURNING *;
+
+-- name: GetOrganizations :many
+SELECT * FROM organization
+ORDER BY name;
+
+-- name: GetOrganizationsForAdmin :many
+SELECT o.* FROM organization o
+JOIN admin_organization ao ON ao.organization_id = o.id
+WHERE ao.admin_id = $1
+ORDER BY o.name;
+
+-- name: GetOrganizationForAdmin :one
+SELECT o.* FROM organization o
+WHERE o.id = sqlc.arg(id)
+AND (sqlc.arg(is_super_user)::boolean OR EXISTS (
+  SELECT 1 FROM admin_organization ao WHERE ao.organization_id = o.id AND ao.admin_id = sqlc.arg(admin_id)
+));
+
+-- name: UpdateOrganization :execrows
+UPDATE organization
+SET name = $1, version = uuid_generate_v4()
+WHERE id = $2 AND version = $3;
+
+-- name: GetOrganizationMembers :many
+SELECT a.id, a.email, a.role, a.is_super_user, ao.created_at AS added_at
+FROM admin_organization ao
+JOIN admin a ON a.id = ao.admin_id
+WHERE ao.organization_id = $1
+ORDER BY a.email;
+
+-- name: AddOrganizationMember :exec
+INSERT INTO admin_organization (admin_id, organization_id) VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: AddMemberOfAllOrganizations :exec
+INSERT INTO admin_organization (admin_id, organization_id)
+SELECT $1, id FROM organization
+ON CONFLICT DO NOTHING;
+
+-- name: RemoveOrganizationMember :execrows
+DELETE FROM admin_organization WHERE admin_id = $1 AND organization_id = $2;
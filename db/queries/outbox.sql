@@ -0,0 +1,52 @@
+-- name: CreateOutboxMessage :exec
+INSERT INTO outbox (kind, recipient, payload) VALUES ($1, $2, $3);
+
+-- name: ClaimOutboxMessages :many
+UPDATE outbox SET attempts = attempts + 1, next_attempt_at = sqlc.arg(lease_until)
+WHERE id IN (
+    SELECT id FROM outbox
+    WHERE status = 'pending' AND next_attempt_at <= NOW()
+    ORDER BY id
+    LIMIT sqlc.arg('limit')
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, kind, payload, attempts;
+
+-- name: MarkOutboxMessageSent :exec
+UPDATE outbox SET status = 'sent', sent_at = NOW(), last_error = '', payload = 'null'
+WHERE id = $1;
+
+-- name: MarkOutboxMessageFailed :exec
+UPDATE outbox SET last_error = $1, next_attempt_at = $2
+WHERE id = $3;
+
+-- name: MarkOutboxMessageDead :exec
+UPDATE outbox SET status = 'dead', last_error = $1
+WHERE id = $2;
+
+-- name: GetOutboxMessages :many
+SELECT id, kind, recipient, status, attempts, next_attempt_at, last_error, created_at, sent_at
+FROM outbox
+WHERE (sqlc.narg('status')::text IS NULL OR status = sqlc.narg('status'))
+AND (sqlc.narg('before_id')::bigint IS NULL OR id < sqlc.narg('before_id'))
+ORDER BY id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: ReplayOutboxMessage :execrows
+UPDATE outbox SET status = 'pending', attempts = 0, next_attempt_at = NOW()
+WHERE id = $1 AND status = 'dead';
+
+-- name: ReplayDeadOutboxMessages :execrows
+UPDATE outbox SET status = 'pending', attempts = 0, next_attempt_at = NOW()
+WHERE status = 'dead';
+
+-- name: DeleteSentOutboxMessages :execrows
+DELETE FROM outbox WHERE status IN ('sent', 'skipped') AND sent_at < $1;
+
+-- name: MarkOutboxMessageSkipped :exec
+UPDATE outbox SET status = 'skipped', sent_at = NOW(), last_error = $1, payload = 'null'
+WHERE id = $2;
+
+-- name: DeferOutboxMessage :exec
+UPDATE outbox SET attempts = attempts - 1, next_attempt_at = $1
+WHERE id = $2;
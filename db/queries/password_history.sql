@@ -0,0 +1,8 @@
+-- name: CreatePasswordHistory :exec
+INSERT INTO password_history (admin_id, password_hash) VALUES ($1, $2);
+
+-- name: GetPasswordHistory :many
+SELECT password_hash FROM password_history
+WHERE admin_id = $1
+ORDER BY id DESC
+LIMIT $2;
@@ -1,10 +1,16 @@
--- name: CreatePayment :exec
-INSERT INTO payment (tenant_id, amount, start_date, end_date, created_by) VALUES ($1,$2,$3,$4,$5) RETURNING id;
+-- name: CreatePayment :one
+INSERT INTO payment (tenant_id, amount, start_date, end_date, created_by, organization_id) VALUES ($1,$2,$3,$4,$5,$6) RETURNING id;
 
 
 -- name: GetPaymentById :one
 SELECT * FROM payment
-WHERE id = $1;
+WHERE id = $1 AND organization_id = $2;
+
+-- name: LockPayment :exec
+-- LockPayment holds the payment for the rest of the transaction.
+SELECT 1 FROM payment
+WHERE id = $1 AND organization_id = $2
+FOR UPDATE;
 
 -- name: GetDetailedPaymentById :one
 SELECT p.id, t.name AS tenant_name,
@@ -14,7 +20,7 @@ FROM payment p
 JOIN tenant t ON p.tenant_id = t.id
 JOIN house h ON t.house_id = h.id
 JOIN admin a ON p.created_by = a.id
-WHERE p.id = $1;
+WHERE p.id = $1 AND p.organization_id = $2;
 
 
 -- name: GetAllPayments :many
@@ -24,14 +30,15 @@ p.created_at , p.updated_at, p.version
 FROM payment p
 JOIN tenant t ON p.tenant_id = t.id
 JOIN house h ON t.house_id = h.id
-JOIN admin a ON p.created_by = a.id;
+JOIN admin a ON p.created_by = a.id
+WHERE p.organization_id = $1;
 
 
--- name: UpdatePayment :exec
+-- name: UpdatePayment :execrows
 UPDATE payment
 SET amount = $1, start_date = $2, end_date = $3, version = uuid_generate_v4(), updated_at = NOW()
-WHERE id = $4 AND version = $5;
+WHERE id = $4 AND version = $5 AND organization_id = $6;
 
 
 -- name: DeletePayment :exec    
-DELETE FROM payment WHERE id = $1;
+DELETE FROM payment WHERE id = $1 AND organization_id = $2;
@@ -0,0 +1,34 @@
+-- name: GetReminderCandidates :many
+SELECT t.id, t.organization_id, t.name, t.phone, t.email, t.preferred_language, h.location, h.block, h.partition, t.eos, p.paid_through
+FROM tenant t
+JOIN house h ON h.id = t.house_id
+LEFT JOIN LATERAL (
+    SELECT MAX(end_date) AS paid_through FROM payment WHERE payment.tenant_id = t.id
+) p ON true
+WHERE t.active AND (t.phone <> '' OR t.email <> '')
+AND (t.eos BETWEEN sqlc.arg(from_date) AND sqlc.arg(to_date)
+    OR p.paid_through BETWEEN sqlc.arg(from_date) AND sqlc.arg(to_date));
+
+-- name: CreateReminder :one
+INSERT INTO reminder (organization_id, tenant_id, kind, due_date, days_before, phone, body)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (tenant_id, kind, due_date, days_before) DO NOTHING
+RETURNING id;
+
+-- name: MarkReminderSent :exec
+UPDATE reminder SET status = 'sent', provider_id = $1, error = '', sent_at = NOW()
+WHERE id = $2;
+
+-- name: MarkReminderFailed :exec
+UPDATE reminder SET status = 'failed', error = $1
+WHERE id = $2 AND status <> 'sent';
+
+-- name: GetRemindersForTenant :many
+SELECT id, kind, due_date, days_before, phone, body, status, provider_id, error, created_at, sent_at
+FROM reminder
+WHERE tenant_id = $1 AND organization_id = $2
+ORDER BY created_at DESC;
+
+-- name: MarkReminderSkipped :exec
+UPDATE reminder SET status = 'skipped', error = $1
+WHERE id = $2 AND status <> 'sent';
@@ -0,0 +1,32 @@
+-- name: GetIncomeByHouse :many
+-- Rent expected and collected for each house and month from from_month to
+-- to_month, both the first of a month. A house is expected to bring in its
+-- price for every month a tenancy, from sos to eos, overlaps; a payment counts
+-- as collected in the local month it was recorded in.
+WITH months AS (
+    SELECT generate_series(sqlc.arg(from_month)::date, sqlc.arg(to_month)::date, interval '1 month')::date AS month
+),
+expected AS (
+    SELECT m.month, h.id AS house_id, h.price
+    FROM months m
+    JOIN house h ON h.organization_id = sqlc.arg(organization_id)
+    WHERE EXISTS (
+        SELECT 1 FROM tenant t
+        WHERE t.house_id = h.id AND t.sos < m.month + interval '1 month' AND t.eos >= m.month
+    )
+),
+collected AS (
+    SELECT date_trunc('month', p.created_at AT TIME ZONE sqlc.arg(time_zone)::text)::date AS month,
+    t.house_id, SUM(p.amount) AS amount
+    FROM payment p
+    JOIN tenant t ON t.id = p.tenant_id
+    WHERE p.organization_id = sqlc.arg(organization_id)
+    GROUP BY 1, 2
+)
+SELECT COALESCE(e.month, c.month)::date AS month, h.id AS house_id, h.location, h.block, h.partition,
+COALESCE(e.price, 0)::bigint AS expected, COALESCE(c.amount, 0)::bigint AS collected
+FROM expected e
+FULL JOIN collected c ON c.month = e.month AND c.house_id = e.house_id
+JOIN house h ON h.id = COALESCE(e.house_id, c.house_id)
+WHERE COALESCE(e.month, c.month) BETWEEN sqlc.arg(from_month)::date AND sqlc.arg(to_month)::date
+ORDER BY 1, h.location, h.block, h.partition;
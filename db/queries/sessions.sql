@@ -0,0 +1,29 @@
+-- name: CreateSession :one
+INSERT INTO session (admin_id, ip, user_agent)
+VALUES ($1, $2, $3)
+RETURNING id;
+
+-- name: GetSessionsForAdmin :many
+SELECT s.id, s.created_at, s.last_used_at, s.ip, s.user_agent
+FROM session s
+WHERE s.admin_id = $1
+AND EXISTS (SELECT 1 FROM token t WHERE t.session_id = s.id AND t.expiry > NOW())
+ORDER BY s.last_used_at DESC;
+
+-- name: TouchSession :exec
+UPDATE session SET last_used_at = NOW()
+WHERE id = $1 AND last_used_at < NOW() - INTERVAL '1 minute';
+
+-- name: DeleteSession :execrows
+DELETE FROM session WHERE id = $1 AND admin_id = $2;
+
+-- name: DeleteAllSessions :exec
+DELETE FROM session WHERE admin_id = $1;
+
+-- name: DeleteOtherSessions :exec
+DELETE FROM session WHERE admin_id = $1 AND id IS DISTINCT FROM sqlc.narg(keep)::uuid;
+
+-- name: DeleteEmptySessions :execrows
+DELETE FROM session s
+WHERE s.created_at < $1
+AND NOT EXISTS (SELECT 1 FROM token t WHERE t.session_id = s.id);
@@ -1,14 +1,21 @@
--- name: CreateTenant :exec
+-- name: CreateTenant :one
 INSERT INTO TENANT
-(name, house_id, phone, personal_id_type,personal_id, active, sos, eos) 
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8) ;
+(name, house_id, phone, personal_id_type,personal_id, active, sos, eos, organization_id, preferred_language, email) 
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+RETURNING id;
 
 -- name: GetTenantById :one
 SELECT t.id AS tenant_id, t.name, t.house_id,h.location, h.block, h.partition, h.price ,
-t.phone, t.personal_id_type,t.personal_id, t.active, t.sos, t.eos, t.version 
+t.phone, t.personal_id_type,t.personal_id, t.active, t.sos, t.eos, t.version, t.preferred_language, t.email
 FROM tenant t
 JOIN house h ON t.house_id = h.id
-WHERE t.id = $1;
+WHERE t.id = $1 AND t.organization_id = $2;
+
+-- name: LockTenant :exec
+-- LockTenant holds the tenant for the rest of the transaction.
+SELECT 1 FROM tenant
+WHERE id = $1 AND organization_id = $2
+FOR UPDATE;
 
 -- name: GetTenants :many
 SELECT 
@@ -23,14 +30,17 @@ SELECT
     t.personal_id, 
     t.active, 
     t.sos, 
-    t.eos
+    t.eos,
+    t.preferred_language,
+    t.email
 FROM tenant t
-JOIN house h ON t.house_id = h.id;
+JOIN house h ON t.house_id = h.id
+WHERE t.organization_id = $1;
 
--- name: UpdateTenant :exec
+-- name: UpdateTenant :execrows
 UPDATE tenant 
-SET name = $1, house_id = $2, phone = $3 ,personal_id_type = $4 ,personal_id = $5 ,active = $6, sos=$7 ,eos = $8, version = uuid_generate_v4()
-WHERE id = $9 AND version = $10;
+SET name = $1, house_id = $2, phone = $3 ,personal_id_type = $4 ,personal_id = $5 ,active = $6, sos=$7 ,eos = $8, preferred_language = $12, email = $13, version = uuid_generate_v4()
+WHERE id = $9 AND version = $10 AND organization_id = $11;
 
 
 
@@ -1,5 +1,24 @@
 -- name: CreateToken :exec
-INSERT INTO token (hash, id, expiry, scope) VALUES ($1, $2, $3, $4);
+INSERT INTO token (hash, id, expiry, scope, session_id) VALUES ($1, $2, $3, $4, $5);
 
 -- name: DeleteAllToken :exec
 DELETE FROM token WHERE scope = $1 AND id = $2;
+
+-- name: DeleteToken :exec
+DELETE FROM token WHERE hash = $1;
+
+-- name: GetTokenForUpdate :one
+SELECT hash, id, expiry, scope, session_id, rotated_at FROM token
+WHERE hash = $1 AND scope = $2
+FOR UPDATE;
+
+-- name: MarkTokenRotated :exec
+UPDATE token SET rotated_at = NOW() WHERE hash = $1;
+
+-- name: DeleteSessionlessTokens :exec
+DELETE FROM token WHERE id = $1 AND scope = ANY(sqlc.arg(scopes)::text[]) AND session_id IS NULL;
+
+-- name: DeleteExpiredTokens :execrows
+DELETE FROM token WHERE hash IN (
+    SELECT hash FROM token WHERE expiry < $1 LIMIT $2
+);
@@ -0,0 +1,40 @@
+-- name: GetAdminTotp :one
+SELECT admin_id, secret, confirmed_at, last_step, created_at FROM admin_totp
+WHERE admin_id = $1;
+
+-- name: UpsertAdminTotp :execrows
+INSERT INTO admin_totp (admin_id, secret) VALUES ($1, $2)
+ON CONFLICT (admin_id) DO UPDATE
+SET secret = EXCLUDED.secret, created_at = NOW()
+WHERE admin_totp.confirmed_at IS NULL;
+
+-- name: ConfirmAdminTotp :execrows
+UPDATE admin_totp SET confirmed_at = NOW(), last_step = $2
+WHERE admin_id = $1 AND confirmed_at IS NULL;
+
+-- name: UpdateTotpLastStep :execrows
+UPDATE admin_totp SET last_step = $2
+WHERE admin_id = $1 AND confirmed_at IS NOT NULL AND last_step < $2;
+
+-- name: DeleteAdminTotp :exec
+DELETE FROM admin_totp WHERE admin_id = $1;
+
+-- name: CreateRecoveryCode :exec
+INSERT INTO admin_recovery_code (admin_id, hash) VALUES ($1, $2);
+
+-- name: DeleteRecoveryCodes :exec
+DELETE FROM admin_recovery_code WHERE admin_id = $1;
+
+-- name: UseRecoveryCode :execrows
+UPDATE admin_recovery_code SET used_at = NOW()
+WHERE admin_id = $1 AND hash = $2 AND used_at IS NULL;
+
+-- name: CountRecoveryCodes :one
+SELECT COUNT(*) FROM admin_recovery_code
+WHERE admin_id = $1 AND used_at IS NULL;
+
+-- name: GetSecurityPolicy :one
+SELECT id, require_totp, updated_at, updated_by FROM security_policy;
+
+-- name: UpdateSecurityPolicy :exec
+UPDATE security_policy SET require_totp = $1, updated_at = NOW(), updated_by = $2;
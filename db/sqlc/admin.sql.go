@@ -13,15 +13,17 @@ import (
 )
 
 const createAdmin = `-- name: CreateAdmin :one
-INSERT INTO admin (email, password_hash, activated)
-VALUES ($1, $2, $3 )
+INSERT INTO admin (email, password_hash, activated, role, preferred_language)
+VALUES ($1, $2, $3, $4, $5)
 RETURNING id, created_at, version
 `
 
 type CreateAdminParams struct {
-	Email        string `json:"email"`
-	PasswordHash []byte `json:"password_hash"`
-	Activated    bool   `json:"activated"`
+	Email             string `json:"email"`
+	PasswordHash      []byte `json:"password_hash"`
+	Activated         bool   `json:"activated"`
+	Role              string `json:"role"`
+	PreferredLanguage string `json:"preferred_language"`
 }
 
 type CreateAdminRow struct {
@@ -31,14 +33,68 @@ type CreateAdminRow struct {
 }
 
 func (q *Queries) CreateAdmin(ctx context.Context, arg CreateAdminParams) (CreateAdminRow, error) {
-	row := q.db.QueryRowContext(ctx, createAdmin, arg.Email, arg.PasswordHash, arg.Activated)
+	row := q.db.QueryRowContext(ctx, createAdmin,
+		arg.Email,
+		arg.PasswordHash,
+		arg.Activated,
+		arg.Role,
+		arg.PreferredLanguage,
+	)
 	var i CreateAdminRow
 	err := row.Scan(&i.ID, &i.CreatedAt, &i.Version)
 	return i, err
 }
 
+const createSuperUser = `-- name: CreateSuperUser :one
+INSERT INTO admin (email, password_hash, activated, is_super_user, role, preferred_language)
+VALUES ($1, $2, true, true, 'owner', $3)
+RETURNING id, created_at, version
+`
+
+type CreateSuperUserParams struct {
+	Email             string `json:"email"`
+	PasswordHash      []byte `json:"password_hash"`
+	PreferredLanguage string `json:"preferred_language"`
+}
+
+type CreateSuperUserRow struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Version   uuid.UUID `json:"version"`
+}
+
+func (q *Queries) CreateSuperUser(ctx context.Context, arg CreateSuperUserParams) (CreateSuperUserRow, error) {
+	row := q.db.QueryRowContext(ctx, createSuperUser, arg.Email, arg.PasswordHash, arg.PreferredLanguage)
+	var i CreateSuperUserRow
+	err := row.Scan(&i.ID, &i.CreatedAt, &i.Version)
+	return i, err
+}
+
+const getAdminById = `-- name: GetAdminById :one
+SELECT id, created_at, email, password_hash, activated, is_super_user, version, role, preferred_language
+FROM admin
+WHERE id = $1
+`
+
+func (q *Queries) GetAdminById(ctx context.Context, id uuid.UUID) (Admin, error) {
+	row := q.db.QueryRowContext(ctx, getAdminById, id)
+	var i Admin
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.Email,
+		&i.PasswordHash,
+		&i.Activated,
+		&i.IsSuperUser,
+		&i.Version,
+		&i.Role,
+		&i.PreferredLanguage,
+	)
+	return i, err
+}
+
 const getAdminByEmail = `-- name: GetAdminByEmail :one
-SELECT id, created_at, email, password_hash, activated, is_super_user , version
+SELECT id, created_at, email, password_hash, activated, is_super_user , version, role, preferred_language
 FROM admin
 WHERE email = $1
 `
@@ -54,12 +110,64 @@ func (q *Queries) GetAdminByEmail(ctx context.Context, email string) (Admin, err
 		&i.Activated,
 		&i.IsSuperUser,
 		&i.Version,
+		&i.Role,
+		&i.PreferredLanguage,
 	)
 	return i, err
 }
 
+const getAdmins = `-- name: GetAdmins :many
+SELECT id, created_at, email, activated, is_super_user, role, preferred_language
+FROM admin
+ORDER BY created_at
+`
+
+type GetAdminsRow struct {
+	ID                uuid.UUID `json:"id"`
+	CreatedAt         time.Time `json:"created_at"`
+	Email             string    `json:"email"`
+	Activated         bool      `json:"activated"`
+	IsSuperUser       bool      `json:"is_super_user"`
+	Role              string    `json:"role"`
+	PreferredLanguage string    `json:"preferred_language"`
+}
+
+func (q *Queries) GetAdmins(ctx context.Context) ([]GetAdminsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getAdmins)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetAdminsRow{}
+	for rows.Next() {
+		var i GetAdminsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.Email,
+			&i.Activated,
+			&i.IsSuperUser,
+			&i.Role,
+			&i.PreferredLanguage,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getHashTokenForAdmin = `-- name: GetHashTokenForAdmin :one
-SELECT admin.id, admin.created_at,admin.email, admin.password_hash,admin.version, admin.activated
+SELECT admin.id, admin.created_at,admin.email, admin.password_hash,admin.version, admin.activated,
+admin.is_super_user, admin.role, admin.preferred_language, token.session_id,
+EXISTS (SELECT 1 FROM admin_totp WHERE admin_totp.admin_id = admin.id AND admin_totp.confirmed_at IS NOT NULL) AS totp_enabled,
+COALESCE((SELECT require_totp FROM security_policy), false)::boolean AS totp_required
 FROM admin
 INNER JOIN token
 ON admin.id = token.id
@@ -75,12 +183,18 @@ type GetHashTokenForAdminParams struct {
 }
 
 type GetHashTokenForAdminRow struct {
-	ID           uuid.UUID `json:"id"`
-	CreatedAt    time.Time `json:"created_at"`
-	Email        string    `json:"email"`
-	PasswordHash []byte    `json:"password_hash"`
-	Version      uuid.UUID `json:"version"`
-	Activated    bool      `json:"activated"`
+	ID                uuid.UUID     `json:"id"`
+	CreatedAt         time.Time     `json:"created_at"`
+	Email             string        `json:"email"`
+	PasswordHash      []byte        `json:"password_hash"`
+	Version           uuid.UUID     `json:"version"`
+	Activated         bool          `json:"activated"`
+	IsSuperUser       bool          `json:"is_super_user"`
+	Role              string        `json:"role"`
+	PreferredLanguage string        `json:"preferred_language"`
+	SessionID         uuid.NullUUID `json:"session_id"`
+	TotpEnabled       bool          `json:"totp_enabled"`
+	TotpRequired      bool          `json:"totp_required"`
 }
 
 func (q *Queries) GetHashTokenForAdmin(ctx context.Context, arg GetHashTokenForAdminParams) (GetHashTokenForAdminRow, error) {
@@ -93,10 +207,28 @@ func (q *Queries) GetHashTokenForAdmin(ctx context.Context, arg GetHashTokenForA
 		&i.PasswordHash,
 		&i.Version,
 		&i.Activated,
+		&i.IsSuperUser,
+		&i.Role,
+		&i.PreferredLanguage,
+		&i.SessionID,
+		&i.TotpEnabled,
+		&i.TotpRequired,
 	)
 	return i, err
 }
 
+const lockAdmin = `-- name: LockAdmin :exec
+SELECT 1 FROM admin
+WHERE id = $1
+FOR UPDATE
+`
+
+// LockAdmin holds the admin for the rest of the transaction.
+func (q *Queries) LockAdmin(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, lockAdmin, id)
+	return err
+}
+
 const updateAdmin = `-- name: UpdateAdmin :one
 UPDATE admin
 SET email = $1, password_hash = $2, activated = $3, version = uuid_generate_v4()
@@ -124,3 +256,43 @@ func (q *Queries) UpdateAdmin(ctx context.Context, arg UpdateAdminParams) (uuid.
 	err := row.Scan(&version)
 	return version, err
 }
+
+const updateAdminLanguage = `-- name: UpdateAdminLanguage :execrows
+UPDATE admin
+SET preferred_language = $1, version = uuid_generate_v4()
+WHERE id = $2 AND version = $3
+`
+
+type UpdateAdminLanguageParams struct {
+	PreferredLanguage string    `json:"preferred_language"`
+	ID                uuid.UUID `json:"id"`
+	Version           uuid.UUID `json:"version"`
+}
+
+func (q *Queries) UpdateAdminLanguage(ctx context.Context, arg UpdateAdminLanguageParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateAdminLanguage, arg.PreferredLanguage, arg.ID, arg.Version)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updateAdminRole = `-- name: UpdateAdminRole :execrows
+UPDATE admin
+SET role = $1, version = uuid_generate_v4()
+WHERE id = $2 AND version = $3
+`
+
+type UpdateAdminRoleParams struct {
+	Role    string    `json:"role"`
+	ID      uuid.UUID `json:"id"`
+	Version uuid.UUID `json:"version"`
+}
+
+func (q *Queries) UpdateAdminRole(ctx context.Context, arg UpdateAdminRoleParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateAdminRole, arg.Role, arg.ID, arg.Version)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
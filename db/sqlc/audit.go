@@ -0,0 +1,115 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// Snapshot reads an entity for the audit log inside the transaction that
+// changes it, locking it so the change is the only one between the two reads.
+// It returns nil when there is no such entity.
+type Snapshot func(ctx context.Context, q *Queries, id uuid.UUID) (any, error)
+
+// AuditEntry is an audit log entry written together with the change it
+// records, the way an OutboxMessage is queued, so that neither is kept without
+// the other. When Snapshot is set Before and After are read with it around the
+// change, otherwise they are written as given.
+type AuditEntry struct {
+	OrganizationID uuid.NullUUID
+	AdminID        uuid.NullUUID
+	AdminEmail     string
+	ApiKeyID       uuid.NullUUID
+	Ip             string
+	Action         string
+	EntityType     string
+	EntityID       uuid.UUID
+	Before         any
+	After          any
+	Snapshot       Snapshot
+}
+
+// audit makes change and records it. change returns the id of the entity it
+// creates, which becomes the entry's EntityID, or uuid.Nil.
+func audit(ctx context.Context, q *Queries, entry AuditEntry, change func() (uuid.UUID, error)) (uuid.UUID, error) {
+
+	var err error
+
+	if entry.Snapshot != nil && entry.EntityID != uuid.Nil {
+		if entry.Before, err = entry.Snapshot(ctx, q, entry.EntityID); err != nil {
+			return uuid.Nil, fmt.Errorf("reading %s for audit: %w", entry.EntityType, err)
+		}
+	}
+
+	id, err := change()
+
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	if id != uuid.Nil {
+		entry.EntityID = id
+	}
+
+	if entry.Snapshot != nil {
+		if entry.After, err = entry.Snapshot(ctx, q, entry.EntityID); err != nil {
+			return uuid.Nil, fmt.Errorf("reading %s for audit: %w", entry.EntityType, err)
+		}
+	}
+
+	before, err := json.Marshal(entry.Before)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("encoding audit snapshot: %w", err)
+	}
+
+	after, err := json.Marshal(entry.After)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("encoding audit snapshot: %w", err)
+	}
+
+	err = q.CreateAuditLog(ctx, CreateAuditLogParams{
+		AdminID:        entry.AdminID,
+		AdminEmail:     entry.AdminEmail,
+		ApiKeyID:       entry.ApiKeyID,
+		Action:         entry.Action,
+		EntityType:     entry.EntityType,
+		EntityID:       entry.EntityID,
+		Before:         before,
+		After:          after,
+		Ip:             entry.Ip,
+		OrganizationID: entry.OrganizationID,
+	})
+
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return entry.EntityID, nil
+}
+
+// TxnAudit makes a change that needs no transaction of its own and records
+// it in the audit log in one. It returns the id of the entity changed.
+func (store *SQLStore) TxnAudit(ctx context.Context, entry AuditEntry, change func(q *Queries) (uuid.UUID, error)) (uuid.UUID, error) {
+
+	tx, err := store.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	defer tx.Rollback()
+
+	qtx := New(tx)
+
+	id, err := audit(ctx, qtx, entry, func() (uuid.UUID, error) {
+		return change(qtx)
+	})
+
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return id, tx.Commit()
+}
@@ -0,0 +1,92 @@
+package db
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// EachTenant and EachPayment are written by hand alongside the generated
+// GetTenants and GetAllPayments, which they share their queries with: sqlc
+// only returns a whole slice, and an export of every row should not hold them
+// all in memory. Each calls fn with one row at a time as it is read, and stops
+// at the first error fn returns.
+
+func (s *SQLStore) EachTenant(ctx context.Context, organizationID uuid.UUID, fn func(GetTenantsRow) error) error {
+
+	rows, err := s.db.QueryContext(ctx, getTenants, organizationID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var i GetTenantsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Location,
+			&i.Block,
+			&i.Partition,
+			&i.Price,
+			&i.Phone,
+			&i.PersonalIDType,
+			&i.PersonalID,
+			&i.Active,
+			&i.Sos,
+			&i.Eos,
+			&i.PreferredLanguage,
+			&i.Email,
+		); err != nil {
+			return err
+		}
+		if err := fn(i); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Close(); err != nil {
+		return err
+	}
+
+	return rows.Err()
+}
+
+func (s *SQLStore) EachPayment(ctx context.Context, organizationID uuid.UUID, fn func(GetAllPaymentsRow) error) error {
+
+	rows, err := s.db.QueryContext(ctx, getAllPayments, organizationID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var i GetAllPaymentsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.TenantName,
+			&i.TenantID,
+			&i.Amount,
+			&i.StartDate,
+			&i.EndDate,
+			&i.AdminEmail,
+			&i.Location,
+			&i.Block,
+			&i.Partition,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Version,
+		); err != nil {
+			return err
+		}
+		if err := fn(i); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Close(); err != nil {
+		return err
+	}
+
+	return rows.Err()
+}
@@ -10,18 +10,22 @@ import (
 	"database/sql"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createHouse = `-- name: CreateHouse :one
-INSERT INTO house (location, block, partition, price, occupied) VALUES ($1,$2,$3,$4,$5) RETURNING id
+INSERT INTO house (location, block, partition, price, occupied, unit_type, photos, organization_id) VALUES ($1,$2,$3,$4,$5,$6,$7,$8) RETURNING id
 `
 
 type CreateHouseParams struct {
-	Location  string `json:"location"`
-	Block     string `json:"block"`
-	Partition int16  `json:"partition"`
-	Price     int32  `json:"price"`
-	Occupied  bool   `json:"occupied"`
+	Location       string    `json:"location"`
+	Block          string    `json:"block"`
+	Partition      int16     `json:"partition"`
+	Price          int32     `json:"price"`
+	Occupied       bool      `json:"occupied"`
+	UnitType       string    `json:"unit_type"`
+	Photos         []string  `json:"photos"`
+	OrganizationID uuid.UUID `json:"organization_id"`
 }
 
 func (q *Queries) CreateHouse(ctx context.Context, arg CreateHouseParams) (uuid.UUID, error) {
@@ -31,6 +35,9 @@ func (q *Queries) CreateHouse(ctx context.Context, arg CreateHouseParams) (uuid.
 		arg.Partition,
 		arg.Price,
 		arg.Occupied,
+		arg.UnitType,
+		pq.Array(arg.Photos),
+		arg.OrganizationID,
 	)
 	var id uuid.UUID
 	err := row.Scan(&id)
@@ -38,11 +45,16 @@ func (q *Queries) CreateHouse(ctx context.Context, arg CreateHouseParams) (uuid.
 }
 
 const deleteHouseById = `-- name: DeleteHouseById :exec
-DELETE FROM house WHERE id = $1
+DELETE FROM house WHERE id = $1 AND organization_id = $2
 `
 
-func (q *Queries) DeleteHouseById(ctx context.Context, id uuid.UUID) error {
-	_, err := q.db.ExecContext(ctx, deleteHouseById, id)
+type DeleteHouseByIdParams struct {
+	ID             uuid.UUID `json:"id"`
+	OrganizationID uuid.UUID `json:"organization_id"`
+}
+
+func (q *Queries) DeleteHouseById(ctx context.Context, arg DeleteHouseByIdParams) error {
+	_, err := q.db.ExecContext(ctx, deleteHouseById, arg.ID, arg.OrganizationID)
 	return err
 }
 
@@ -56,12 +68,20 @@ SELECT
   h.Occupied, 
   t.name, 
   t.id AS tenant_id,
-  h.version
+  h.version,
+  h.archived,
+  h.unit_type,
+  h.photos
 FROM house h
-LEFT JOIN tenant t ON h.id = t.house_id
-WHERE h.id = $1
+LEFT JOIN tenant t ON h.id = t.house_id AND t.active
+WHERE h.id = $1 AND h.organization_id = $2
 `
 
+type GetHouseByIdParams struct {
+	ID             uuid.UUID `json:"id"`
+	OrganizationID uuid.UUID `json:"organization_id"`
+}
+
 type GetHouseByIdRow struct {
 	HouseID   uuid.UUID      `json:"house_id"`
 	Location  string         `json:"location"`
@@ -72,10 +92,13 @@ type GetHouseByIdRow struct {
 	Name      sql.NullString `json:"name"`
 	TenantID  uuid.NullUUID  `json:"tenant_id"`
 	Version   uuid.UUID      `json:"version"`
+	Archived  bool           `json:"archived"`
+	UnitType  string         `json:"unit_type"`
+	Photos    []string       `json:"photos"`
 }
 
-func (q *Queries) GetHouseById(ctx context.Context, id uuid.UUID) (GetHouseByIdRow, error) {
-	row := q.db.QueryRowContext(ctx, getHouseById, id)
+func (q *Queries) GetHouseById(ctx context.Context, arg GetHouseByIdParams) (GetHouseByIdRow, error) {
+	row := q.db.QueryRowContext(ctx, getHouseById, arg.ID, arg.OrganizationID)
 	var i GetHouseByIdRow
 	err := row.Scan(
 		&i.HouseID,
@@ -87,12 +110,112 @@ func (q *Queries) GetHouseById(ctx context.Context, id uuid.UUID) (GetHouseByIdR
 		&i.Name,
 		&i.TenantID,
 		&i.Version,
+		&i.Archived,
+		&i.UnitType,
+		pq.Array(&i.Photos),
 	)
 	return i, err
 }
 
+const getHouseHistoryCount = `-- name: GetHouseHistoryCount :one
+SELECT
+  (SELECT COUNT(*) FROM tenant t WHERE t.house_id = $1 AND t.organization_id = $2) AS tenants,
+  (SELECT COUNT(*) FROM payment p JOIN tenant t ON p.tenant_id = t.id WHERE t.house_id = $1 AND p.organization_id = $2) AS payments
+`
+
+type GetHouseHistoryCountParams struct {
+	HouseID        uuid.UUID `json:"house_id"`
+	OrganizationID uuid.UUID `json:"organization_id"`
+}
+
+type GetHouseHistoryCountRow struct {
+	Tenants  int64 `json:"tenants"`
+	Payments int64 `json:"payments"`
+}
+
+func (q *Queries) GetHouseHistoryCount(ctx context.Context, arg GetHouseHistoryCountParams) (GetHouseHistoryCountRow, error) {
+	row := q.db.QueryRowContext(ctx, getHouseHistoryCount, arg.HouseID, arg.OrganizationID)
+	var i GetHouseHistoryCountRow
+	err := row.Scan(&i.Tenants, &i.Payments)
+	return i, err
+}
+
+const getHouseIdByUnit = `-- name: GetHouseIdByUnit :one
+SELECT id FROM house
+WHERE organization_id = $1 AND location = $2 AND block = $3 AND partition = $4
+FOR UPDATE
+`
+
+type GetHouseIdByUnitParams struct {
+	OrganizationID uuid.UUID `json:"organization_id"`
+	Location       string    `json:"location"`
+	Block          string    `json:"block"`
+	Partition      int16     `json:"partition"`
+}
+
+func (q *Queries) GetHouseIdByUnit(ctx context.Context, arg GetHouseIdByUnitParams) (uuid.UUID, error) {
+	row := q.db.QueryRowContext(ctx, getHouseIdByUnit,
+		arg.OrganizationID,
+		arg.Location,
+		arg.Block,
+		arg.Partition,
+	)
+	var id uuid.UUID
+	err := row.Scan(&id)
+	return id, err
+}
+
+const getHouseUnits = `-- name: GetHouseUnits :many
+SELECT id, location, block, partition, archived,
+EXISTS (SELECT 1 FROM tenant WHERE tenant.house_id = house.id AND tenant.active)::bool AS tenanted
+FROM house
+WHERE organization_id = $1
+`
+
+type GetHouseUnitsRow struct {
+	ID        uuid.UUID `json:"id"`
+	Location  string    `json:"location"`
+	Block     string    `json:"block"`
+	Partition int16     `json:"partition"`
+	Archived  bool      `json:"archived"`
+	Tenanted  bool      `json:"tenanted"`
+}
+
+// GetHouseUnits lists every house with what an import may not change on it:
+// whether it is archived and whether an active tenant lives in it.
+func (q *Queries) GetHouseUnits(ctx context.Context, organizationID uuid.UUID) ([]GetHouseUnitsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getHouseUnits, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetHouseUnitsRow{}
+	for rows.Next() {
+		var i GetHouseUnitsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Location,
+			&i.Block,
+			&i.Partition,
+			&i.Archived,
+			&i.Tenanted,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getHouses = `-- name: GetHouses :many
 SELECT id,location, block, partition, price , occupied FROM house
+WHERE archived = false AND organization_id = $1
 `
 
 type GetHousesRow struct {
@@ -104,8 +227,8 @@ type GetHousesRow struct {
 	Occupied  bool      `json:"occupied"`
 }
 
-func (q *Queries) GetHouses(ctx context.Context) ([]GetHousesRow, error) {
-	rows, err := q.db.QueryContext(ctx, getHouses)
+func (q *Queries) GetHouses(ctx context.Context, organizationID uuid.UUID) ([]GetHousesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getHouses, organizationID)
 	if err != nil {
 		return nil, err
 	}
@@ -134,32 +257,213 @@ func (q *Queries) GetHouses(ctx context.Context) ([]GetHousesRow, error) {
 	return items, nil
 }
 
-const updateHouseById = `-- name: UpdateHouseById :exec
+const getHousesByIds = `-- name: GetHousesByIds :many
+SELECT id, location, block, partition, occupied, price, version, archived, unit_type, photos, organization_id, vacated_at FROM house
+WHERE id = ANY($1::uuid[]) AND organization_id = $2
+`
+
+type GetHousesByIdsParams struct {
+	Ids            []uuid.UUID `json:"ids"`
+	OrganizationID uuid.UUID   `json:"organization_id"`
+}
+
+func (q *Queries) GetHousesByIds(ctx context.Context, arg GetHousesByIdsParams) ([]House, error) {
+	rows, err := q.db.QueryContext(ctx, getHousesByIds, pq.Array(arg.Ids), arg.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []House{}
+	for rows.Next() {
+		var i House
+		if err := rows.Scan(
+			&i.ID,
+			&i.Location,
+			&i.Block,
+			&i.Partition,
+			&i.Occupied,
+			&i.Price,
+			&i.Version,
+			&i.Archived,
+			&i.UnitType,
+			pq.Array(&i.Photos),
+			&i.OrganizationID,
+			&i.VacatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockHouse = `-- name: LockHouse :exec
+SELECT 1 FROM house
+WHERE id = $1 AND organization_id = $2
+FOR UPDATE
+`
+
+type LockHouseParams struct {
+	ID             uuid.UUID `json:"id"`
+	OrganizationID uuid.UUID `json:"organization_id"`
+}
+
+// LockHouse holds the house for the rest of the transaction.
+func (q *Queries) LockHouse(ctx context.Context, arg LockHouseParams) error {
+	_, err := q.db.ExecContext(ctx, lockHouse, arg.ID, arg.OrganizationID)
+	return err
+}
+
+const occupyHouse = `-- name: OccupyHouse :execrows
+UPDATE house
+SET occupied = true, vacated_at = NULL, version = uuid_generate_v4()
+WHERE id = $1 AND organization_id = $2 AND NOT occupied AND NOT archived
+`
+
+type OccupyHouseParams struct {
+	ID             uuid.UUID `json:"id"`
+	OrganizationID uuid.UUID `json:"organization_id"`
+}
+
+// OccupyHouse lets a house that is vacant and not archived. It changes no row
+// otherwise, and the row lock it takes keeps two tenants from moving in at
+// once.
+func (q *Queries) OccupyHouse(ctx context.Context, arg OccupyHouseParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, occupyHouse, arg.ID, arg.OrganizationID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setHouseArchived = `-- name: SetHouseArchived :execrows
+UPDATE house
+SET archived = $1, version = uuid_generate_v4()
+WHERE id = $2 AND version = $3 AND organization_id = $4
+`
+
+type SetHouseArchivedParams struct {
+	Archived       bool      `json:"archived"`
+	ID             uuid.UUID `json:"id"`
+	Version        uuid.UUID `json:"version"`
+	OrganizationID uuid.UUID `json:"organization_id"`
+}
+
+func (q *Queries) SetHouseArchived(ctx context.Context, arg SetHouseArchivedParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setHouseArchived,
+		arg.Archived,
+		arg.ID,
+		arg.Version,
+		arg.OrganizationID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setHouseOccupied = `-- name: SetHouseOccupied :exec
+UPDATE house
+SET occupied = $1, vacated_at = CASE WHEN $1 THEN NULL WHEN occupied THEN NOW() ELSE vacated_at END,
+version = uuid_generate_v4()
+WHERE id = $2 AND organization_id = $3
+`
+
+type SetHouseOccupiedParams struct {
+	Occupied       bool      `json:"occupied"`
+	ID             uuid.UUID `json:"id"`
+	OrganizationID uuid.UUID `json:"organization_id"`
+}
+
+func (q *Queries) SetHouseOccupied(ctx context.Context, arg SetHouseOccupiedParams) error {
+	_, err := q.db.ExecContext(ctx, setHouseOccupied, arg.Occupied, arg.ID, arg.OrganizationID)
+	return err
+}
+
+const updateHouseById = `-- name: UpdateHouseById :execrows
 UPDATE house
 SET location = $1, block = $2, partition = $3, occupied = $4, price = $5, 
+unit_type = $6, photos = $7, vacated_at = CASE WHEN $4 THEN NULL WHEN occupied THEN NOW() ELSE vacated_at END,
 version = uuid_generate_v4()
-WHERE id = $6 AND version = $7
+WHERE id = $8 AND version = $9 AND organization_id = $10
 `
 
 type UpdateHouseByIdParams struct {
-	Location  string    `json:"location"`
-	Block     string    `json:"block"`
-	Partition int16     `json:"partition"`
-	Occupied  bool      `json:"occupied"`
-	Price     int32     `json:"price"`
-	ID        uuid.UUID `json:"id"`
-	Version   uuid.UUID `json:"version"`
+	Location       string    `json:"location"`
+	Block          string    `json:"block"`
+	Partition      int16     `json:"partition"`
+	Occupied       bool      `json:"occupied"`
+	Price          int32     `json:"price"`
+	UnitType       string    `json:"unit_type"`
+	Photos         []string  `json:"photos"`
+	ID             uuid.UUID `json:"id"`
+	Version        uuid.UUID `json:"version"`
+	OrganizationID uuid.UUID `json:"organization_id"`
 }
 
-func (q *Queries) UpdateHouseById(ctx context.Context, arg UpdateHouseByIdParams) error {
-	_, err := q.db.ExecContext(ctx, updateHouseById,
+func (q *Queries) UpdateHouseById(ctx context.Context, arg UpdateHouseByIdParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateHouseById,
 		arg.Location,
 		arg.Block,
 		arg.Partition,
 		arg.Occupied,
 		arg.Price,
+		arg.UnitType,
+		pq.Array(arg.Photos),
 		arg.ID,
 		arg.Version,
+		arg.OrganizationID,
 	)
-	return err
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const upsertHouse = `-- name: UpsertHouse :one
+INSERT INTO house (location, block, partition, price, occupied, unit_type, organization_id)
+VALUES ($1, $2, $3, $4, COALESCE($5::bool, false), $6, $7)
+ON CONFLICT (organization_id, location, block, partition)
+DO UPDATE SET price = EXCLUDED.price,
+occupied = COALESCE($5::bool, house.occupied),
+vacated_at = CASE WHEN $5::bool THEN NULL WHEN NOT $5::bool AND house.occupied THEN NOW() ELSE house.vacated_at END,
+unit_type = COALESCE(NULLIF(EXCLUDED.unit_type, ''), house.unit_type),
+version = uuid_generate_v4()
+WHERE NOT house.archived
+AND ($5::bool IS NOT FALSE OR NOT EXISTS (SELECT 1 FROM tenant WHERE tenant.house_id = house.id AND tenant.active))
+RETURNING id
+`
+
+type UpsertHouseParams struct {
+	Location       string       `json:"location"`
+	Block          string       `json:"block"`
+	Partition      int16        `json:"partition"`
+	Price          int32        `json:"price"`
+	Occupied       sql.NullBool `json:"occupied"`
+	UnitType       string       `json:"unit_type"`
+	OrganizationID uuid.UUID    `json:"organization_id"`
+}
+
+// A null occupied creates a vacant house and leaves an existing one as it is.
+// An archived house, or one an active tenant lives in that would be vacated,
+// is left alone and no row is returned.
+func (q *Queries) UpsertHouse(ctx context.Context, arg UpsertHouseParams) (uuid.UUID, error) {
+	row := q.db.QueryRowContext(ctx, upsertHouse,
+		arg.Location,
+		arg.Block,
+		arg.Partition,
+		arg.Price,
+		arg.Occupied,
+		arg.UnitType,
+		arg.OrganizationID,
+	)
+	var id uuid.UUID
+	err := row.Scan(&id)
+	return id, err
 }
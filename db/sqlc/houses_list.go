@@ -0,0 +1,367 @@
+package db
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// ListHousesParams filters the houses list. OrganizationID is required unless
+// PublicOnly is set, in which case vacancies of every organization are listed.
+type ListHousesParams struct {
+	OrganizationID uuid.NullUUID
+	Location       string
+	Block          string
+	UnitType       string
+	Partition      *int16
+	Occupied       *bool
+	MinPrice       *int32
+	MaxPrice       *int32
+	Archived       bool
+	PublicOnly     bool
+	WithTenant     bool
+	Sort           string
+	Desc           bool
+	After          *HouseCursor
+	Offset         int
+	Limit          int
+}
+
+type ListHousesRow struct {
+	ID         uuid.UUID  `json:"id"`
+	Location   string     `json:"location"`
+	Block      string     `json:"block"`
+	Partition  int16      `json:"partition"`
+	Price      int32      `json:"price"`
+	Occupied   bool       `json:"occupied"`
+	Archived   bool       `json:"archived"`
+	UnitType   string     `json:"unit_type"`
+	Photos     []string   `json:"photos"`
+	TenantName *string    `json:"name,omitempty"`
+	TenantID   *uuid.UUID `json:"tenant_id,omitempty"`
+}
+
+// HouseCursor marks the last row of a page. Values holds the sort key of that
+// row, one entry per column of the sort, and Desc the direction it was sorted
+// in, which the next page has to keep.
+type HouseCursor struct {
+	Sort   string    `json:"s"`
+	Desc   bool      `json:"d,omitempty"`
+	Values []string  `json:"v"`
+	ID     uuid.UUID `json:"id"`
+}
+
+// VacancyCursor marks where a page of public vacancies ends. Unlike a
+// HouseCursor it holds no ids, only how many vacancies came before, so that
+// nothing internal is given away to the public.
+type VacancyCursor struct {
+	Sort   string `json:"s"`
+	Desc   bool   `json:"d,omitempty"`
+	Offset int    `json:"o"`
+}
+
+type houseColumn struct {
+	name string
+	cast string
+	get  func(r ListHousesRow) string
+}
+
+var (
+	colLocation  = houseColumn{"h.location", "citext", func(r ListHousesRow) string { return r.Location }}
+	colBlock     = houseColumn{"h.block", "citext", func(r ListHousesRow) string { return r.Block }}
+	colPartition = houseColumn{"h.partition", "smallint", func(r ListHousesRow) string { return strconv.Itoa(int(r.Partition)) }}
+	colPrice     = houseColumn{"h.price", "int", func(r ListHousesRow) string { return strconv.Itoa(int(r.Price)) }}
+)
+
+var houseSorts = map[string][]houseColumn{
+	"location":  {colLocation, colBlock, colPartition},
+	"block":     {colBlock, colPartition, colLocation},
+	"partition": {colPartition, colLocation, colBlock},
+	"price":     {colPrice},
+}
+
+const DefaultHouseSort = "location"
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+func ValidHouseSort(sort string) bool {
+	_, ok := houseSorts[sort]
+	return ok
+}
+
+func EncodeHouseCursor(sort string, desc bool, row ListHousesRow) string {
+
+	cur := HouseCursor{Sort: sort, Desc: desc, ID: row.ID}
+
+	for _, col := range houseSorts[sort] {
+		cur.Values = append(cur.Values, col.get(row))
+	}
+
+	b, _ := json.Marshal(cur)
+
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func DecodeHouseCursor(s string) (*HouseCursor, error) {
+
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cur HouseCursor
+
+	if err := json.Unmarshal(b, &cur); err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	cols, ok := houseSorts[cur.Sort]
+	if !ok || len(cols) != len(cur.Values) {
+		return nil, ErrInvalidCursor
+	}
+
+	// the values are cast in the query, so one that does not fit its column
+	// would fail there rather than as a bad cursor
+	for i, col := range cols {
+		var err error
+		switch col.cast {
+		case "citext":
+			if strings.ContainsRune(cur.Values[i], 0) {
+				err = ErrInvalidCursor
+			}
+		case "smallint":
+			_, err = strconv.ParseInt(cur.Values[i], 10, 16)
+		case "int":
+			_, err = strconv.ParseInt(cur.Values[i], 10, 32)
+		}
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+	}
+
+	return &cur, nil
+}
+
+func EncodeVacancyCursor(sort string, desc bool, offset int) string {
+
+	b, _ := json.Marshal(VacancyCursor{Sort: sort, Desc: desc, Offset: offset})
+
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func DecodeVacancyCursor(s string) (*VacancyCursor, error) {
+
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cur VacancyCursor
+
+	if err := json.Unmarshal(b, &cur); err != nil || !ValidHouseSort(cur.Sort) || cur.Offset < 0 {
+		return nil, ErrInvalidCursor
+	}
+
+	return &cur, nil
+}
+
+// ListHouses is written by hand rather than generated: the mix of optional
+// filters, caller chosen sort order and keyset cursor does not fit a static
+// sqlc query.
+func (s *SQLStore) ListHouses(ctx context.Context, arg ListHousesParams) ([]ListHousesRow, error) {
+
+	items := []ListHousesRow{}
+
+	err := s.EachHouse(ctx, arg, func(i ListHousesRow) error {
+		items = append(items, i)
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// EachHouse calls fn with each house ListHouses would list, one row at a time
+// as they are read, and stops at the first error fn returns. Unlike
+// ListHouses, a zero Limit lists every house, so that an export need not
+// hold them all in memory.
+func (s *SQLStore) EachHouse(ctx context.Context, arg ListHousesParams, fn func(ListHousesRow) error) error {
+
+	query, args, err := listHousesQuery(arg)
+	if err != nil {
+		return err
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			i    ListHousesRow
+			name *string
+			id   uuid.NullUUID
+		)
+		if err := rows.Scan(
+			&i.ID,
+			&i.Location,
+			&i.Block,
+			&i.Partition,
+			&i.Price,
+			&i.Occupied,
+			&i.Archived,
+			&i.UnitType,
+			pq.Array(&i.Photos),
+			&name,
+			&id,
+		); err != nil {
+			return err
+		}
+		if id.Valid {
+			i.TenantName = name
+			i.TenantID = &id.UUID
+		}
+		if err := fn(i); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Close(); err != nil {
+		return err
+	}
+
+	return rows.Err()
+}
+
+func listHousesQuery(arg ListHousesParams) (string, []interface{}, error) {
+
+	sort := arg.Sort
+	if sort == "" {
+		sort = DefaultHouseSort
+	}
+
+	cols, ok := houseSorts[sort]
+	if !ok {
+		return "", nil, fmt.Errorf("ListHouses: unknown sort %q", sort)
+	}
+
+	if arg.After != nil && (arg.After.Sort != sort || arg.After.Desc != arg.Desc) {
+		return "", nil, ErrInvalidCursor
+	}
+
+	var (
+		where []string
+		args  []interface{}
+	)
+
+	param := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	switch {
+	case arg.OrganizationID.Valid:
+		where = append(where, "h.organization_id = "+param(arg.OrganizationID.UUID))
+	case !arg.PublicOnly:
+		return "", nil, errors.New("ListHouses: organization is required")
+	}
+
+	where = append(where, "h.archived = "+param(arg.Archived))
+
+	if arg.Location != "" {
+		where = append(where, "h.location = "+param(arg.Location))
+	}
+
+	if arg.Block != "" {
+		where = append(where, "h.block = "+param(arg.Block))
+	}
+
+	if arg.UnitType != "" {
+		where = append(where, "h.unit_type = "+param(arg.UnitType))
+	}
+
+	if arg.Partition != nil {
+		where = append(where, "h.partition = "+param(*arg.Partition))
+	}
+
+	if arg.Occupied != nil {
+		where = append(where, "h.occupied = "+param(*arg.Occupied))
+	}
+
+	if arg.MinPrice != nil {
+		where = append(where, "h.price >= "+param(*arg.MinPrice))
+	}
+
+	if arg.MaxPrice != nil {
+		where = append(where, "h.price <= "+param(*arg.MaxPrice))
+	}
+
+	if arg.PublicOnly {
+		where = append(where, "NOT EXISTS (SELECT 1 FROM listing_opt_out o WHERE o.organization_id = h.organization_id AND o.location = h.location)")
+	}
+
+	dir, cmp := "ASC", ">"
+	if arg.Desc {
+		dir, cmp = "DESC", "<"
+	}
+
+	order := make([]string, 0, len(cols)+1)
+	keys := make([]string, 0, len(cols)+1)
+	values := make([]string, 0, len(cols)+1)
+
+	for i, col := range cols {
+		order = append(order, col.name+" "+dir)
+		keys = append(keys, col.name)
+		if arg.After != nil {
+			values = append(values, param(arg.After.Values[i])+"::"+col.cast)
+		}
+	}
+
+	order = append(order, "h.id "+dir)
+	keys = append(keys, "h.id")
+
+	if arg.After != nil {
+		values = append(values, param(arg.After.ID))
+		where = append(where, fmt.Sprintf("(%s) %s (%s)", strings.Join(keys, ", "), cmp, strings.Join(values, ", ")))
+	}
+
+	tenantCols := "NULL::text, NULL::uuid"
+	tenantJoin := ""
+
+	if arg.WithTenant {
+		tenantCols = "t.name, t.id"
+		tenantJoin = `
+LEFT JOIN LATERAL (
+  SELECT name, id FROM tenant WHERE house_id = h.id AND organization_id = h.organization_id AND active LIMIT 1
+) t ON true`
+	}
+
+	limit := ""
+
+	if arg.Limit > 0 {
+		limit = "\nLIMIT " + param(arg.Limit)
+	}
+
+	if arg.Offset > 0 {
+		limit += "\nOFFSET " + param(arg.Offset)
+	}
+
+	query := fmt.Sprintf(`SELECT h.id, h.location, h.block, h.partition, h.price, h.occupied, h.archived, h.unit_type, h.photos, %s
+FROM house h%s
+WHERE %s
+ORDER BY %s%s`, tenantCols, tenantJoin, strings.Join(where, " AND "), strings.Join(order, ", "), limit)
+
+	return query, args, nil
+}
@@ -0,0 +1,42 @@
+package db
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestHouseCursorKeepsDirection(t *testing.T) {
+
+	row := ListHousesRow{ID: uuid.New(), Location: "Sinza", Block: "A", Partition: 2, Price: 150000}
+
+	for _, desc := range []bool{false, true} {
+
+		cur, err := DecodeHouseCursor(EncodeHouseCursor("price", desc, row))
+		if err != nil {
+			t.Fatalf("DecodeHouseCursor: %v", err)
+		}
+
+		if cur.Sort != "price" || cur.Desc != desc || cur.ID != row.ID || len(cur.Values) != 1 || cur.Values[0] != "150000" {
+			t.Errorf("cursor = %+v, want price sorted desc=%t after %v", cur, desc, row.ID)
+		}
+
+		arg := ListHousesParams{
+			OrganizationID: uuid.NullUUID{UUID: uuid.New(), Valid: true},
+			Sort:           "price",
+			Desc:           desc,
+			After:          cur,
+		}
+
+		if _, _, err := listHousesQuery(arg); err != nil {
+			t.Errorf("listHousesQuery with desc=%t cursor: %v", desc, err)
+		}
+
+		arg.Desc = !desc
+
+		if _, _, err := listHousesQuery(arg); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("listHousesQuery with the direction changed: error = %v, want ErrInvalidCursor", err)
+		}
+	}
+}
@@ -5,60 +5,307 @@
 package db
 
 import (
+	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
 )
 
 type Admin struct {
-	ID           uuid.UUID `json:"id"`
-	CreatedAt    time.Time `json:"created_at"`
-	Email        string    `json:"email"`
-	PasswordHash []byte    `json:"password_hash"`
-	Activated    bool      `json:"activated"`
-	IsSuperUser  bool      `json:"is_super_user"`
-	Version      uuid.UUID `json:"version"`
+	ID                uuid.UUID `json:"id"`
+	CreatedAt         time.Time `json:"created_at"`
+	Email             string    `json:"email"`
+	PasswordHash      []byte    `json:"password_hash"`
+	Activated         bool      `json:"activated"`
+	IsSuperUser       bool      `json:"is_super_user"`
+	Version           uuid.UUID `json:"version"`
+	Role              string    `json:"role"`
+	PreferredLanguage string    `json:"preferred_language"`
+}
+
+type AdminDigest struct {
+	AdminID    uuid.UUID    `json:"admin_id"`
+	Enabled    bool         `json:"enabled"`
+	SendHour   int16        `json:"send_hour"`
+	EosDays    int16        `json:"eos_days"`
+	LastSentOn sql.NullTime `json:"last_sent_on"`
+	UpdatedAt  time.Time    `json:"updated_at"`
+}
+
+type AdminInvite struct {
+	ID             uuid.UUID    `json:"id"`
+	Email          string       `json:"email"`
+	Role           string       `json:"role"`
+	Hash           []byte       `json:"hash"`
+	InvitedBy      uuid.UUID    `json:"invited_by"`
+	CreatedAt      time.Time    `json:"created_at"`
+	Expiry         time.Time    `json:"expiry"`
+	AcceptedAt     sql.NullTime `json:"accepted_at"`
+	RevokedAt      sql.NullTime `json:"revoked_at"`
+	OrganizationID uuid.UUID    `json:"organization_id"`
+}
+
+type AdminOrganization struct {
+	AdminID        uuid.UUID `json:"admin_id"`
+	OrganizationID uuid.UUID `json:"organization_id"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type AdminRecoveryCode struct {
+	ID      uuid.UUID    `json:"id"`
+	AdminID uuid.UUID    `json:"admin_id"`
+	Hash    []byte       `json:"hash"`
+	UsedAt  sql.NullTime `json:"used_at"`
+}
+
+type AdminTotp struct {
+	AdminID     uuid.UUID    `json:"admin_id"`
+	Secret      string       `json:"secret"`
+	ConfirmedAt sql.NullTime `json:"confirmed_at"`
+	LastStep    int64        `json:"last_step"`
+	CreatedAt   time.Time    `json:"created_at"`
+}
+
+type ApiKey struct {
+	ID         uuid.UUID    `json:"id"`
+	AdminID    uuid.UUID    `json:"admin_id"`
+	Name       string       `json:"name"`
+	Prefix     string       `json:"prefix"`
+	Hash       []byte       `json:"hash"`
+	Scopes     []string     `json:"scopes"`
+	AllowedIps []string     `json:"allowed_ips"`
+	ExpiresAt  sql.NullTime `json:"expires_at"`
+	CreatedAt  time.Time    `json:"created_at"`
+	LastUsedAt sql.NullTime `json:"last_used_at"`
+	RevokedAt  sql.NullTime `json:"revoked_at"`
+}
+
+type ApiKeyUsage struct {
+	ID        int64     `json:"id"`
+	ApiKeyID  uuid.UUID `json:"api_key_id"`
+	Method    string    `json:"method"`
+	Path      string    `json:"path"`
+	Ip        string    `json:"ip"`
+	Status    int32     `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type AuditLog struct {
+	ID             int64           `json:"id"`
+	AdminID        uuid.NullUUID   `json:"admin_id"`
+	AdminEmail     string          `json:"admin_email"`
+	ApiKeyID       uuid.NullUUID   `json:"api_key_id"`
+	Action         string          `json:"action"`
+	EntityType     string          `json:"entity_type"`
+	EntityID       uuid.UUID       `json:"entity_id"`
+	Before         json.RawMessage `json:"before"`
+	After          json.RawMessage `json:"after"`
+	Ip             string          `json:"ip"`
+	CreatedAt      time.Time       `json:"created_at"`
+	OrganizationID uuid.NullUUID   `json:"organization_id"`
 }
 
 type House struct {
-	ID        uuid.UUID `json:"id"`
-	Location  string    `json:"location"`
-	Block     string    `json:"block"`
-	Partition int16     `json:"partition"`
-	Occupied  bool      `json:"occupied"`
-	Price     int32     `json:"price"`
-	Version   uuid.UUID `json:"version"`
+	ID             uuid.UUID    `json:"id"`
+	Location       string       `json:"location"`
+	Block          string       `json:"block"`
+	Partition      int16        `json:"partition"`
+	Occupied       bool         `json:"occupied"`
+	Price          int32        `json:"price"`
+	Version        uuid.UUID    `json:"version"`
+	Archived       bool         `json:"archived"`
+	UnitType       string       `json:"unit_type"`
+	Photos         []string     `json:"photos"`
+	OrganizationID uuid.UUID    `json:"organization_id"`
+	VacatedAt      sql.NullTime `json:"vacated_at"`
 }
 
-type Payment struct {
+type Inspection struct {
+	ID             uuid.UUID     `json:"id"`
+	HouseID        uuid.UUID     `json:"house_id"`
+	TenantID       uuid.NullUUID `json:"tenant_id"`
+	Type           string        `json:"type"`
+	Notes          string        `json:"notes"`
+	InspectedOn    time.Time     `json:"inspected_on"`
+	CreatedBy      uuid.UUID     `json:"created_by"`
+	CreatedAt      time.Time     `json:"created_at"`
+	SignedBy       uuid.NullUUID `json:"signed_by"`
+	Signature      string        `json:"signature"`
+	SignedAt       sql.NullTime  `json:"signed_at"`
+	Version        uuid.UUID     `json:"version"`
+	OrganizationID uuid.UUID     `json:"organization_id"`
+}
+
+type InspectionChecklistItem struct {
+	ID             uuid.UUID `json:"id"`
+	Room           string    `json:"room"`
+	Item           string    `json:"item"`
+	Position       int32     `json:"position"`
+	OrganizationID uuid.UUID `json:"organization_id"`
+}
+
+type InspectionItem struct {
+	ID           uuid.UUID `json:"id"`
+	InspectionID uuid.UUID `json:"inspection_id"`
+	Room         string    `json:"room"`
+	Item         string    `json:"item"`
+	Condition    int16     `json:"condition"`
+	Notes        string    `json:"notes"`
+}
+
+type InspectionPhoto struct {
+	ID           uuid.UUID     `json:"id"`
+	InspectionID uuid.UUID     `json:"inspection_id"`
+	ItemID       uuid.NullUUID `json:"item_id"`
+	Url          string        `json:"url"`
+}
+
+type ListingOptOut struct {
+	Location       string    `json:"location"`
+	CreatedAt      time.Time `json:"created_at"`
+	CreatedBy      uuid.UUID `json:"created_by"`
+	OrganizationID uuid.UUID `json:"organization_id"`
+}
+
+type NotificationConsent struct {
+	ID             int64         `json:"id"`
+	TenantID       uuid.UUID     `json:"tenant_id"`
+	OrganizationID uuid.UUID     `json:"organization_id"`
+	Source         string        `json:"source"`
+	AdminID        uuid.NullUUID `json:"admin_id"`
+	Actor          string        `json:"actor"`
+	Detail         string        `json:"detail"`
+	Channels       []string      `json:"channels"`
+	QuietStart     sql.NullInt16 `json:"quiet_start"`
+	QuietEnd       sql.NullInt16 `json:"quiet_end"`
+	Marketing      bool          `json:"marketing"`
+	OptedOut       bool          `json:"opted_out"`
+	CreatedAt      time.Time     `json:"created_at"`
+}
+
+type NotificationPreference struct {
+	TenantID       uuid.UUID     `json:"tenant_id"`
+	OrganizationID uuid.UUID     `json:"organization_id"`
+	Channels       []string      `json:"channels"`
+	QuietStart     sql.NullInt16 `json:"quiet_start"`
+	QuietEnd       sql.NullInt16 `json:"quiet_end"`
+	Marketing      bool          `json:"marketing"`
+	OptedOutAt     sql.NullTime  `json:"opted_out_at"`
+	UpdatedAt      time.Time     `json:"updated_at"`
+}
+
+type OccupancySnapshot struct {
+	HouseID        uuid.UUID `json:"house_id"`
+	Day            time.Time `json:"day"`
+	OrganizationID uuid.UUID `json:"organization_id"`
+	Occupied       bool      `json:"occupied"`
+}
+
+type Organization struct {
 	ID        uuid.UUID `json:"id"`
-	TenantID  uuid.UUID `json:"tenant_id"`
-	Amount    int32     `json:"amount"`
-	StartDate time.Time `json:"start_date"`
-	EndDate   time.Time `json:"end_date"`
-	Version   uuid.UUID `json:"version"`
+	Name      string    `json:"name"`
 	CreatedAt time.Time `json:"created_at"`
-	CreatedBy uuid.UUID `json:"created_by"`
-	UpdatedAt time.Time `json:"updated_at"`
+	Version   uuid.UUID `json:"version"`
 }
 
-type Tenant struct {
+type LoginAttempt struct {
+	ID        uuid.UUID     `json:"id"`
+	Email     string        `json:"email"`
+	Ip        string        `json:"ip"`
+	UserAgent string        `json:"user_agent"`
+	Success   bool          `json:"success"`
+	AdminID   uuid.NullUUID `json:"admin_id"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+type Outbox struct {
+	ID            int64           `json:"id"`
+	Kind          string          `json:"kind"`
+	Recipient     string          `json:"recipient"`
+	Payload       json.RawMessage `json:"payload"`
+	Status        string          `json:"status"`
+	Attempts      int32           `json:"attempts"`
+	NextAttemptAt time.Time       `json:"next_attempt_at"`
+	LastError     string          `json:"last_error"`
+	CreatedAt     time.Time       `json:"created_at"`
+	SentAt        sql.NullTime    `json:"sent_at"`
+}
+
+type PasswordHistory struct {
+	ID           int64     `json:"id"`
+	AdminID      uuid.UUID `json:"admin_id"`
+	PasswordHash []byte    `json:"password_hash"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type Payment struct {
 	ID             uuid.UUID `json:"id"`
-	Name           string    `json:"name"`
-	Phone          string    `json:"phone"`
-	HouseID        uuid.UUID `json:"house_id"`
-	PersonalIDType string    `json:"personal_id_type"`
-	PersonalID     string    `json:"personal_id"`
-	Photo          string    `json:"photo"`
-	Active         bool      `json:"active"`
-	Sos            time.Time `json:"sos"`
-	Eos            time.Time `json:"eos"`
+	TenantID       uuid.UUID `json:"tenant_id"`
+	Amount         int32     `json:"amount"`
+	StartDate      time.Time `json:"start_date"`
+	EndDate        time.Time `json:"end_date"`
 	Version        uuid.UUID `json:"version"`
+	CreatedAt      time.Time `json:"created_at"`
+	CreatedBy      uuid.UUID `json:"created_by"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	OrganizationID uuid.UUID `json:"organization_id"`
+}
+
+type Reminder struct {
+	ID             uuid.UUID    `json:"id"`
+	OrganizationID uuid.UUID    `json:"organization_id"`
+	TenantID       uuid.UUID    `json:"tenant_id"`
+	Kind           string       `json:"kind"`
+	DueDate        time.Time    `json:"due_date"`
+	DaysBefore     int32        `json:"days_before"`
+	Phone          string       `json:"phone"`
+	Body           string       `json:"body"`
+	Status         string       `json:"status"`
+	ProviderID     string       `json:"provider_id"`
+	Error          string       `json:"error"`
+	CreatedAt      time.Time    `json:"created_at"`
+	SentAt         sql.NullTime `json:"sent_at"`
+}
+
+type SecurityPolicy struct {
+	ID          bool          `json:"id"`
+	RequireTotp bool          `json:"require_totp"`
+	UpdatedAt   time.Time     `json:"updated_at"`
+	UpdatedBy   uuid.NullUUID `json:"updated_by"`
+}
+
+type Session struct {
+	ID         uuid.UUID `json:"id"`
+	AdminID    uuid.UUID `json:"admin_id"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	Ip         string    `json:"ip"`
+	UserAgent  string    `json:"user_agent"`
+}
+
+type Tenant struct {
+	ID                uuid.UUID `json:"id"`
+	Name              string    `json:"name"`
+	Phone             string    `json:"phone"`
+	HouseID           uuid.UUID `json:"house_id"`
+	PersonalIDType    string    `json:"personal_id_type"`
+	PersonalID        string    `json:"personal_id"`
+	Photo             string    `json:"photo"`
+	Active            bool      `json:"active"`
+	Sos               time.Time `json:"sos"`
+	Eos               time.Time `json:"eos"`
+	Version           uuid.UUID `json:"version"`
+	OrganizationID    uuid.UUID `json:"organization_id"`
+	PreferredLanguage string    `json:"preferred_language"`
+	Email             string    `json:"email"`
 }
 
 type Token struct {
-	Hash   []byte    `json:"hash"`
-	ID     uuid.UUID `json:"id"`
-	Expiry time.Time `json:"expiry"`
-	Scope  string    `json:"scope"`
+	Hash      []byte        `json:"hash"`
+	ID        uuid.UUID     `json:"id"`
+	Expiry    time.Time     `json:"expiry"`
+	Scope     string        `json:"scope"`
+	SessionID uuid.NullUUID `json:"session_id"`
+	RotatedAt sql.NullTime  `json:"rotated_at"`
 }
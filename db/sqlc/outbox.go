@@ -0,0 +1,166 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	OutboxPending = "pending"
+	OutboxSent    = "sent"
+	OutboxSkipped = "skipped"
+	OutboxDead    = "dead"
+)
+
+// OutboxMessage is a notification queued together with the change that
+// causes it. Kind tells the dispatcher how to deliver Payload, which is stored
+// as JSON.
+type OutboxMessage struct {
+	Kind      string
+	Recipient string
+	Payload   any
+}
+
+func enqueue(ctx context.Context, q *Queries, messages ...OutboxMessage) error {
+
+	for _, m := range messages {
+
+		payload, err := json.Marshal(m.Payload)
+		if err != nil {
+			return fmt.Errorf("encoding %s message: %w", m.Kind, err)
+		}
+
+		err = q.CreateOutboxMessage(ctx, CreateOutboxMessageParams{
+			Kind:      m.Kind,
+			Recipient: m.Recipient,
+			Payload:   payload,
+		})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Enqueue queues messages that do not go with any other change.
+func (s *SQLStore) Enqueue(ctx context.Context, messages ...OutboxMessage) error {
+
+	tx, err := s.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	if err = enqueue(ctx, New(tx), messages...); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// NewTokenWithMessage creates a token and queues the message built from its
+// plaintext, such as the email that carries it, in one transaction: either the
+// token exists and its message will be delivered, or neither happened.
+func (s *SQLStore) NewTokenWithMessage(ctx context.Context, id uuid.UUID, expiry time.Time, scope string, message func(plaintext string) OutboxMessage) (*TokenLoc, error) {
+
+	token, err := generateToken(id, expiry, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	qtx := New(tx)
+
+	err = qtx.CreateToken(ctx, CreateTokenParams{
+		Hash:   token.Hash,
+		ID:     id,
+		Expiry: token.Expiry,
+		Scope:  token.Scope,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = enqueue(ctx, qtx, message(token.Plaintext)); err != nil {
+		return nil, err
+	}
+
+	return token, tx.Commit()
+}
+
+// TxnCreateReminder records a reminder and queues the message built from its
+// id. It returns false, queueing nothing, when the tenant already has this
+// reminder.
+func (s *SQLStore) TxnCreateReminder(ctx context.Context, args CreateReminderParams, message func(id uuid.UUID) OutboxMessage) (bool, error) {
+
+	tx, err := s.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return false, err
+	}
+
+	defer tx.Rollback()
+
+	qtx := New(tx)
+
+	id, err := qtx.CreateReminder(ctx, args)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	if err = enqueue(ctx, qtx, message(id)); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+// TxnQueueDigest records an admin's digest for the day as sent and queues its
+// messages. It returns false, queueing nothing, when the day's digest was
+// already recorded, by this instance or another.
+func (s *SQLStore) TxnQueueDigest(ctx context.Context, args MarkDigestSentParams, messages ...OutboxMessage) (bool, error) {
+
+	tx, err := s.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return false, err
+	}
+
+	defer tx.Rollback()
+
+	qtx := New(tx)
+
+	n, err := qtx.MarkDigestSent(ctx, args)
+
+	if err != nil || n == 0 {
+		return false, err
+	}
+
+	if err = enqueue(ctx, qtx, messages...); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
@@ -12,35 +12,44 @@ import (
 	"github.com/google/uuid"
 )
 
-const createPayment = `-- name: CreatePayment :exec
-INSERT INTO payment (tenant_id, amount, start_date, end_date, created_by) VALUES ($1,$2,$3,$4,$5) RETURNING id
+const createPayment = `-- name: CreatePayment :one
+INSERT INTO payment (tenant_id, amount, start_date, end_date, created_by, organization_id) VALUES ($1,$2,$3,$4,$5,$6) RETURNING id
 `
 
 type CreatePaymentParams struct {
-	TenantID  uuid.UUID `json:"tenant_id"`
-	Amount    int32     `json:"amount"`
-	StartDate time.Time `json:"start_date"`
-	EndDate   time.Time `json:"end_date"`
-	CreatedBy uuid.UUID `json:"created_by"`
+	TenantID       uuid.UUID `json:"tenant_id"`
+	Amount         int32     `json:"amount"`
+	StartDate      time.Time `json:"start_date"`
+	EndDate        time.Time `json:"end_date"`
+	CreatedBy      uuid.UUID `json:"created_by"`
+	OrganizationID uuid.UUID `json:"organization_id"`
 }
 
-func (q *Queries) CreatePayment(ctx context.Context, arg CreatePaymentParams) error {
-	_, err := q.db.ExecContext(ctx, createPayment,
+func (q *Queries) CreatePayment(ctx context.Context, arg CreatePaymentParams) (uuid.UUID, error) {
+	row := q.db.QueryRowContext(ctx, createPayment,
 		arg.TenantID,
 		arg.Amount,
 		arg.StartDate,
 		arg.EndDate,
 		arg.CreatedBy,
+		arg.OrganizationID,
 	)
-	return err
+	var id uuid.UUID
+	err := row.Scan(&id)
+	return id, err
 }
 
 const deletePayment = `-- name: DeletePayment :exec
-DELETE FROM payment WHERE id = $1
+DELETE FROM payment WHERE id = $1 AND organization_id = $2
 `
 
-func (q *Queries) DeletePayment(ctx context.Context, id uuid.UUID) error {
-	_, err := q.db.ExecContext(ctx, deletePayment, id)
+type DeletePaymentParams struct {
+	ID             uuid.UUID `json:"id"`
+	OrganizationID uuid.UUID `json:"organization_id"`
+}
+
+func (q *Queries) DeletePayment(ctx context.Context, arg DeletePaymentParams) error {
+	_, err := q.db.ExecContext(ctx, deletePayment, arg.ID, arg.OrganizationID)
 	return err
 }
 
@@ -52,6 +61,7 @@ FROM payment p
 JOIN tenant t ON p.tenant_id = t.id
 JOIN house h ON t.house_id = h.id
 JOIN admin a ON p.created_by = a.id
+WHERE p.organization_id = $1
 `
 
 type GetAllPaymentsRow struct {
@@ -70,8 +80,8 @@ type GetAllPaymentsRow struct {
 	Version    uuid.UUID `json:"version"`
 }
 
-func (q *Queries) GetAllPayments(ctx context.Context) ([]GetAllPaymentsRow, error) {
-	rows, err := q.db.QueryContext(ctx, getAllPayments)
+func (q *Queries) GetAllPayments(ctx context.Context, organizationID uuid.UUID) ([]GetAllPaymentsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getAllPayments, organizationID)
 	if err != nil {
 		return nil, err
 	}
@@ -115,9 +125,14 @@ FROM payment p
 JOIN tenant t ON p.tenant_id = t.id
 JOIN house h ON t.house_id = h.id
 JOIN admin a ON p.created_by = a.id
-WHERE p.id = $1
+WHERE p.id = $1 AND p.organization_id = $2
 `
 
+type GetDetailedPaymentByIdParams struct {
+	ID             uuid.UUID `json:"id"`
+	OrganizationID uuid.UUID `json:"organization_id"`
+}
+
 type GetDetailedPaymentByIdRow struct {
 	ID         uuid.UUID `json:"id"`
 	TenantName string    `json:"tenant_name"`
@@ -134,8 +149,8 @@ type GetDetailedPaymentByIdRow struct {
 	Version    uuid.UUID `json:"version"`
 }
 
-func (q *Queries) GetDetailedPaymentById(ctx context.Context, id uuid.UUID) (GetDetailedPaymentByIdRow, error) {
-	row := q.db.QueryRowContext(ctx, getDetailedPaymentById, id)
+func (q *Queries) GetDetailedPaymentById(ctx context.Context, arg GetDetailedPaymentByIdParams) (GetDetailedPaymentByIdRow, error) {
+	row := q.db.QueryRowContext(ctx, getDetailedPaymentById, arg.ID, arg.OrganizationID)
 	var i GetDetailedPaymentByIdRow
 	err := row.Scan(
 		&i.ID,
@@ -156,12 +171,17 @@ func (q *Queries) GetDetailedPaymentById(ctx context.Context, id uuid.UUID) (Get
 }
 
 const getPaymentById = `-- name: GetPaymentById :one
-SELECT id, tenant_id, amount, start_date, end_date, version, created_at, created_by, updated_at FROM payment
-WHERE id = $1
+SELECT id, tenant_id, amount, start_date, end_date, version, created_at, created_by, updated_at, organization_id FROM payment
+WHERE id = $1 AND organization_id = $2
 `
 
-func (q *Queries) GetPaymentById(ctx context.Context, id uuid.UUID) (Payment, error) {
-	row := q.db.QueryRowContext(ctx, getPaymentById, id)
+type GetPaymentByIdParams struct {
+	ID             uuid.UUID `json:"id"`
+	OrganizationID uuid.UUID `json:"organization_id"`
+}
+
+func (q *Queries) GetPaymentById(ctx context.Context, arg GetPaymentByIdParams) (Payment, error) {
+	row := q.db.QueryRowContext(ctx, getPaymentById, arg.ID, arg.OrganizationID)
 	var i Payment
 	err := row.Scan(
 		&i.ID,
@@ -173,31 +193,54 @@ func (q *Queries) GetPaymentById(ctx context.Context, id uuid.UUID) (Payment, er
 		&i.CreatedAt,
 		&i.CreatedBy,
 		&i.UpdatedAt,
+		&i.OrganizationID,
 	)
 	return i, err
 }
 
-const updatePayment = `-- name: UpdatePayment :exec
+const lockPayment = `-- name: LockPayment :exec
+SELECT 1 FROM payment
+WHERE id = $1 AND organization_id = $2
+FOR UPDATE
+`
+
+type LockPaymentParams struct {
+	ID             uuid.UUID `json:"id"`
+	OrganizationID uuid.UUID `json:"organization_id"`
+}
+
+// LockPayment holds the payment for the rest of the transaction.
+func (q *Queries) LockPayment(ctx context.Context, arg LockPaymentParams) error {
+	_, err := q.db.ExecContext(ctx, lockPayment, arg.ID, arg.OrganizationID)
+	return err
+}
+
+const updatePayment = `-- name: UpdatePayment :execrows
 UPDATE payment
 SET amount = $1, start_date = $2, end_date = $3, version = uuid_generate_v4(), updated_at = NOW()
-WHERE id = $4 AND version = $5
+WHERE id = $4 AND version = $5 AND organization_id = $6
 `
 
 type UpdatePaymentParams struct {
-	Amount    int32     `json:"amount"`
-	StartDate time.Time `json:"start_date"`
-	EndDate   time.Time `json:"end_date"`
-	ID        uuid.UUID `json:"id"`
-	Version   uuid.UUID `json:"version"`
+	Amount         int32     `json:"amount"`
+	StartDate      time.Time `json:"start_date"`
+	EndDate        time.Time `json:"end_date"`
+	ID             uuid.UUID `json:"id"`
+	Version        uuid.UUID `json:"version"`
+	OrganizationID uuid.UUID `json:"organization_id"`
 }
 
-func (q *Queries) UpdatePayment(ctx context.Context, arg UpdatePaymentParams) error {
-	_, err := q.db.ExecContext(ctx, updatePayment,
+func (q *Queries) UpdatePayment(ctx context.Context, arg UpdatePaymentParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updatePayment,
 		arg.Amount,
 		arg.StartDate,
 		arg.EndDate,
 		arg.ID,
 		arg.Version,
+		arg.OrganizationID,
 	)
-	return err
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
@@ -6,32 +6,172 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 type Querier interface {
+	AcceptInvite(ctx context.Context, id uuid.UUID) (uuid.UUID, error)
+	AddMemberOfAllOrganizations(ctx context.Context, adminID uuid.UUID) error
+	AddOrganizationMember(ctx context.Context, arg AddOrganizationMemberParams) error
+	ClaimOutboxMessages(ctx context.Context, arg ClaimOutboxMessagesParams) ([]ClaimOutboxMessagesRow, error)
+	ConfirmAdminTotp(ctx context.Context, arg ConfirmAdminTotpParams) (int64, error)
+	CountRecoveryCodes(ctx context.Context, adminID uuid.UUID) (int64, error)
 	CreateAdmin(ctx context.Context, arg CreateAdminParams) (CreateAdminRow, error)
+	CreateApiKey(ctx context.Context, arg CreateApiKeyParams) (CreateApiKeyRow, error)
+	CreateAuditLog(ctx context.Context, arg CreateAuditLogParams) error
+	CreateChecklistItem(ctx context.Context, arg CreateChecklistItemParams) (uuid.UUID, error)
 	CreateHouse(ctx context.Context, arg CreateHouseParams) (uuid.UUID, error)
-	CreatePayment(ctx context.Context, arg CreatePaymentParams) error
-	CreateTenant(ctx context.Context, arg CreateTenantParams) error
+	CreateInspection(ctx context.Context, arg CreateInspectionParams) (uuid.UUID, error)
+	CreateInspectionItem(ctx context.Context, arg CreateInspectionItemParams) (uuid.UUID, error)
+	CreateInspectionPhoto(ctx context.Context, arg CreateInspectionPhotoParams) error
+	CreateInvite(ctx context.Context, arg CreateInviteParams) (uuid.UUID, error)
+	CreateListingOptOut(ctx context.Context, arg CreateListingOptOutParams) error
+	CreateLoginAttempt(ctx context.Context, arg CreateLoginAttemptParams) error
+	CreateNotificationConsent(ctx context.Context, arg CreateNotificationConsentParams) error
+	CreateOrganization(ctx context.Context, name string) (Organization, error)
+	CreateOutboxMessage(ctx context.Context, arg CreateOutboxMessageParams) error
+	CreatePasswordHistory(ctx context.Context, arg CreatePasswordHistoryParams) error
+	CreatePayment(ctx context.Context, arg CreatePaymentParams) (uuid.UUID, error)
+	CreateRecoveryCode(ctx context.Context, arg CreateRecoveryCodeParams) error
+	CreateReminder(ctx context.Context, arg CreateReminderParams) (uuid.UUID, error)
+	CreateSession(ctx context.Context, arg CreateSessionParams) (uuid.UUID, error)
+	CreateSuperUser(ctx context.Context, arg CreateSuperUserParams) (CreateSuperUserRow, error)
+	CreateTenant(ctx context.Context, arg CreateTenantParams) (uuid.UUID, error)
 	CreateToken(ctx context.Context, arg CreateTokenParams) error
+	DeferOutboxMessage(ctx context.Context, arg DeferOutboxMessageParams) error
+	DeleteAdminTotp(ctx context.Context, adminID uuid.UUID) error
+	DeleteAllSessions(ctx context.Context, adminID uuid.UUID) error
 	DeleteAllToken(ctx context.Context, arg DeleteAllTokenParams) error
-	DeleteHouseById(ctx context.Context, id uuid.UUID) error
-	DeletePayment(ctx context.Context, id uuid.UUID) error
+	DeleteChecklistItem(ctx context.Context, arg DeleteChecklistItemParams) (int64, error)
+	DeleteEmptySessions(ctx context.Context, createdAt time.Time) (int64, error)
+	DeleteExpiredTokens(ctx context.Context, arg DeleteExpiredTokensParams) (int64, error)
+	DeleteHouseById(ctx context.Context, arg DeleteHouseByIdParams) error
+	DeleteInspection(ctx context.Context, arg DeleteInspectionParams) (int64, error)
+	DeleteListingOptOut(ctx context.Context, arg DeleteListingOptOutParams) (int64, error)
+	DeleteOtherSessions(ctx context.Context, arg DeleteOtherSessionsParams) error
+	DeletePayment(ctx context.Context, arg DeletePaymentParams) error
+	DeleteRecoveryCodes(ctx context.Context, adminID uuid.UUID) error
+	DeleteSentOutboxMessages(ctx context.Context, sentAt sql.NullTime) (int64, error)
+	DeleteSession(ctx context.Context, arg DeleteSessionParams) (int64, error)
+	DeleteSessionlessTokens(ctx context.Context, arg DeleteSessionlessTokensParams) error
+	DeleteToken(ctx context.Context, hash []byte) error
 	GetAdminByEmail(ctx context.Context, email string) (Admin, error)
-	GetAllPayments(ctx context.Context) ([]GetAllPaymentsRow, error)
-	GetDetailedPaymentById(ctx context.Context, id uuid.UUID) (GetDetailedPaymentByIdRow, error)
+	GetAdminById(ctx context.Context, id uuid.UUID) (Admin, error)
+	GetAdminTotp(ctx context.Context, adminID uuid.UUID) (AdminTotp, error)
+	GetAdmins(ctx context.Context) ([]GetAdminsRow, error)
+	GetAllPayments(ctx context.Context, organizationID uuid.UUID) ([]GetAllPaymentsRow, error)
+	GetApiKeyByHash(ctx context.Context, arg GetApiKeyByHashParams) (GetApiKeyByHashRow, error)
+	GetApiKeyUsage(ctx context.Context, arg GetApiKeyUsageParams) ([]GetApiKeyUsageRow, error)
+	GetApiKeysForAdmin(ctx context.Context, adminID uuid.UUID) ([]GetApiKeysForAdminRow, error)
+	GetAuditLog(ctx context.Context, arg GetAuditLogParams) ([]AuditLog, error)
+	GetChecklistItems(ctx context.Context, organizationID uuid.UUID) ([]GetChecklistItemsRow, error)
+	GetDetailedPaymentById(ctx context.Context, arg GetDetailedPaymentByIdParams) (GetDetailedPaymentByIdRow, error)
+	GetDigestSettings(ctx context.Context, id uuid.UUID) (GetDigestSettingsRow, error)
+	GetDueDigests(ctx context.Context, arg GetDueDigestsParams) ([]GetDueDigestsRow, error)
+	// Vacancies that ended, with the house let again, from from_day to to_day,
+	// by the month they ended in. A vacancy runs from the first snapshot of a
+	// house found empty to the first found let again.
+	GetEndedVacanciesByMonth(ctx context.Context, arg GetEndedVacanciesByMonthParams) ([]GetEndedVacanciesByMonthRow, error)
 	GetHashTokenForAdmin(ctx context.Context, arg GetHashTokenForAdminParams) (GetHashTokenForAdminRow, error)
-	GetHouseById(ctx context.Context, id uuid.UUID) (GetHouseByIdRow, error)
-	GetHouses(ctx context.Context) ([]GetHousesRow, error)
-	GetPaymentById(ctx context.Context, id uuid.UUID) (Payment, error)
-	GetTenantById(ctx context.Context, id uuid.UUID) (GetTenantByIdRow, error)
-	GetTenants(ctx context.Context) ([]GetTenantsRow, error)
+	GetHouseById(ctx context.Context, arg GetHouseByIdParams) (GetHouseByIdRow, error)
+	GetHouseHistoryCount(ctx context.Context, arg GetHouseHistoryCountParams) (GetHouseHistoryCountRow, error)
+	GetHouseIdByUnit(ctx context.Context, arg GetHouseIdByUnitParams) (uuid.UUID, error)
+	// GetHouseUnits lists every house with what an import may not change on it:
+	// whether it is archived and whether an active tenant lives in it.
+	GetHouseUnits(ctx context.Context, organizationID uuid.UUID) ([]GetHouseUnitsRow, error)
+	GetHouses(ctx context.Context, organizationID uuid.UUID) ([]GetHousesRow, error)
+	GetHousesByIds(ctx context.Context, arg GetHousesByIdsParams) ([]House, error)
+	// Rent expected and collected for each house and month from from_month to
+	// to_month, both the first of a month. A house is expected to bring in its
+	// price for every month a tenancy, from sos to eos, overlaps; a payment counts
+	// as collected in the local month it was recorded in.
+	GetIncomeByHouse(ctx context.Context, arg GetIncomeByHouseParams) ([]GetIncomeByHouseRow, error)
+	GetInspectionById(ctx context.Context, arg GetInspectionByIdParams) (Inspection, error)
+	GetInspectionItems(ctx context.Context, arg GetInspectionItemsParams) ([]GetInspectionItemsRow, error)
+	GetInspectionPhotos(ctx context.Context, arg GetInspectionPhotosParams) ([]GetInspectionPhotosRow, error)
+	GetInspectionsByHouse(ctx context.Context, arg GetInspectionsByHouseParams) ([]Inspection, error)
+	GetInvites(ctx context.Context, organizationID uuid.UUID) ([]GetInvitesRow, error)
+	GetListingOptOuts(ctx context.Context, organizationID uuid.UUID) ([]ListingOptOut, error)
+	GetLoginFailuresByEmail(ctx context.Context, arg GetLoginFailuresByEmailParams) (GetLoginFailuresByEmailRow, error)
+	GetLoginFailuresByIp(ctx context.Context, arg GetLoginFailuresByIpParams) (GetLoginFailuresByIpRow, error)
+	GetNotificationConsents(ctx context.Context, arg GetNotificationConsentsParams) ([]GetNotificationConsentsRow, error)
+	GetNotificationSettings(ctx context.Context, arg GetNotificationSettingsParams) (GetNotificationSettingsRow, error)
+	GetOccupancyByMonth(ctx context.Context, arg GetOccupancyByMonthParams) ([]GetOccupancyByMonthRow, error)
+	GetOrganizationForAdmin(ctx context.Context, arg GetOrganizationForAdminParams) (Organization, error)
+	GetOrganizationMembers(ctx context.Context, organizationID uuid.UUID) ([]GetOrganizationMembersRow, error)
+	GetOrganizations(ctx context.Context) ([]Organization, error)
+	GetOrganizationsForAdmin(ctx context.Context, adminID uuid.UUID) ([]Organization, error)
+	GetOutboxMessages(ctx context.Context, arg GetOutboxMessagesParams) ([]GetOutboxMessagesRow, error)
+	GetPasswordHistory(ctx context.Context, arg GetPasswordHistoryParams) ([][]byte, error)
+	GetPaymentById(ctx context.Context, arg GetPaymentByIdParams) (Payment, error)
+	GetPendingInviteByHash(ctx context.Context, arg GetPendingInviteByHashParams) (GetPendingInviteByHashRow, error)
+	GetRecentPayments(ctx context.Context, arg GetRecentPaymentsParams) ([]GetRecentPaymentsRow, error)
+	GetReminderCandidates(ctx context.Context, arg GetReminderCandidatesParams) ([]GetReminderCandidatesRow, error)
+	GetRemindersForTenant(ctx context.Context, arg GetRemindersForTenantParams) ([]GetRemindersForTenantRow, error)
+	GetSecurityPolicy(ctx context.Context) (SecurityPolicy, error)
+	GetSessionsForAdmin(ctx context.Context, adminID uuid.UUID) ([]GetSessionsForAdminRow, error)
+	GetSignInsForAdmin(ctx context.Context, arg GetSignInsForAdminParams) ([]GetSignInsForAdminRow, error)
+	GetTenancyInspection(ctx context.Context, arg GetTenancyInspectionParams) (Inspection, error)
+	GetTenantById(ctx context.Context, arg GetTenantByIdParams) (GetTenantByIdRow, error)
+	GetTenants(ctx context.Context, organizationID uuid.UUID) ([]GetTenantsRow, error)
+	// Numbers are compared in full once both are in E.164 form.
+	GetTenantsByPhone(ctx context.Context, arg GetTenantsByPhoneParams) ([]uuid.UUID, error)
+	GetTenantsEnding(ctx context.Context, arg GetTenantsEndingParams) ([]GetTenantsEndingRow, error)
+	GetTenantsInArrears(ctx context.Context, arg GetTenantsInArrearsParams) ([]GetTenantsInArrearsRow, error)
+	GetTokenForUpdate(ctx context.Context, arg GetTokenForUpdateParams) (Token, error)
+	GetVacatedHouses(ctx context.Context, arg GetVacatedHousesParams) ([]GetVacatedHousesRow, error)
+	// LockAdmin holds the admin for the rest of the transaction.
+	LockAdmin(ctx context.Context, id uuid.UUID) error
+	// LockHouse holds the house for the rest of the transaction.
+	LockHouse(ctx context.Context, arg LockHouseParams) error
+	// LockPayment holds the payment for the rest of the transaction.
+	LockPayment(ctx context.Context, arg LockPaymentParams) error
+	// LockTenant holds the tenant for the rest of the transaction.
+	LockTenant(ctx context.Context, arg LockTenantParams) error
+	MarkDigestSent(ctx context.Context, arg MarkDigestSentParams) (int64, error)
+	MarkOutboxMessageDead(ctx context.Context, arg MarkOutboxMessageDeadParams) error
+	MarkOutboxMessageFailed(ctx context.Context, arg MarkOutboxMessageFailedParams) error
+	MarkOutboxMessageSent(ctx context.Context, id int64) error
+	MarkOutboxMessageSkipped(ctx context.Context, arg MarkOutboxMessageSkippedParams) error
+	MarkReminderFailed(ctx context.Context, arg MarkReminderFailedParams) error
+	MarkReminderSent(ctx context.Context, arg MarkReminderSentParams) error
+	MarkReminderSkipped(ctx context.Context, arg MarkReminderSkippedParams) error
+	MarkTokenRotated(ctx context.Context, hash []byte) error
+	// OccupyHouse lets a house that is vacant and not archived. It changes no row
+	// otherwise, and the row lock it takes keeps two tenants from moving in at
+	// once.
+	OccupyHouse(ctx context.Context, arg OccupyHouseParams) (int64, error)
+	RecordApiKeyUsage(ctx context.Context, arg RecordApiKeyUsageParams) error
+	RemoveOrganizationMember(ctx context.Context, arg RemoveOrganizationMemberParams) (int64, error)
+	ReplayDeadOutboxMessages(ctx context.Context) (int64, error)
+	ReplayOutboxMessage(ctx context.Context, id int64) (int64, error)
+	RevokeApiKey(ctx context.Context, arg RevokeApiKeyParams) (int64, error)
+	RevokeInvite(ctx context.Context, arg RevokeInviteParams) (int64, error)
+	SetHouseArchived(ctx context.Context, arg SetHouseArchivedParams) (int64, error)
+	SetHouseOccupied(ctx context.Context, arg SetHouseOccupiedParams) error
+	SignInspection(ctx context.Context, arg SignInspectionParams) (int64, error)
+	SnapshotOccupancy(ctx context.Context, day time.Time) (int64, error)
+	TouchSession(ctx context.Context, id uuid.UUID) error
 	UpdateAdmin(ctx context.Context, arg UpdateAdminParams) (uuid.UUID, error)
-	UpdateHouseById(ctx context.Context, arg UpdateHouseByIdParams) error
-	UpdatePayment(ctx context.Context, arg UpdatePaymentParams) error
-	UpdateTenant(ctx context.Context, arg UpdateTenantParams) error
+	UpdateAdminLanguage(ctx context.Context, arg UpdateAdminLanguageParams) (int64, error)
+	UpdateAdminRole(ctx context.Context, arg UpdateAdminRoleParams) (int64, error)
+	UpdateHouseById(ctx context.Context, arg UpdateHouseByIdParams) (int64, error)
+	UpdateOrganization(ctx context.Context, arg UpdateOrganizationParams) (int64, error)
+	UpdatePayment(ctx context.Context, arg UpdatePaymentParams) (int64, error)
+	UpdateSecurityPolicy(ctx context.Context, arg UpdateSecurityPolicyParams) error
+	UpdateTenant(ctx context.Context, arg UpdateTenantParams) (int64, error)
+	UpdateTotpLastStep(ctx context.Context, arg UpdateTotpLastStepParams) (int64, error)
+	UpsertAdminTotp(ctx context.Context, arg UpsertAdminTotpParams) (int64, error)
+	UpsertDigestSettings(ctx context.Context, arg UpsertDigestSettingsParams) error
+	// A null occupied creates a vacant house and leaves an existing one as it is.
+	// An archived house, or one an active tenant lives in that would be vacated,
+	// is left alone and no row is returned.
+	UpsertHouse(ctx context.Context, arg UpsertHouseParams) (uuid.UUID, error)
+	UpsertNotificationPreference(ctx context.Context, arg UpsertNotificationPreferenceParams) error
+	UseRecoveryCode(ctx context.Context, arg UseRecoveryCodeParams) (int64, error)
 }
 
 var _ Querier = (*Queries)(nil)
@@ -0,0 +1,140 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrRefreshTokenInvalid = errors.New("invalid or expired refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected")
+)
+
+// SessionTokens is the pair handed to a client on login and on every refresh.
+type SessionTokens struct {
+	Access  *TokenLoc
+	Refresh *TokenLoc
+}
+
+// NewSession starts a login session and issues its first access and refresh
+// tokens. The session is the token family: deleting it deletes every token
+// issued to it.
+func (s *SQLStore) NewSession(ctx context.Context, id uuid.UUID, accessExpiry, refreshExpiry time.Time, ip, userAgent string) (*SessionTokens, error) {
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	qtx := New(tx)
+
+	sessionID, err := qtx.CreateSession(ctx, CreateSessionParams{
+		AdminID:   id,
+		Ip:        ip,
+		UserAgent: userAgent,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, err := issueSessionTokens(ctx, qtx, id, sessionID, accessExpiry, refreshExpiry)
+	if err != nil {
+		return nil, err
+	}
+
+	return tokens, tx.Commit()
+}
+
+// RefreshSession exchanges a refresh token for a new pair in the same
+// session. Each refresh token works once; presenting one that was already
+// rotated means it leaked, so the whole session is revoked.
+func (s *SQLStore) RefreshSession(ctx context.Context, hash []byte, accessExpiry, refreshExpiry time.Time) (*SessionTokens, error) {
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	qtx := New(tx)
+
+	token, err := qtx.GetTokenForUpdate(ctx, GetTokenForUpdateParams{
+		Hash:  hash,
+		Scope: ScopeRefresh,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrRefreshTokenInvalid
+		}
+		return nil, err
+	}
+
+	if !token.SessionID.Valid || !token.Expiry.After(time.Now()) {
+		return nil, ErrRefreshTokenInvalid
+	}
+
+	if token.RotatedAt.Valid {
+		_, err = qtx.DeleteSession(ctx, DeleteSessionParams{
+			ID:      token.SessionID.UUID,
+			AdminID: token.ID,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err = tx.Commit(); err != nil {
+			return nil, err
+		}
+		return nil, ErrRefreshTokenReused
+	}
+
+	if err = qtx.MarkTokenRotated(ctx, hash); err != nil {
+		return nil, err
+	}
+
+	if err = qtx.TouchSession(ctx, token.SessionID.UUID); err != nil {
+		return nil, err
+	}
+
+	tokens, err := issueSessionTokens(ctx, qtx, token.ID, token.SessionID.UUID, accessExpiry, refreshExpiry)
+	if err != nil {
+		return nil, err
+	}
+
+	return tokens, tx.Commit()
+}
+
+func issueSessionTokens(ctx context.Context, q *Queries, id, sessionID uuid.UUID, accessExpiry, refreshExpiry time.Time) (*SessionTokens, error) {
+
+	access, err := generateToken(id, accessExpiry, ScopeAuthentication)
+	if err != nil {
+		return nil, err
+	}
+
+	refresh, err := generateToken(id, refreshExpiry, ScopeRefresh)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, t := range []*TokenLoc{access, refresh} {
+		t.SessionID = sessionID
+		err = q.CreateToken(ctx, CreateTokenParams{
+			Hash:      t.Hash,
+			ID:        id,
+			Expiry:    t.Expiry,
+			Scope:     t.Scope,
+			SessionID: uuid.NullUUID{UUID: sessionID, Valid: true},
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &SessionTokens{Access: access, Refresh: refresh}, nil
+}
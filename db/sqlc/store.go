@@ -11,10 +11,31 @@ import (
 type Store interface {
 	Querier
 	NewToken(id uuid.UUID, expiry time.Time, scope string) (*TokenLoc, error)
-	BulkInsert(ctx context.Context, houses []HouseBulk) error
-	TxnCreateTenant(ctx context.Context, args CreateTenantParams) error
-	TxnUpdateTenantHouse(ctx context.Context, args UpdateTenantParams, prev_house_id uuid.UUID) error
-	TxnRemoveTenantHouse(ctx context.Context, args UpdateTenantParams) error
+	NewSession(ctx context.Context, id uuid.UUID, accessExpiry, refreshExpiry time.Time, ip, userAgent string) (*SessionTokens, error)
+	RefreshSession(ctx context.Context, hash []byte, accessExpiry, refreshExpiry time.Time) (*SessionTokens, error)
+	NewTokenWithMessage(ctx context.Context, id uuid.UUID, expiry time.Time, scope string, message func(plaintext string) OutboxMessage) (*TokenLoc, error)
+	NewInvite(ctx context.Context, email, role string, invitedBy, organizationID uuid.UUID, expiry time.Time, message func(plaintext string) OutboxMessage) error
+	Enqueue(ctx context.Context, messages ...OutboxMessage) error
+	TxnCreateReminder(ctx context.Context, args CreateReminderParams, message func(id uuid.UUID) OutboxMessage) (bool, error)
+	TxnQueueDigest(ctx context.Context, args MarkDigestSentParams, messages ...OutboxMessage) (bool, error)
+	ListHouses(ctx context.Context, arg ListHousesParams) ([]ListHousesRow, error)
+	EachHouse(ctx context.Context, arg ListHousesParams, fn func(ListHousesRow) error) error
+	EachTenant(ctx context.Context, organizationID uuid.UUID, fn func(GetTenantsRow) error) error
+	EachPayment(ctx context.Context, organizationID uuid.UUID, fn func(GetAllPaymentsRow) error) error
+	TxnAudit(ctx context.Context, entry AuditEntry, change func(q *Queries) (uuid.UUID, error)) (uuid.UUID, error)
+	TxnUpsertHouses(ctx context.Context, houses []UpsertHouseParams, entry AuditEntry) ([]uuid.UUID, error)
+	TxnCreateTenant(ctx context.Context, args CreateTenantParams, entry AuditEntry) (uuid.UUID, error)
+	TxnUpdateTenantHouse(ctx context.Context, args UpdateTenantParams, prev_house_id uuid.UUID, entry AuditEntry) error
+	TxnRemoveTenantHouse(ctx context.Context, args UpdateTenantParams, entry AuditEntry) error
+	TxnAcceptInvite(ctx context.Context, inviteID uuid.UUID, args CreateAdminParams, entry func(admin CreateAdminRow) AuditEntry) (CreateAdminRow, error)
+	TxnCreateSuperUser(ctx context.Context, args CreateSuperUserParams, entry func(admin CreateSuperUserRow) AuditEntry) (CreateSuperUserRow, error)
+	TxnConfirmTotp(ctx context.Context, adminID uuid.UUID, step int64, recoveryHashes [][]byte) error
+	TxnReplaceRecoveryCodes(ctx context.Context, adminID uuid.UUID, recoveryHashes [][]byte) error
+	TxnDisableTotp(ctx context.Context, adminID uuid.UUID) error
+	TxnChangePassword(ctx context.Context, args UpdateAdminParams, previousHash []byte, keepSession uuid.NullUUID, entry AuditEntry, messages ...OutboxMessage) error
+	TxnCreatePayment(ctx context.Context, args CreatePaymentParams, entry AuditEntry, messages ...OutboxMessage) (uuid.UUID, error)
+	TxnSetNotificationPreference(ctx context.Context, args UpsertNotificationPreferenceParams, consent CreateNotificationConsentParams) error
+	TxnCreateInspection(ctx context.Context, args CreateInspectionParams, items []InspectionItemEntry, photos []string) (uuid.UUID, error)
 }
 
 type SQLStore struct {
@@ -0,0 +1,83 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	_ "github.com/lib/pq"
+)
+
+// the tests that need a database run against the one RENT_TEST_DSN points
+// at, migrating it first when it is empty, and are skipped without it. Each
+// test works in an organization of its own so they leave each other alone.
+var (
+	testDBOnce sync.Once
+	testDB     *sql.DB
+	testDBErr  error
+)
+
+func newTestStore(t *testing.T) (*SQLStore, uuid.UUID) {
+
+	dsn := os.Getenv("RENT_TEST_DSN")
+
+	if dsn == "" {
+		t.Skip("RENT_TEST_DSN not set")
+	}
+
+	testDBOnce.Do(func() {
+		testDB, testDBErr = sql.Open("postgres", dsn)
+		if testDBErr == nil {
+			testDBErr = migrate(testDB)
+		}
+	})
+
+	if testDBErr != nil {
+		t.Fatalf("opening test database: %v", testDBErr)
+	}
+
+	store := NewStore(testDB).(*SQLStore)
+
+	org, err := store.CreateOrganization(context.Background(), "test "+uuid.NewString())
+	if err != nil {
+		t.Fatalf("CreateOrganization: %v", err)
+	}
+
+	return store, org.ID
+}
+
+// migrate applies the up migrations to a database that has none yet.
+func migrate(db *sql.DB) error {
+
+	var house sql.NullString
+
+	if err := db.QueryRow(`SELECT to_regclass('house')::text`).Scan(&house); err != nil || house.Valid {
+		return err
+	}
+
+	files, err := filepath.Glob(filepath.Join("..", "migrations", "*.up.sql"))
+	if err != nil {
+		return err
+	}
+
+	sort.Strings(files)
+
+	for _, file := range files {
+
+		stmts, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+
+		if _, err := db.Exec(string(stmts)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
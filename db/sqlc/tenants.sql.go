@@ -12,25 +12,29 @@ import (
 	"github.com/google/uuid"
 )
 
-const createTenant = `-- name: CreateTenant :exec
+const createTenant = `-- name: CreateTenant :one
 INSERT INTO TENANT
-(name, house_id, phone, personal_id_type,personal_id, active, sos, eos) 
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+(name, house_id, phone, personal_id_type,personal_id, active, sos, eos, organization_id, preferred_language, email) 
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+RETURNING id
 `
 
 type CreateTenantParams struct {
-	Name           string    `json:"name"`
-	HouseID        uuid.UUID `json:"house_id"`
-	Phone          string    `json:"phone"`
-	PersonalIDType string    `json:"personal_id_type"`
-	PersonalID     string    `json:"personal_id"`
-	Active         bool      `json:"active"`
-	Sos            time.Time `json:"sos"`
-	Eos            time.Time `json:"eos"`
+	Name              string    `json:"name"`
+	HouseID           uuid.UUID `json:"house_id"`
+	Phone             string    `json:"phone"`
+	PersonalIDType    string    `json:"personal_id_type"`
+	PersonalID        string    `json:"personal_id"`
+	Active            bool      `json:"active"`
+	Sos               time.Time `json:"sos"`
+	Eos               time.Time `json:"eos"`
+	OrganizationID    uuid.UUID `json:"organization_id"`
+	PreferredLanguage string    `json:"preferred_language"`
+	Email             string    `json:"email"`
 }
 
-func (q *Queries) CreateTenant(ctx context.Context, arg CreateTenantParams) error {
-	_, err := q.db.ExecContext(ctx, createTenant,
+func (q *Queries) CreateTenant(ctx context.Context, arg CreateTenantParams) (uuid.UUID, error) {
+	row := q.db.QueryRowContext(ctx, createTenant,
 		arg.Name,
 		arg.HouseID,
 		arg.Phone,
@@ -39,37 +43,49 @@ func (q *Queries) CreateTenant(ctx context.Context, arg CreateTenantParams) erro
 		arg.Active,
 		arg.Sos,
 		arg.Eos,
+		arg.OrganizationID,
+		arg.PreferredLanguage,
+		arg.Email,
 	)
-	return err
+	var id uuid.UUID
+	err := row.Scan(&id)
+	return id, err
 }
 
 const getTenantById = `-- name: GetTenantById :one
 SELECT t.id AS tenant_id, t.name, t.house_id,h.location, h.block, h.partition, h.price ,
-t.phone, t.personal_id_type,t.personal_id, t.active, t.sos, t.eos, t.version 
+t.phone, t.personal_id_type,t.personal_id, t.active, t.sos, t.eos, t.version, t.preferred_language, t.email
 FROM tenant t
 JOIN house h ON t.house_id = h.id
-WHERE t.id = $1
+WHERE t.id = $1 AND t.organization_id = $2
 `
 
+type GetTenantByIdParams struct {
+	ID             uuid.UUID `json:"id"`
+	OrganizationID uuid.UUID `json:"organization_id"`
+}
+
 type GetTenantByIdRow struct {
-	TenantID       uuid.UUID `json:"tenant_id"`
-	Name           string    `json:"name"`
-	HouseID        uuid.UUID `json:"house_id"`
-	Location       string    `json:"location"`
-	Block          string    `json:"block"`
-	Partition      int16     `json:"partition"`
-	Price          int32     `json:"price"`
-	Phone          string    `json:"phone"`
-	PersonalIDType string    `json:"personal_id_type"`
-	PersonalID     string    `json:"personal_id"`
-	Active         bool      `json:"active"`
-	Sos            time.Time `json:"sos"`
-	Eos            time.Time `json:"eos"`
-	Version        uuid.UUID `json:"version"`
+	TenantID          uuid.UUID `json:"tenant_id"`
+	Name              string    `json:"name"`
+	HouseID           uuid.UUID `json:"house_id"`
+	Location          string    `json:"location"`
+	Block             string    `json:"block"`
+	Partition         int16     `json:"partition"`
+	Price             int32     `json:"price"`
+	Phone             string    `json:"phone"`
+	PersonalIDType    string    `json:"personal_id_type"`
+	PersonalID        string    `json:"personal_id"`
+	Active            bool      `json:"active"`
+	Sos               time.Time `json:"sos"`
+	Eos               time.Time `json:"eos"`
+	Version           uuid.UUID `json:"version"`
+	PreferredLanguage string    `json:"preferred_language"`
+	Email             string    `json:"email"`
 }
 
-func (q *Queries) GetTenantById(ctx context.Context, id uuid.UUID) (GetTenantByIdRow, error) {
-	row := q.db.QueryRowContext(ctx, getTenantById, id)
+func (q *Queries) GetTenantById(ctx context.Context, arg GetTenantByIdParams) (GetTenantByIdRow, error) {
+	row := q.db.QueryRowContext(ctx, getTenantById, arg.ID, arg.OrganizationID)
 	var i GetTenantByIdRow
 	err := row.Scan(
 		&i.TenantID,
@@ -86,6 +102,8 @@ func (q *Queries) GetTenantById(ctx context.Context, id uuid.UUID) (GetTenantByI
 		&i.Sos,
 		&i.Eos,
 		&i.Version,
+		&i.PreferredLanguage,
+		&i.Email,
 	)
 	return i, err
 }
@@ -103,28 +121,33 @@ SELECT
     t.personal_id, 
     t.active, 
     t.sos, 
-    t.eos
+    t.eos,
+    t.preferred_language,
+    t.email
 FROM tenant t
 JOIN house h ON t.house_id = h.id
+WHERE t.organization_id = $1
 `
 
 type GetTenantsRow struct {
-	ID             uuid.UUID `json:"id"`
-	Name           string    `json:"name"`
-	Location       string    `json:"location"`
-	Block          string    `json:"block"`
-	Partition      int16     `json:"partition"`
-	Price          int32     `json:"price"`
-	Phone          string    `json:"phone"`
-	PersonalIDType string    `json:"personal_id_type"`
-	PersonalID     string    `json:"personal_id"`
-	Active         bool      `json:"active"`
-	Sos            time.Time `json:"sos"`
-	Eos            time.Time `json:"eos"`
+	ID                uuid.UUID `json:"id"`
+	Name              string    `json:"name"`
+	Location          string    `json:"location"`
+	Block             string    `json:"block"`
+	Partition         int16     `json:"partition"`
+	Price             int32     `json:"price"`
+	Phone             string    `json:"phone"`
+	PersonalIDType    string    `json:"personal_id_type"`
+	PersonalID        string    `json:"personal_id"`
+	Active            bool      `json:"active"`
+	Sos               time.Time `json:"sos"`
+	Eos               time.Time `json:"eos"`
+	PreferredLanguage string    `json:"preferred_language"`
+	Email             string    `json:"email"`
 }
 
-func (q *Queries) GetTenants(ctx context.Context) ([]GetTenantsRow, error) {
-	rows, err := q.db.QueryContext(ctx, getTenants)
+func (q *Queries) GetTenants(ctx context.Context, organizationID uuid.UUID) ([]GetTenantsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getTenants, organizationID)
 	if err != nil {
 		return nil, err
 	}
@@ -145,6 +168,8 @@ func (q *Queries) GetTenants(ctx context.Context) ([]GetTenantsRow, error) {
 			&i.Active,
 			&i.Sos,
 			&i.Eos,
+			&i.PreferredLanguage,
+			&i.Email,
 		); err != nil {
 			return nil, err
 		}
@@ -159,27 +184,47 @@ func (q *Queries) GetTenants(ctx context.Context) ([]GetTenantsRow, error) {
 	return items, nil
 }
 
-const updateTenant = `-- name: UpdateTenant :exec
+const lockTenant = `-- name: LockTenant :exec
+SELECT 1 FROM tenant
+WHERE id = $1 AND organization_id = $2
+FOR UPDATE
+`
+
+type LockTenantParams struct {
+	ID             uuid.UUID `json:"id"`
+	OrganizationID uuid.UUID `json:"organization_id"`
+}
+
+// LockTenant holds the tenant for the rest of the transaction.
+func (q *Queries) LockTenant(ctx context.Context, arg LockTenantParams) error {
+	_, err := q.db.ExecContext(ctx, lockTenant, arg.ID, arg.OrganizationID)
+	return err
+}
+
+const updateTenant = `-- name: UpdateTenant :execrows
 UPDATE tenant 
-SET name = $1, house_id = $2, phone = $3 ,personal_id_type = $4 ,personal_id = $5 ,active = $6, sos=$7 ,eos = $8, version = uuid_generate_v4()
-WHERE id = $9 AND version = $10
+SET name = $1, house_id = $2, phone = $3 ,personal_id_type = $4 ,personal_id = $5 ,active = $6, sos=$7 ,eos = $8, preferred_language = $12, email = $13, version = uuid_generate_v4()
+WHERE id = $9 AND version = $10 AND organization_id = $11
 `
 
 type UpdateTenantParams struct {
-	Name           string    `json:"name"`
-	HouseID        uuid.UUID `json:"house_id"`
-	Phone          string    `json:"phone"`
-	PersonalIDType string    `json:"personal_id_type"`
-	PersonalID     string    `json:"personal_id"`
-	Active         bool      `json:"active"`
-	Sos            time.Time `json:"sos"`
-	Eos            time.Time `json:"eos"`
-	ID             uuid.UUID `json:"id"`
-	Version        uuid.UUID `json:"version"`
+	Name              string    `json:"name"`
+	HouseID           uuid.UUID `json:"house_id"`
+	Phone             string    `json:"phone"`
+	PersonalIDType    string    `json:"personal_id_type"`
+	PersonalID        string    `json:"personal_id"`
+	Active            bool      `json:"active"`
+	Sos               time.Time `json:"sos"`
+	Eos               time.Time `json:"eos"`
+	ID                uuid.UUID `json:"id"`
+	Version           uuid.UUID `json:"version"`
+	OrganizationID    uuid.UUID `json:"organization_id"`
+	PreferredLanguage string    `json:"preferred_language"`
+	Email             string    `json:"email"`
 }
 
-func (q *Queries) UpdateTenant(ctx context.Context, arg UpdateTenantParams) error {
-	_, err := q.db.ExecContext(ctx, updateTenant,
+func (q *Queries) UpdateTenant(ctx context.Context, arg UpdateTenantParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateTenant,
 		arg.Name,
 		arg.HouseID,
 		arg.Phone,
@@ -190,6 +235,12 @@ func (q *Queries) UpdateTenant(ctx context.Context, arg UpdateTenantParams) erro
 		arg.Eos,
 		arg.ID,
 		arg.Version,
+		arg.OrganizationID,
+		arg.PreferredLanguage,
+		arg.Email,
 	)
-	return err
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
@@ -10,17 +10,19 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createToken = `-- name: CreateToken :exec
-INSERT INTO token (hash, id, expiry, scope) VALUES ($1, $2, $3, $4)
+INSERT INTO token (hash, id, expiry, scope, session_id) VALUES ($1, $2, $3, $4, $5)
 `
 
 type CreateTokenParams struct {
-	Hash   []byte    `json:"hash"`
-	ID     uuid.UUID `json:"id"`
-	Expiry time.Time `json:"expiry"`
-	Scope  string    `json:"scope"`
+	Hash      []byte        `json:"hash"`
+	ID        uuid.UUID     `json:"id"`
+	Expiry    time.Time     `json:"expiry"`
+	Scope     string        `json:"scope"`
+	SessionID uuid.NullUUID `json:"session_id"`
 }
 
 func (q *Queries) CreateToken(ctx context.Context, arg CreateTokenParams) error {
@@ -29,6 +31,7 @@ func (q *Queries) CreateToken(ctx context.Context, arg CreateTokenParams) error
 		arg.ID,
 		arg.Expiry,
 		arg.Scope,
+		arg.SessionID,
 	)
 	return err
 }
@@ -46,3 +49,79 @@ func (q *Queries) DeleteAllToken(ctx context.Context, arg DeleteAllTokenParams)
 	_, err := q.db.ExecContext(ctx, deleteAllToken, arg.Scope, arg.ID)
 	return err
 }
+
+const deleteExpiredTokens = `-- name: DeleteExpiredTokens :execrows
+DELETE FROM token WHERE hash IN (
+    SELECT hash FROM token WHERE expiry < $1 LIMIT $2
+)
+`
+
+type DeleteExpiredTokensParams struct {
+	Expiry time.Time `json:"expiry"`
+	Limit  int32     `json:"limit"`
+}
+
+func (q *Queries) DeleteExpiredTokens(ctx context.Context, arg DeleteExpiredTokensParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteExpiredTokens, arg.Expiry, arg.Limit)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteSessionlessTokens = `-- name: DeleteSessionlessTokens :exec
+DELETE FROM token WHERE id = $1 AND scope = ANY($2::text[]) AND session_id IS NULL
+`
+
+type DeleteSessionlessTokensParams struct {
+	ID     uuid.UUID `json:"id"`
+	Scopes []string  `json:"scopes"`
+}
+
+func (q *Queries) DeleteSessionlessTokens(ctx context.Context, arg DeleteSessionlessTokensParams) error {
+	_, err := q.db.ExecContext(ctx, deleteSessionlessTokens, arg.ID, pq.Array(arg.Scopes))
+	return err
+}
+
+const deleteToken = `-- name: DeleteToken :exec
+DELETE FROM token WHERE hash = $1
+`
+
+func (q *Queries) DeleteToken(ctx context.Context, hash []byte) error {
+	_, err := q.db.ExecContext(ctx, deleteToken, hash)
+	return err
+}
+
+const getTokenForUpdate = `-- name: GetTokenForUpdate :one
+SELECT hash, id, expiry, scope, session_id, rotated_at FROM token
+WHERE hash = $1 AND scope = $2
+FOR UPDATE
+`
+
+type GetTokenForUpdateParams struct {
+	Hash  []byte `json:"hash"`
+	Scope string `json:"scope"`
+}
+
+func (q *Queries) GetTokenForUpdate(ctx context.Context, arg GetTokenForUpdateParams) (Token, error) {
+	row := q.db.QueryRowContext(ctx, getTokenForUpdate, arg.Hash, arg.Scope)
+	var i Token
+	err := row.Scan(
+		&i.Hash,
+		&i.ID,
+		&i.Expiry,
+		&i.Scope,
+		&i.SessionID,
+		&i.RotatedAt,
+	)
+	return i, err
+}
+
+const markTokenRotated = `-- name: MarkTokenRotated :exec
+UPDATE token SET rotated_at = NOW() WHERE hash = $1
+`
+
+func (q *Queries) MarkTokenRotated(ctx context.Context, hash []byte) error {
+	_, err := q.db.ExecContext(ctx, markTokenRotated, hash)
+	return err
+}
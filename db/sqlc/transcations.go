@@ -2,101 +2,183 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 
 	"github.com/google/uuid"
-	"github.com/lib/pq"
 )
 
-type HouseBulk struct {
-	Location  string
-	Block     string
-	Partition int
-	Occupied  bool
-}
-
-func (s *SQLStore) BulkInsert(ctx context.Context, houses []HouseBulk) error {
+// TxnUpsertHouses returns the id of each house in the order given. entry is
+// recorded in the audit log once for each house. It returns ErrEditConflict,
+// and changes nothing, when a house was archived or let since the import was
+// checked, so that it may no longer be changed.
+func (s *SQLStore) TxnUpsertHouses(ctx context.Context, houses []UpsertHouseParams, entry AuditEntry) ([]uuid.UUID, error) {
 
 	fail := func(err error) error {
-		return fmt.Errorf("BulkInsert: %v", err)
+		return fmt.Errorf("TxnUpsertHouses: %v", err)
 	}
 
-	txn, err := s.db.Begin()
+	txn, err := s.db.BeginTx(ctx, nil)
 
 	if err != nil {
-		return fail(err)
+		return nil, fail(err)
 	}
 
 	defer txn.Rollback()
 
-	stmt, err := txn.PrepareContext(ctx, pq.CopyIn("house", "location", "block", "partition", "occupied"))
+	qtx := New(txn)
 
-	if err != nil {
-		return fmt.Errorf("BulkInsert: %v", err)
-	}
+	ids := make([]uuid.UUID, 0, len(houses))
 
 	for _, house := range houses {
-		_, err = stmt.Exec(house.Location, house.Block, house.Partition, house.Occupied)
+
+		houseEntry := entry
+
+		houseEntry.EntityID, err = qtx.GetHouseIdByUnit(ctx, GetHouseIdByUnitParams{
+			OrganizationID: house.OrganizationID,
+			Location:       house.Location,
+			Block:          house.Block,
+			Partition:      house.Partition,
+		})
+
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return nil, fail(fmt.Errorf("error finding house %s-%s%d: %v", house.Location, house.Block, house.Partition, err))
+		}
+
+		id, err := audit(ctx, qtx, houseEntry, func() (uuid.UUID, error) {
+			return qtx.UpsertHouse(ctx, house)
+		})
+
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrEditConflict
+		}
+
 		if err != nil {
-			return fail(fmt.Errorf("error inserting house: %v", err))
+			return nil, fail(fmt.Errorf("error upserting house %s-%s%d: %v", house.Location, house.Block, house.Partition, err))
 		}
+		ids = append(ids, id)
 	}
 
-	_, err = stmt.Exec()
+	err = txn.Commit()
 	if err != nil {
-		return fail(fmt.Errorf("error executing stmt: %v", err))
+		return nil, fail(fmt.Errorf("error commiting txn: %v", err))
 	}
 
-	err = stmt.Close()
-	if err != nil {
-		return fail(fmt.Errorf("error closing stmt: %v", err))
-	}
+	return ids, nil
 
-	err = txn.Commit()
-	if err != nil {
-		return fail(fmt.Errorf("error commiting stmt: %v", err))
+}
+
+var (
+	ErrHouseOccupied = errors.New("house is already occupied")
+	ErrHouseArchived = errors.New("house is archived")
+)
+
+// moveIntoHouse marks a house let, returning ErrHouseOccupied or
+// ErrHouseArchived when it cannot take a tenant and sql.ErrNoRows when there
+// is no such house.
+func moveIntoHouse(ctx context.Context, q *Queries, id, organizationID uuid.UUID) error {
+
+	n, err := q.OccupyHouse(ctx, OccupyHouseParams{
+		ID:             id,
+		OrganizationID: organizationID,
+	})
+
+	if err != nil || n == 1 {
+		return err
 	}
 
-	return nil
+	house, err := q.GetHouseById(ctx, GetHouseByIdParams{
+		ID:             id,
+		OrganizationID: organizationID,
+	})
 
+	switch {
+	case err != nil:
+		return err
+	case house.Archived:
+		return ErrHouseArchived
+	default:
+		return ErrHouseOccupied
+	}
 }
 
-func (store *SQLStore) TxnCreateTenant(ctx context.Context, args CreateTenantParams) error {
+// TxnCreateTenant moves a new tenant into their house, which has to be vacant
+// and not archived.
+func (store *SQLStore) TxnCreateTenant(ctx context.Context, args CreateTenantParams, entry AuditEntry) (uuid.UUID, error) {
 
 	tx, err := store.db.Begin()
 	if err != nil {
-		return err
+		return uuid.Nil, err
 	}
 	defer tx.Rollback()
 	qtx := New(tx)
 
-	err = qtx.CreateTenant(ctx, args)
+	if err = moveIntoHouse(ctx, qtx, args.HouseID, args.OrganizationID); err != nil {
+		return uuid.Nil, err
+	}
+
+	id, err := audit(ctx, qtx, entry, func() (uuid.UUID, error) {
+		return qtx.CreateTenant(ctx, args)
+	})
 
 	if err != nil {
-		return err
+		return uuid.Nil, err
 	}
 
-	house, err := qtx.GetHouseById(ctx, args.HouseID)
+	return id, tx.Commit()
+
+}
+
+func (store *SQLStore) TxnUpdateTenantHouse(ctx context.Context, args UpdateTenantParams, prev_house_id uuid.UUID, entry AuditEntry) error {
+
+	tx, err := store.db.Begin()
 
 	if err != nil {
 		return err
 	}
 
-	if house.Occupied {
-		return fmt.Errorf("house is already occupied")
+	defer tx.Rollback()
+
+	qtx := New(tx)
+
+	// rare cases when tenant shifts houses
+	if prev_house_id != args.HouseID {
+
+		// old house tenant is moving from
+		err = qtx.SetHouseOccupied(ctx, SetHouseOccupiedParams{
+			Occupied:       false,
+			ID:             prev_house_id,
+			OrganizationID: args.OrganizationID,
+		})
+
+		if err != nil {
+			return err
+		}
+
+		// new house tenant is moving to, which has to be vacant
+		if err = moveIntoHouse(ctx, qtx, args.HouseID, args.OrganizationID); err != nil {
+			return err
+		}
+
 	}
 
-	err = qtx.UpdateHouseById(ctx, UpdateHouseByIdParams{
-		Occupied:  true,
-		ID:        args.HouseID,
-		Location:  house.Location,
-		Block:     house.Block,
-		Partition: house.Partition,
-		Version:   house.Version,
+	_, err = audit(ctx, qtx, entry, func() (uuid.UUID, error) {
+
+		n, err := qtx.UpdateTenant(ctx, args)
+
+		if err != nil {
+			return uuid.Nil, err
+		}
+
+		if n == 0 {
+			return uuid.Nil, ErrEditConflict
+		}
+
+		return uuid.Nil, nil
 	})
 
 	if err != nil {
-
 		return err
 	}
 
@@ -104,7 +186,7 @@ func (store *SQLStore) TxnCreateTenant(ctx context.Context, args CreateTenantPar
 
 }
 
-func (store *SQLStore) TxnUpdateTenantHouse(ctx context.Context, args UpdateTenantParams, prev_house_id uuid.UUID) error {
+func (store *SQLStore) TxnRemoveTenantHouse(ctx context.Context, args UpdateTenantParams, entry AuditEntry) error {
 
 	tx, err := store.db.Begin()
 
@@ -116,69 +198,243 @@ func (store *SQLStore) TxnUpdateTenantHouse(ctx context.Context, args UpdateTena
 
 	qtx := New(tx)
 
-	// rare cases when tenant shifts houses
-	if prev_house_id != args.HouseID {
+	args.Active = false
 
-		// old house tenant is moving from
-		house, err := qtx.GetHouseById(ctx, prev_house_id)
+	_, err = audit(ctx, qtx, entry, func() (uuid.UUID, error) {
+
+		n, err := qtx.UpdateTenant(ctx, args)
 
 		if err != nil {
-			return err
+			return uuid.Nil, err
 		}
 
-		hargs := UpdateHouseByIdParams{
-			Occupied:  false,
-			ID:        prev_house_id,
-			Location:  house.Location,
-			Block:     house.Block,
-			Partition: house.Partition,
-			Version:   house.Version,
+		if n == 0 {
+			return uuid.Nil, ErrEditConflict
 		}
 
-		err = qtx.UpdateHouseById(ctx, hargs)
+		return uuid.Nil, qtx.SetHouseOccupied(ctx, SetHouseOccupiedParams{
+			Occupied:       false,
+			ID:             args.HouseID,
+			OrganizationID: args.OrganizationID,
+		})
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+
+}
+
+type InspectionItemEntry struct {
+	Room      string
+	Item      string
+	Condition int16
+	Notes     string
+	Photos    []string
+}
+
+func (store *SQLStore) TxnCreateInspection(ctx context.Context, args CreateInspectionParams, items []InspectionItemEntry, photos []string) (uuid.UUID, error) {
 
+	tx, err := store.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	defer tx.Rollback()
+
+	qtx := New(tx)
+
+	id, err := qtx.CreateInspection(ctx, args)
+
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	for _, url := range photos {
+		err = qtx.CreateInspectionPhoto(ctx, CreateInspectionPhotoParams{InspectionID: id, Url: url})
 		if err != nil {
-			return err
+			return uuid.Nil, err
 		}
+	}
 
-		// new house tenant is moving to
+	for _, item := range items {
 
-		nh, err := qtx.GetHouseById(ctx, args.HouseID)
+		itemID, err := qtx.CreateInspectionItem(ctx, CreateInspectionItemParams{
+			InspectionID: id,
+			Room:         item.Room,
+			Item:         item.Item,
+			Condition:    item.Condition,
+			Notes:        item.Notes,
+		})
 
 		if err != nil {
-			return err
+			return uuid.Nil, err
 		}
 
-		nhargs := UpdateHouseByIdParams{
-			Occupied:  true,
-			ID:        args.HouseID,
-			Location:  nh.Location,
-			Block:     nh.Block,
-			Partition: nh.Partition,
-			Version:   nh.Version,
+		for _, url := range item.Photos {
+			err = qtx.CreateInspectionPhoto(ctx, CreateInspectionPhotoParams{
+				InspectionID: id,
+				ItemID:       uuid.NullUUID{UUID: itemID, Valid: true},
+				Url:          url,
+			})
+			if err != nil {
+				return uuid.Nil, err
+			}
 		}
+	}
 
-		err = qtx.UpdateHouseById(ctx, nhargs)
+	return id, tx.Commit()
+}
 
-		if err != nil {
-			return err
+var ErrEditConflict = errors.New("edit conflict")
+
+var ErrInviteUsed = errors.New("invite has already been used or revoked")
+
+// TxnAcceptInvite creates the admin, makes them a member of the organization
+// that invited them and marks the invite accepted together so an invite can
+// never be redeemed twice. entry builds the audit entry for the new admin.
+func (store *SQLStore) TxnAcceptInvite(ctx context.Context, inviteID uuid.UUID, args CreateAdminParams, entry func(admin CreateAdminRow) AuditEntry) (CreateAdminRow, error) {
+
+	tx, err := store.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return CreateAdminRow{}, err
+	}
+
+	defer tx.Rollback()
+
+	qtx := New(tx)
+
+	organizationID, err := qtx.AcceptInvite(ctx, inviteID)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return CreateAdminRow{}, ErrInviteUsed
 		}
+		return CreateAdminRow{}, err
+	}
+
+	admin, err := qtx.CreateAdmin(ctx, args)
+
+	if err != nil {
+		return CreateAdminRow{}, err
+	}
+
+	err = qtx.AddOrganizationMember(ctx, AddOrganizationMemberParams{
+		AdminID:        admin.ID,
+		OrganizationID: organizationID,
+	})
+
+	if err != nil {
+		return CreateAdminRow{}, err
+	}
+
+	_, err = audit(ctx, qtx, entry(admin), func() (uuid.UUID, error) {
+		return admin.ID, nil
+	})
+
+	if err != nil {
+		return CreateAdminRow{}, err
+	}
+
+	return admin, tx.Commit()
+}
+
+// TxnCreateSuperUser creates a super user and makes them a member of every
+// organization, so that one set up on a new database can start inviting
+// admins to it straight away. entry builds the audit entry for the new admin.
+func (store *SQLStore) TxnCreateSuperUser(ctx context.Context, args CreateSuperUserParams, entry func(admin CreateSuperUserRow) AuditEntry) (CreateSuperUserRow, error) {
+
+	tx, err := store.db.BeginTx(ctx, nil)
 
+	if err != nil {
+		return CreateSuperUserRow{}, err
 	}
 
-	err = qtx.UpdateTenant(ctx, args)
+	defer tx.Rollback()
+
+	qtx := New(tx)
+
+	admin, err := qtx.CreateSuperUser(ctx, args)
+
+	if err != nil {
+		return CreateSuperUserRow{}, err
+	}
+
+	if err = qtx.AddMemberOfAllOrganizations(ctx, admin.ID); err != nil {
+		return CreateSuperUserRow{}, err
+	}
+
+	_, err = audit(ctx, qtx, entry(admin), func() (uuid.UUID, error) {
+		return admin.ID, nil
+	})
+
+	if err != nil {
+		return CreateSuperUserRow{}, err
+	}
+
+	return admin, tx.Commit()
+}
+
+var ErrTotpNotPending = errors.New("no pending two-factor enrolment")
+
+// TxnConfirmTotp activates a pending enrolment and stores its first set of
+// recovery codes.
+func (store *SQLStore) TxnConfirmTotp(ctx context.Context, adminID uuid.UUID, step int64, recoveryHashes [][]byte) error {
+
+	tx, err := store.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	qtx := New(tx)
+
+	n, err := qtx.ConfirmAdminTotp(ctx, ConfirmAdminTotpParams{
+		AdminID:  adminID,
+		LastStep: step,
+	})
 
 	if err != nil {
 		return err
 	}
 
+	if n == 0 {
+		return ErrTotpNotPending
+	}
+
+	if err = replaceRecoveryCodes(ctx, qtx, adminID, recoveryHashes); err != nil {
+		return err
+	}
+
 	return tx.Commit()
+}
+
+func (store *SQLStore) TxnReplaceRecoveryCodes(ctx context.Context, adminID uuid.UUID, recoveryHashes [][]byte) error {
+
+	tx, err := store.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	if err = replaceRecoveryCodes(ctx, New(tx), adminID, recoveryHashes); err != nil {
+		return err
+	}
 
+	return tx.Commit()
 }
 
-func (store *SQLStore) TxnRemoveTenantHouse(ctx context.Context, args UpdateTenantParams) error {
+func (store *SQLStore) TxnDisableTotp(ctx context.Context, adminID uuid.UUID) error {
 
-	tx, err := store.db.Begin()
+	tx, err := store.db.BeginTx(ctx, nil)
 
 	if err != nil {
 		return err
@@ -188,35 +444,145 @@ func (store *SQLStore) TxnRemoveTenantHouse(ctx context.Context, args UpdateTena
 
 	qtx := New(tx)
 
-	args.Active = false
+	if err = qtx.DeleteAdminTotp(ctx, adminID); err != nil {
+		return err
+	}
+
+	if err = qtx.DeleteRecoveryCodes(ctx, adminID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
 
-	err = qtx.UpdateTenant(ctx, args)
+func replaceRecoveryCodes(ctx context.Context, q *Queries, adminID uuid.UUID, hashes [][]byte) error {
+
+	if err := q.DeleteRecoveryCodes(ctx, adminID); err != nil {
+		return err
+	}
+
+	for _, hash := range hashes {
+		err := q.CreateRecoveryCode(ctx, CreateRecoveryCodeParams{
+			AdminID: adminID,
+			Hash:    hash,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// TxnChangePassword sets a new password, remembers the one it replaces, signs
+// the admin out everywhere except keepSession and queues messages. Outstanding
+// password reset links die with the old password. It returns sql.ErrNoRows on
+// an edit conflict.
+func (store *SQLStore) TxnChangePassword(ctx context.Context, args UpdateAdminParams, previousHash []byte, keepSession uuid.NullUUID, entry AuditEntry, messages ...OutboxMessage) error {
+
+	tx, err := store.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	qtx := New(tx)
+
+	_, err = audit(ctx, qtx, entry, func() (uuid.UUID, error) {
+		_, err := qtx.UpdateAdmin(ctx, args)
+		return uuid.Nil, err
+	})
 
 	if err != nil {
 		return err
 	}
 
-	house, err := qtx.GetHouseById(ctx, args.HouseID)
+	err = qtx.CreatePasswordHistory(ctx, CreatePasswordHistoryParams{
+		AdminID:      args.ID,
+		PasswordHash: previousHash,
+	})
 
 	if err != nil {
 		return err
 	}
 
-	hargs := UpdateHouseByIdParams{
-		Occupied:  false,
-		ID:        args.HouseID,
-		Location:  house.Location,
-		Block:     house.Block,
-		Partition: house.Partition,
-		Version:   house.Version,
+	err = qtx.DeleteOtherSessions(ctx, DeleteOtherSessionsParams{
+		AdminID: args.ID,
+		Keep:    keepSession,
+	})
+
+	if err != nil {
+		return err
 	}
 
-	err = qtx.UpdateHouseById(ctx, hargs)
+	err = qtx.DeleteSessionlessTokens(ctx, DeleteSessionlessTokensParams{
+		ID:     args.ID,
+		Scopes: []string{ScopeAuthentication, ScopeRefresh, ScopePasswordReset},
+	})
 
 	if err != nil {
 		return err
 	}
 
+	if err = enqueue(ctx, qtx, messages...); err != nil {
+		return err
+	}
+
 	return tx.Commit()
+}
+
+// TxnCreatePayment records a payment and queues messages that go with it,
+// such as the tenant's receipt.
+func (store *SQLStore) TxnCreatePayment(ctx context.Context, args CreatePaymentParams, entry AuditEntry, messages ...OutboxMessage) (uuid.UUID, error) {
+
+	tx, err := store.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	defer tx.Rollback()
+
+	qtx := New(tx)
+
+	id, err := audit(ctx, qtx, entry, func() (uuid.UUID, error) {
+		return qtx.CreatePayment(ctx, args)
+	})
+
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	if err = enqueue(ctx, qtx, messages...); err != nil {
+		return uuid.Nil, err
+	}
+
+	return id, tx.Commit()
+}
+
+// TxnSetNotificationPreference saves a tenant's notification preference
+// together with the consent record of the change.
+func (store *SQLStore) TxnSetNotificationPreference(ctx context.Context, args UpsertNotificationPreferenceParams, consent CreateNotificationConsentParams) error {
 
+	tx, err := store.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	qtx := New(tx)
+
+	if err = qtx.UpsertNotificationPreference(ctx, args); err != nil {
+		return err
+	}
+
+	if err = qtx.CreateNotificationConsent(ctx, consent); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
@@ -0,0 +1,221 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func createTestHouse(t *testing.T, store *SQLStore, orgID uuid.UUID, partition int16) uuid.UUID {
+
+	id, err := store.CreateHouse(context.Background(), CreateHouseParams{
+		Location:       "Sinza",
+		Block:          "A",
+		Partition:      partition,
+		Price:          100000,
+		UnitType:       "room",
+		Photos:         []string{},
+		OrganizationID: orgID,
+	})
+
+	if err != nil {
+		t.Fatalf("CreateHouse: %v", err)
+	}
+
+	return id
+}
+
+func testTenant(orgID, houseID uuid.UUID, name string) CreateTenantParams {
+
+	sos := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	return CreateTenantParams{
+		Name:              name,
+		HouseID:           houseID,
+		Phone:             "0712345678",
+		Active:            true,
+		Sos:               sos,
+		Eos:               sos.AddDate(1, 0, 0),
+		OrganizationID:    orgID,
+		PreferredLanguage: "en",
+	}
+}
+
+func testEntry(orgID uuid.UUID) AuditEntry {
+	return AuditEntry{
+		OrganizationID: uuid.NullUUID{UUID: orgID, Valid: true},
+		Action:         "create",
+		EntityType:     "tenant",
+	}
+}
+
+func TestTxnCreateTenantRejectsOccupiedHouse(t *testing.T) {
+
+	store, orgID := newTestStore(t)
+	ctx := context.Background()
+	houseID := createTestHouse(t, store, orgID, 1)
+
+	if _, err := store.TxnCreateTenant(ctx, testTenant(orgID, houseID, "first"), testEntry(orgID)); err != nil {
+		t.Fatalf("TxnCreateTenant: %v", err)
+	}
+
+	_, err := store.TxnCreateTenant(ctx, testTenant(orgID, houseID, "second"), testEntry(orgID))
+
+	if !errors.Is(err, ErrHouseOccupied) {
+		t.Errorf("second TxnCreateTenant error = %v, want ErrHouseOccupied", err)
+	}
+}
+
+func TestTxnCreateTenantRejectsArchivedHouse(t *testing.T) {
+
+	store, orgID := newTestStore(t)
+	ctx := context.Background()
+	houseID := createTestHouse(t, store, orgID, 1)
+
+	if _, err := store.db.Exec(`UPDATE house SET archived = true WHERE id = $1`, houseID); err != nil {
+		t.Fatalf("archiving house: %v", err)
+	}
+
+	_, err := store.TxnCreateTenant(ctx, testTenant(orgID, houseID, "first"), testEntry(orgID))
+
+	if !errors.Is(err, ErrHouseArchived) {
+		t.Errorf("TxnCreateTenant error = %v, want ErrHouseArchived", err)
+	}
+}
+
+func TestTxnCreateTenantConcurrent(t *testing.T) {
+
+	store, orgID := newTestStore(t)
+	ctx := context.Background()
+	houseID := createTestHouse(t, store, orgID, 1)
+
+	const n = 8
+
+	var wg sync.WaitGroup
+	errs := make([]error, n)
+
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = store.TxnCreateTenant(ctx, testTenant(orgID, houseID, fmt.Sprint("tenant ", i)), testEntry(orgID))
+		}()
+	}
+
+	wg.Wait()
+
+	created := 0
+
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			created++
+		case !errors.Is(err, ErrHouseOccupied):
+			t.Errorf("TxnCreateTenant error = %v, want nil or ErrHouseOccupied", err)
+		}
+	}
+
+	if created != 1 {
+		t.Errorf("tenants created = %d, want 1", created)
+	}
+}
+
+func TestTxnUpdateTenantHouseRejectsOccupiedHouse(t *testing.T) {
+
+	store, orgID := newTestStore(t)
+	ctx := context.Background()
+	from := createTestHouse(t, store, orgID, 1)
+	to := createTestHouse(t, store, orgID, 2)
+
+	tenantID, err := store.TxnCreateTenant(ctx, testTenant(orgID, from, "mover"), testEntry(orgID))
+	if err != nil {
+		t.Fatalf("TxnCreateTenant: %v", err)
+	}
+
+	if _, err := store.TxnCreateTenant(ctx, testTenant(orgID, to, "resident"), testEntry(orgID)); err != nil {
+		t.Fatalf("TxnCreateTenant: %v", err)
+	}
+
+	tenant, err := store.GetTenantById(ctx, GetTenantByIdParams{ID: tenantID, OrganizationID: orgID})
+	if err != nil {
+		t.Fatalf("GetTenantById: %v", err)
+	}
+
+	err = store.TxnUpdateTenantHouse(ctx, UpdateTenantParams{
+		Name:              tenant.Name,
+		HouseID:           to,
+		Phone:             tenant.Phone,
+		Active:            tenant.Active,
+		Sos:               tenant.Sos,
+		Eos:               tenant.Eos,
+		ID:                tenant.TenantID,
+		Version:           tenant.Version,
+		OrganizationID:    orgID,
+		PreferredLanguage: tenant.PreferredLanguage,
+	}, from, testEntry(orgID))
+
+	if !errors.Is(err, ErrHouseOccupied) {
+		t.Fatalf("TxnUpdateTenantHouse error = %v, want ErrHouseOccupied", err)
+	}
+
+	// the move was rolled back, so the tenant's house is still let
+	house, err := store.GetHouseById(ctx, GetHouseByIdParams{ID: from, OrganizationID: orgID})
+	if err != nil {
+		t.Fatalf("GetHouseById: %v", err)
+	}
+
+	if !house.Occupied {
+		t.Error("house moved from is vacant, want it still occupied")
+	}
+}
+
+func TestTxnUpsertHousesLeavesGuardedHouses(t *testing.T) {
+
+	store, orgID := newTestStore(t)
+	ctx := context.Background()
+	let := createTestHouse(t, store, orgID, 1)
+	archived := createTestHouse(t, store, orgID, 2)
+
+	if _, err := store.TxnCreateTenant(ctx, testTenant(orgID, let, "resident"), testEntry(orgID)); err != nil {
+		t.Fatalf("TxnCreateTenant: %v", err)
+	}
+
+	if _, err := store.db.Exec(`UPDATE house SET archived = true WHERE id = $1`, archived); err != nil {
+		t.Fatalf("archiving house: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		house UpsertHouseParams
+	}{
+		{"vacating a let house", UpsertHouseParams{Partition: 1, Occupied: sql.NullBool{Bool: false, Valid: true}}},
+		{"changing an archived house", UpsertHouseParams{Partition: 2}},
+	}
+
+	for _, tt := range tests {
+
+		house := tt.house
+		house.Location, house.Block, house.Price, house.OrganizationID = "Sinza", "A", 200000, orgID
+
+		_, err := store.TxnUpsertHouses(ctx, []UpsertHouseParams{house}, AuditEntry{Action: "import", EntityType: "house"})
+
+		if !errors.Is(err, ErrEditConflict) {
+			t.Errorf("%s: TxnUpsertHouses error = %v, want ErrEditConflict", tt.name, err)
+		}
+	}
+
+	house, err := store.GetHouseById(ctx, GetHouseByIdParams{ID: let, OrganizationID: orgID})
+	if err != nil {
+		t.Fatalf("GetHouseById: %v", err)
+	}
+
+	if !house.Occupied || house.Price != 100000 {
+		t.Errorf("let house = occupied %t, price %d, want it unchanged", house.Occupied, house.Price)
+	}
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
+	"github.com/lib/pq"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -17,12 +18,36 @@ const (
 	ScopeActivation     = "activation"
 	ScopeAuthentication = "authentication"
 	ScopePasswordReset  = "password-reset"
+	ScopeRefresh        = "refresh"
+	ScopeInvite         = "invite"
+	ScopeMFA            = "mfa"
+)
+
+const (
+	RoleOwner      = "owner"
+	RoleManager    = "manager"
+	RoleAccountant = "accountant"
+	RoleCaretaker  = "caretaker"
 )
 
 var (
 	DuplicateEmail = `pq: duplicate key value violates unique constraint "admin_email_key"`
 )
 
+// IsForeignKeyViolation reports whether err is a postgres foreign key
+// violation, e.g. deleting a row that is still referenced.
+func IsForeignKeyViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23503"
+}
+
+// IsUniqueViolation reports whether err is a postgres unique constraint
+// violation.
+func IsUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
 type Password struct {
 	Plaintext string
 	Hash      []byte
@@ -34,6 +59,7 @@ type TokenLoc struct {
 	AdminID   uuid.UUID `json:"-"`
 	Expiry    time.Time `json:"expiry"`
 	Scope     string    `json:"-"`
+	SessionID uuid.UUID `json:"-"`
 }
 
 func generateToken(id uuid.UUID, expiry time.Time, scope string) (*TokenLoc, error) {
@@ -91,6 +117,44 @@ func (s *SQLStore) NewToken(id uuid.UUID, expiry time.Time, scope string) (*Toke
 	return token, err
 }
 
+// NewInvite stores the hash of a fresh single-use token for email to join
+// organizationID and queues the message built from its plaintext, which is how
+// the invitee receives it.
+func (s *SQLStore) NewInvite(ctx context.Context, email, role string, invitedBy, organizationID uuid.UUID, expiry time.Time, message func(plaintext string) OutboxMessage) error {
+	token, err := generateToken(invitedBy, expiry, ScopeInvite)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	qtx := New(tx)
+
+	_, err = qtx.CreateInvite(ctx, CreateInviteParams{
+		Email:          email,
+		Role:           role,
+		Hash:           token.Hash,
+		InvitedBy:      invitedBy,
+		Expiry:         token.Expiry,
+		OrganizationID: organizationID,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if err = enqueue(ctx, qtx, message(token.Plaintext)); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
 func ReadUUIDParam(c echo.Context) (uuid.UUID, error) {
 
 	id := c.Param("uuid")
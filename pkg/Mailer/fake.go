@@ -0,0 +1,79 @@
+package mailer
+
+import (
+	"context"
+	"sync"
+)
+
+// Message is a notification recorded by Fake.
+type Message struct {
+	// Path is the mail service endpoint the message would have gone to.
+	Path string
+	To   string
+	Data any
+}
+
+// Fake is an in-memory Sender for tests. It records every message and sends
+// nothing. The zero value is ready to use.
+type Fake struct {
+	mu       sync.Mutex
+	messages []Message
+
+	// Err, when set, is returned by every method; the message is still
+	// recorded.
+	Err error
+}
+
+var _ Sender = (*Fake)(nil)
+
+func (f *Fake) Activation(ctx context.Context, data ActivationData) error {
+	return f.record(PathActivation, data.Email, data)
+}
+
+func (f *Fake) PasswordReset(ctx context.Context, data PasswordResetData) error {
+	return f.record(PathPasswordReset, data.Email, data)
+}
+
+func (f *Fake) PasswordResetComplete(ctx context.Context, data PasswordResetCompleteData) error {
+	return f.record(PathPasswordResetComplete, data.Email, data)
+}
+
+func (f *Fake) Invite(ctx context.Context, data InviteData) error {
+	return f.record(PathInvite, data.Email, data)
+}
+
+func (f *Fake) Lockout(ctx context.Context, data LockoutData) error {
+	return f.record(PathLockout, data.Email, data)
+}
+
+func (f *Fake) Notice(ctx context.Context, data NoticeData) error {
+	return f.record(PathNotice, data.Email, data)
+}
+
+// Messages returns the messages recorded so far, oldest first.
+func (f *Fake) Messages() []Message {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]Message(nil), f.messages...)
+}
+
+// Reset forgets every recorded message.
+func (f *Fake) Reset() {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.messages = nil
+}
+
+func (f *Fake) record(path, to string, data any) error {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.messages = append(f.messages, Message{Path: path, To: to, Data: data})
+
+	return f.Err
+}
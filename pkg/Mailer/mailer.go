@@ -1 +1,292 @@
-package mailer
\ No newline at end of file
+// Package mailer is the client for the mail service, which renders and
+// delivers notification emails. Every message type has its own method that
+// posts its data as JSON to the service.
+//
+// Failed deliveries are retried with exponential backoff while the error looks
+// transient: the service could not be reached, timed out, or answered 429 or
+// 5xx. Any other answer is final.
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Sender sends each kind of notification email. *Client sends them through the
+// mail service; *Fake records them.
+type Sender interface {
+	Activation(ctx context.Context, data ActivationData) error
+	PasswordReset(ctx context.Context, data PasswordResetData) error
+	PasswordResetComplete(ctx context.Context, data PasswordResetCompleteData) error
+	Invite(ctx context.Context, data InviteData) error
+	Lockout(ctx context.Context, data LockoutData) error
+	Notice(ctx context.Context, data NoticeData) error
+}
+
+// Content is the message rendered in the recipient's language. The service
+// sends it as it is when set, and renders its own otherwise.
+type Content struct {
+	Locale  string `json:"locale,omitempty"`
+	Subject string `json:"subject,omitempty"`
+	Text    string `json:"text,omitempty"`
+	HTML    string `json:"html,omitempty"`
+}
+
+type ActivationData struct {
+	Email string `json:"email"`
+	Token string `json:"token"`
+	Content
+}
+
+type PasswordResetData struct {
+	Email string `json:"email"`
+	Token string `json:"token"`
+	Content
+}
+
+type PasswordResetCompleteData struct {
+	Email string `json:"email"`
+	Content
+}
+
+type InviteData struct {
+	Email  string `json:"email"`
+	Role   string `json:"role"`
+	Token  string `json:"token"`
+	Expiry string `json:"expiry"`
+	Content
+}
+
+type LockoutData struct {
+	Email       string `json:"email"`
+	IP          string `json:"ip"`
+	LockedUntil string `json:"locked_until"`
+	Content
+}
+
+// NoticeData is any other message, sent as its Content says.
+type NoticeData struct {
+	Email string `json:"email"`
+	Content
+}
+
+// Paths of the mail service endpoints, one per message type.
+const (
+	PathActivation            = "activate"
+	PathPasswordReset         = "resetpwd"
+	PathPasswordResetComplete = "completedpwdreset"
+	PathInvite                = "invite"
+	PathLockout               = "lockout"
+	PathNotice                = "notice"
+)
+
+// Error is returned when a message could not be delivered to the mail service.
+type Error struct {
+	Path     string
+	Attempts int
+
+	// StatusCode and Body are those of the last response, zero when the last
+	// attempt got none.
+	StatusCode int
+	Body       string
+
+	// Err is the transport error of the last attempt, if any.
+	Err error
+}
+
+func (e *Error) Error() string {
+
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "mailer: %s failed after %d attempt(s)", e.Path, e.Attempts)
+
+	if e.StatusCode != 0 {
+		fmt.Fprintf(&b, ": status %d", e.StatusCode)
+		if e.Body != "" {
+			fmt.Fprintf(&b, ": %s", e.Body)
+		}
+	}
+
+	if e.Err != nil {
+		fmt.Fprintf(&b, ": %v", e.Err)
+	}
+
+	return b.String()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Temporary reports whether sending again later might succeed.
+func (e *Error) Temporary() bool {
+	return e.StatusCode == 0 || retryableStatus(e.StatusCode)
+}
+
+type Config struct {
+	// BaseURL is the mail service, without a trailing slash.
+	BaseURL string
+
+	// Timeout bounds a single attempt. Defaults to 10 seconds.
+	Timeout time.Duration
+
+	// MaxAttempts is how many times a message is tried. Defaults to 4.
+	MaxAttempts int
+
+	// Backoff is the wait before the first retry; it doubles with each one
+	// after. Defaults to half a second.
+	Backoff time.Duration
+}
+
+type Client struct {
+	baseURL     string
+	http        *http.Client
+	maxAttempts int
+	backoff     time.Duration
+}
+
+var _ Sender = (*Client)(nil)
+
+func New(cfg Config) *Client {
+
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 4
+	}
+
+	if cfg.Backoff <= 0 {
+		cfg.Backoff = 500 * time.Millisecond
+	}
+
+	return &Client{
+		baseURL:     strings.TrimRight(cfg.BaseURL, "/"),
+		http:        &http.Client{Timeout: cfg.Timeout},
+		maxAttempts: cfg.MaxAttempts,
+		backoff:     cfg.Backoff,
+	}
+}
+
+func (m *Client) Activation(ctx context.Context, data ActivationData) error {
+	return m.send(ctx, PathActivation, data)
+}
+
+func (m *Client) PasswordReset(ctx context.Context, data PasswordResetData) error {
+	return m.send(ctx, PathPasswordReset, data)
+}
+
+func (m *Client) PasswordResetComplete(ctx context.Context, data PasswordResetCompleteData) error {
+	return m.send(ctx, PathPasswordResetComplete, data)
+}
+
+func (m *Client) Invite(ctx context.Context, data InviteData) error {
+	return m.send(ctx, PathInvite, data)
+}
+
+func (m *Client) Lockout(ctx context.Context, data LockoutData) error {
+	return m.send(ctx, PathLockout, data)
+}
+
+func (m *Client) Notice(ctx context.Context, data NoticeData) error {
+	return m.send(ctx, PathNotice, data)
+}
+
+// maxErrorBody is how much of an error response is kept in Error.
+const maxErrorBody = 512
+
+func (m *Client) send(ctx context.Context, path string, data any) error {
+
+	body, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("mailer: encoding %s: %w", path, err)
+	}
+
+	url := m.baseURL + "/" + path
+
+	var last *Error
+
+	for attempt := 1; attempt <= m.maxAttempts; attempt++ {
+
+		if attempt > 1 {
+			if err := sleep(ctx, m.delay(attempt-1)); err != nil {
+				last.Err = errors.Join(last.Err, err)
+				return last
+			}
+		}
+
+		last = &Error{Path: path, Attempts: attempt}
+
+		last.StatusCode, last.Body, last.Err = m.post(ctx, url, body)
+
+		if last.Err == nil && last.StatusCode >= 200 && last.StatusCode < 300 {
+			return nil
+		}
+
+		if ctx.Err() != nil || !last.Temporary() {
+			return last
+		}
+	}
+
+	return last
+}
+
+func (m *Client) post(ctx context.Context, url string, body []byte) (int, string, error) {
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := m.http.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		io.Copy(io.Discard, resp.Body)
+		return resp.StatusCode, "", nil
+	}
+
+	msg, err := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+
+	return resp.StatusCode, strings.TrimSpace(string(msg)), err
+}
+
+// delay returns the wait before retry n, counting from 1, with up to a
+// quarter of it added at random so clients that failed together do not retry
+// together.
+func (m *Client) delay(n int) time.Duration {
+	d := m.backoff << (n - 1)
+	return d + rand.N(d/4+1)
+}
+
+func retryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code >= 500
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
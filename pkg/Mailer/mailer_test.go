@@ -0,0 +1,275 @@
+package mailer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testBackoff keeps the retries of a test short while still long enough to
+// tell from the time a request takes.
+const testBackoff = 20 * time.Millisecond
+
+// recorder answers each request to the mail service with the next of its
+// statuses, repeating the last once they run out, and keeps the time each
+// request came in.
+type recorder struct {
+	mu       sync.Mutex
+	statuses []int
+	body     string
+	times    []time.Time
+	requests []*http.Request
+	payloads []map[string]any
+}
+
+func (rec *recorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	var payload map[string]any
+	json.NewDecoder(r.Body).Decode(&payload)
+
+	rec.mu.Lock()
+	n := len(rec.times)
+	rec.times = append(rec.times, time.Now())
+	rec.requests = append(rec.requests, r)
+	rec.payloads = append(rec.payloads, payload)
+	status := rec.statuses[min(n, len(rec.statuses)-1)]
+	rec.mu.Unlock()
+
+	w.WriteHeader(status)
+	w.Write([]byte(rec.body))
+}
+
+func (rec *recorder) count() int {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return len(rec.times)
+}
+
+func newTestClient(t *testing.T, rec *recorder) *Client {
+
+	srv := httptest.NewServer(rec)
+	t.Cleanup(srv.Close)
+
+	return New(Config{
+		BaseURL:     srv.URL + "/",
+		Timeout:     time.Second,
+		MaxAttempts: 4,
+		Backoff:     testBackoff,
+	})
+}
+
+func TestSendPostsJSON(t *testing.T) {
+
+	rec := &recorder{statuses: []int{http.StatusOK}}
+	m := newTestClient(t, rec)
+
+	err := m.Invite(context.Background(), InviteData{Email: "a@example.com", Role: "manager", Token: "tok"})
+
+	if err != nil {
+		t.Fatalf("Invite: %v", err)
+	}
+
+	if rec.count() != 1 {
+		t.Fatalf("requests = %d, want 1", rec.count())
+	}
+
+	r := rec.requests[0]
+
+	if r.Method != http.MethodPost || r.URL.Path != "/"+PathInvite {
+		t.Errorf("request = %s %s, want POST /%s", r.Method, r.URL.Path, PathInvite)
+	}
+
+	if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+
+	if p := rec.payloads[0]; p["email"] != "a@example.com" || p["role"] != "manager" || p["token"] != "tok" {
+		t.Errorf("payload = %v", p)
+	}
+}
+
+func TestSendRetriesWithBackoff(t *testing.T) {
+
+	rec := &recorder{statuses: []int{http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusOK}}
+	m := newTestClient(t, rec)
+
+	if err := m.Notice(context.Background(), NoticeData{Email: "a@example.com"}); err != nil {
+		t.Fatalf("Notice: %v", err)
+	}
+
+	if rec.count() != 3 {
+		t.Fatalf("requests = %d, want 3", rec.count())
+	}
+
+	// the wait doubles with each retry
+	for i, want := range []time.Duration{testBackoff, 2 * testBackoff} {
+		if got := rec.times[i+1].Sub(rec.times[i]); got < want {
+			t.Errorf("wait before retry %d = %v, want at least %v", i+1, got, want)
+		}
+	}
+}
+
+func TestSendGivesUp(t *testing.T) {
+
+	rec := &recorder{statuses: []int{http.StatusBadGateway}, body: " upstream down \n"}
+	m := newTestClient(t, rec)
+
+	err := m.Notice(context.Background(), NoticeData{Email: "a@example.com"})
+
+	var mailErr *Error
+	if !errors.As(err, &mailErr) {
+		t.Fatalf("error = %v, want *Error", err)
+	}
+
+	if rec.count() != 4 || mailErr.Attempts != 4 {
+		t.Errorf("requests = %d, attempts = %d, want 4", rec.count(), mailErr.Attempts)
+	}
+
+	if mailErr.Path != PathNotice || mailErr.StatusCode != http.StatusBadGateway || mailErr.Body != "upstream down" {
+		t.Errorf("error = %+v", mailErr)
+	}
+
+	if !mailErr.Temporary() {
+		t.Error("Temporary() = false, want true")
+	}
+}
+
+func TestSendDoesNotRetryFinalStatus(t *testing.T) {
+
+	for _, status := range []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusUnprocessableEntity} {
+
+		rec := &recorder{statuses: []int{status, http.StatusOK}, body: "rejected"}
+		m := newTestClient(t, rec)
+
+		err := m.Activation(context.Background(), ActivationData{Email: "a@example.com"})
+
+		var mailErr *Error
+		if !errors.As(err, &mailErr) {
+			t.Fatalf("status %d: error = %v, want *Error", status, err)
+		}
+
+		if rec.count() != 1 || mailErr.Attempts != 1 {
+			t.Errorf("status %d: requests = %d, attempts = %d, want 1", status, rec.count(), mailErr.Attempts)
+		}
+
+		if mailErr.StatusCode != status || mailErr.Body != "rejected" || mailErr.Temporary() {
+			t.Errorf("status %d: error = %+v, temporary %t", status, mailErr, mailErr.Temporary())
+		}
+	}
+}
+
+func TestSendRetriesUnreachableService(t *testing.T) {
+
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+
+	m := New(Config{BaseURL: srv.URL, MaxAttempts: 2, Backoff: testBackoff})
+
+	err := m.Notice(context.Background(), NoticeData{Email: "a@example.com"})
+
+	var mailErr *Error
+	if !errors.As(err, &mailErr) {
+		t.Fatalf("error = %v, want *Error", err)
+	}
+
+	if mailErr.Attempts != 2 || mailErr.StatusCode != 0 || mailErr.Err == nil || !mailErr.Temporary() {
+		t.Errorf("error = %+v, want 2 attempts with a transport error", mailErr)
+	}
+}
+
+func TestSendStopsWhenCancelledDuringBackoff(t *testing.T) {
+
+	rec := &recorder{statuses: []int{http.StatusServiceUnavailable}}
+	srv := httptest.NewServer(rec)
+	t.Cleanup(srv.Close)
+
+	m := New(Config{BaseURL: srv.URL, MaxAttempts: 4, Backoff: time.Hour})
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	go func() {
+		for rec.count() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		cancel()
+	}()
+
+	start := time.Now()
+	err := m.Notice(ctx, NoticeData{Email: "a@example.com"})
+
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("Notice returned after %v, want it to stop once cancelled", elapsed)
+	}
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("error = %v, want context.Canceled", err)
+	}
+
+	var mailErr *Error
+	if !errors.As(err, &mailErr) || mailErr.Attempts != 1 || mailErr.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("error = %+v, want the one attempt made", mailErr)
+	}
+
+	if rec.count() != 1 {
+		t.Errorf("requests = %d, want 1", rec.count())
+	}
+}
+
+func TestSendStopsWhenCancelledDuringRequest(t *testing.T) {
+
+	release := make(chan struct{})
+	defer close(release)
+
+	var requests int
+	var mu sync.Mutex
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		mu.Unlock()
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	m := New(Config{BaseURL: srv.URL, Timeout: time.Minute, MaxAttempts: 4, Backoff: testBackoff})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := m.Notice(ctx, NoticeData{Email: "a@example.com"})
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("error = %v, want context.DeadlineExceeded", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if requests != 1 {
+		t.Errorf("requests = %d, want 1", requests)
+	}
+}
+
+func TestDelay(t *testing.T) {
+
+	m := New(Config{Backoff: time.Second})
+
+	for n := 1; n <= 4; n++ {
+
+		base := time.Second << (n - 1)
+
+		for range 100 {
+			if d := m.delay(n); d < base || d > base+base/4 {
+				t.Fatalf("delay(%d) = %v, want between %v and %v", n, d, base, base+base/4)
+			}
+		}
+	}
+}
@@ -0,0 +1,98 @@
+// Package password checks new passwords against a policy: a length range and
+// a list of passwords common enough to be among the first an attacker tries,
+// and against the passwords an admin used before.
+//
+// The list is bundled with the binary so checks never leave the process.
+package password
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// MaxLength is the longest password in bytes bcrypt will hash.
+const MaxLength = 72
+
+var (
+	ErrTooShort = errors.New("password is too short")
+	ErrTooLong  = errors.New("password is too long")
+	ErrCommon   = errors.New("password is too common")
+)
+
+//go:embed common.txt
+var commonList string
+
+var common = parseList(commonList)
+
+func parseList(list string) map[string]struct{} {
+
+	set := make(map[string]struct{})
+
+	for _, line := range strings.Split(list, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		set[line] = struct{}{}
+	}
+
+	return set
+}
+
+// Policy is what a new password has to satisfy.
+type Policy struct {
+	// MinLength is counted in characters, not bytes.
+	MinLength int
+}
+
+// Check returns nil if plaintext satisfies the policy, otherwise an error
+// wrapping ErrTooShort, ErrTooLong or ErrCommon whose message can be shown to
+// the user.
+func (p Policy) Check(plaintext string) error {
+
+	if n := utf8.RuneCountInString(plaintext); n < p.MinLength {
+		return fmt.Errorf("%w: use at least %d characters", ErrTooShort, p.MinLength)
+	}
+
+	if len(plaintext) > MaxLength {
+		return fmt.Errorf("%w: use at most %d bytes", ErrTooLong, MaxLength)
+	}
+
+	if IsCommon(plaintext) {
+		return fmt.Errorf("%w: it appears in lists of leaked passwords", ErrCommon)
+	}
+
+	return nil
+}
+
+// IsCommon reports whether plaintext, ignoring case and surrounding spaces, is
+// on the bundled list.
+func IsCommon(plaintext string) bool {
+	_, ok := common[strings.ToLower(strings.TrimSpace(plaintext))]
+	return ok
+}
+
+// Reused reports whether plaintext is the password one of the bcrypt hashes in
+// history was made from. Unlike IsCommon it is exact: a password differing
+// only in case is a different password.
+func Reused(plaintext string, history [][]byte) (bool, error) {
+
+	for _, hash := range history {
+
+		err := bcrypt.CompareHashAndPassword(hash, []byte(plaintext))
+
+		switch {
+		case err == nil:
+			return true, nil
+		case !errors.Is(err, bcrypt.ErrMismatchedHashAndPassword):
+			return false, err
+		}
+	}
+
+	return false, nil
+}
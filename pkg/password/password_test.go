@@ -0,0 +1,97 @@
+package password
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestCheck(t *testing.T) {
+
+	p := Policy{MinLength: 10}
+
+	tests := []struct {
+		name      string
+		plaintext string
+		want      error
+	}{
+		{"empty", "", ErrTooShort},
+		{"one short", "horse bat", ErrTooShort},
+		{"just long enough", "horse bats", nil},
+		{"length in characters not bytes", "nyumbaßßßß", nil},
+		{"short in characters though long in bytes", "ßßßßßßßßß", ErrTooShort},
+		{"longest bcrypt takes", strings.Repeat("a", MaxLength-1) + "b", nil},
+		{"longer than bcrypt takes", strings.Repeat("a", MaxLength) + "b", ErrTooLong},
+		{"common", "1234567890", ErrCommon},
+		{"common in upper case", "QWERTYUIOP", ErrCommon},
+		{"common in mixed case", "Password123", ErrCommon},
+		{"common with surrounding spaces", "  1234567890 ", ErrCommon},
+		{"uncommon", "correct horse battery", nil},
+	}
+
+	for _, tt := range tests {
+		if err := p.Check(tt.plaintext); !errors.Is(err, tt.want) || (tt.want == nil) != (err == nil) {
+			t.Errorf("%s: Check(%q) = %v, want %v", tt.name, tt.plaintext, err, tt.want)
+		}
+	}
+}
+
+func TestIsCommon(t *testing.T) {
+
+	for _, plaintext := range []string{"123456", "password", "PASSWORD", "PassWord", " qwerty "} {
+		if !IsCommon(plaintext) {
+			t.Errorf("IsCommon(%q) = false, want true", plaintext)
+		}
+	}
+
+	for _, plaintext := range []string{"", "correct horse battery", "# Passwords seen most often in public breach dumps, plus a few local"} {
+		if IsCommon(plaintext) {
+			t.Errorf("IsCommon(%q) = true, want false", plaintext)
+		}
+	}
+}
+
+func TestReused(t *testing.T) {
+
+	var history [][]byte
+
+	for _, plaintext := range []string{"first kodi ya nyumba", "second kodi ya nyumba"} {
+		hash, err := bcrypt.GenerateFromPassword([]byte(plaintext), bcrypt.MinCost)
+		if err != nil {
+			t.Fatalf("GenerateFromPassword: %v", err)
+		}
+		history = append(history, hash)
+	}
+
+	tests := []struct {
+		name      string
+		plaintext string
+		history   [][]byte
+		want      bool
+	}{
+		{"current password", "first kodi ya nyumba", history, true},
+		{"older password", "second kodi ya nyumba", history, true},
+		{"new password", "third kodi ya nyumba", history, false},
+		{"differs in case", "First Kodi Ya Nyumba", history, false},
+		{"no history", "first kodi ya nyumba", nil, false},
+	}
+
+	for _, tt := range tests {
+
+		got, err := Reused(tt.plaintext, tt.history)
+
+		if err != nil {
+			t.Fatalf("%s: Reused: %v", tt.name, err)
+		}
+
+		if got != tt.want {
+			t.Errorf("%s: Reused(%q) = %t, want %t", tt.name, tt.plaintext, got, tt.want)
+		}
+	}
+
+	if _, err := Reused("anything", [][]byte{[]byte("not a hash")}); err == nil {
+		t.Error("Reused with a malformed hash: error = nil, want one")
+	}
+}
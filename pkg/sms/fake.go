@@ -0,0 +1,41 @@
+package sms
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Fake is an in-memory Provider for tests. The zero value is ready to use.
+type Fake struct {
+	mu   sync.Mutex
+	sent []Message
+
+	// Err, when set, is returned by Send; the message is still recorded.
+	Err error
+}
+
+var _ Provider = (*Fake)(nil)
+
+func (f *Fake) Send(ctx context.Context, msg Message) (string, error) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.sent = append(f.sent, msg)
+
+	if f.Err != nil {
+		return "", f.Err
+	}
+
+	return fmt.Sprintf("fake-%d", len(f.sent)), nil
+}
+
+// Messages returns the messages sent so far, oldest first.
+func (f *Fake) Messages() []Message {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]Message(nil), f.sent...)
+}
@@ -0,0 +1,151 @@
+// Package sms sends text messages through a pluggable Provider.
+//
+// HTTPProvider speaks a small JSON protocol: it posts
+//
+//	{"from": "...", "channel": "sms", "to": "+255...", "message": "..."}
+//
+// to the gateway URL with a bearer token, and expects a 2xx answer carrying
+// {"id": "..."}, the gateway's reference for the message. Most gateways sit
+// behind a thin adapter speaking it; cmd/smsd is a local stand-in that logs
+// what it is sent.
+package sms
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type Message struct {
+	// Channel is ChannelSMS, the default, or ChannelWhatsApp.
+	Channel string `json:"channel,omitempty"`
+	To      string `json:"to"`
+	Body    string `json:"message"`
+}
+
+const (
+	ChannelSMS      = "sms"
+	ChannelWhatsApp = "whatsapp"
+)
+
+// Provider delivers a message and returns the provider's id for it.
+type Provider interface {
+	Send(ctx context.Context, msg Message) (string, error)
+}
+
+// Error is returned when the provider refused a message or could not be
+// reached.
+type Error struct {
+	// StatusCode and Body are those of the response, zero when there was
+	// none.
+	StatusCode int
+	Body       string
+
+	Err error
+}
+
+func (e *Error) Error() string {
+
+	if e.StatusCode == 0 {
+		return fmt.Sprintf("sms: %v", e.Err)
+	}
+
+	if e.Body == "" {
+		return fmt.Sprintf("sms: status %d", e.StatusCode)
+	}
+
+	return fmt.Sprintf("sms: status %d: %s", e.StatusCode, e.Body)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Temporary reports whether sending again later might succeed. A 4xx other
+// than 429 means the message itself was refused, say for a bad number.
+func (e *Error) Temporary() bool {
+	return e.StatusCode == 0 || e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+type HTTPConfig struct {
+	URL   string
+	Token string
+
+	// Sender is the name or number messages appear to come from.
+	Sender string
+
+	// Timeout bounds one request. Defaults to 10 seconds.
+	Timeout time.Duration
+}
+
+type HTTPProvider struct {
+	cfg  HTTPConfig
+	http *http.Client
+}
+
+var _ Provider = (*HTTPProvider)(nil)
+
+func NewHTTP(cfg HTTPConfig) *HTTPProvider {
+
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+
+	return &HTTPProvider{
+		cfg:  cfg,
+		http: &http.Client{Timeout: cfg.Timeout},
+	}
+}
+
+// maxErrorBody is how much of an error response is kept in Error.
+const maxErrorBody = 512
+
+func (p *HTTPProvider) Send(ctx context.Context, msg Message) (string, error) {
+
+	body, err := json.Marshal(struct {
+		From string `json:"from"`
+		Message
+	}{p.cfg.Sender, msg})
+
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	if p.cfg.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+p.cfg.Token)
+	}
+
+	resp, err := p.http.Do(req)
+	if err != nil {
+		return "", &Error{Err: err}
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return "", &Error{StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(msg))}
+	}
+
+	var res struct {
+		ID string `json:"id"`
+	}
+
+	// a gateway that accepted the message but says nothing useful still
+	// accepted it
+	_ = json.NewDecoder(resp.Body).Decode(&res)
+
+	return res.ID, nil
+}
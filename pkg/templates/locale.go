@@ -0,0 +1,133 @@
+package templates
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// locale holds how dates and amounts are written in one language.
+type locale struct {
+	months [12]string
+
+	// dateFormat and timeFormat take the day, month name and year, and the
+	// hour and minute, in that order.
+	dateFormat string
+	timeFormat string
+
+	currency string
+}
+
+var locales = map[string]locale{
+	"en": {
+		months: [12]string{
+			"January", "February", "March", "April", "May", "June",
+			"July", "August", "September", "October", "November", "December",
+		},
+		dateFormat: "%d %s %d",
+		timeFormat: "%02d:%02d",
+		currency:   "TZS",
+	},
+	"sw": {
+		months: [12]string{
+			"Januari", "Februari", "Machi", "Aprili", "Mei", "Juni",
+			"Julai", "Agosti", "Septemba", "Oktoba", "Novemba", "Desemba",
+		},
+		dateFormat: "%d %s %d",
+		timeFormat: "saa %02d:%02d",
+		currency:   "TSh",
+	},
+}
+
+func (l locale) funcs() map[string]any {
+	return map[string]any{
+		"date":     l.date,
+		"datetime": l.datetime,
+		"money":    l.money,
+	}
+}
+
+// Date formats t as a day in locale, as templates do, or in the default
+// locale when there are no templates for locale.
+func (s *Set) Date(locale string, t time.Time) string {
+	return s.locale(locale).date(t)
+}
+
+// DateTime formats t as a day and time of day in locale.
+func (s *Set) DateTime(locale string, t time.Time) string {
+	return s.locale(locale).datetime(t)
+}
+
+func (s *Set) locale(name string) locale {
+
+	name = Normalize(name)
+
+	if _, ok := s.pages[name]; !ok {
+		name = s.fallback
+	}
+
+	return locales[name]
+}
+
+// date formats a time.Time, or a string in RFC 3339 or 2006-01-02 form, as a
+// day in l. Anything else is returned as it is.
+func (l locale) date(v any) string {
+
+	t, ok := toTime(v)
+	if !ok {
+		return fmt.Sprint(v)
+	}
+
+	return fmt.Sprintf(l.dateFormat, t.Day(), l.months[t.Month()-1], t.Year())
+}
+
+func (l locale) datetime(v any) string {
+
+	t, ok := toTime(v)
+	if !ok {
+		return fmt.Sprint(v)
+	}
+
+	return l.date(t) + " " + fmt.Sprintf(l.timeFormat, t.Hour(), t.Minute())
+}
+
+// money formats a whole amount with thousands separators, such as
+// "TZS 350,000".
+func (l locale) money(amount int64) string {
+
+	digits := strconv.FormatInt(amount, 10)
+
+	sign := ""
+	if amount < 0 {
+		sign, digits = "-", digits[1:]
+	}
+
+	var b []byte
+
+	for i := range len(digits) {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b = append(b, ',')
+		}
+		b = append(b, digits[i])
+	}
+
+	return l.currency + " " + sign + string(b)
+}
+
+func toTime(v any) (time.Time, bool) {
+
+	switch v := v.(type) {
+
+	case time.Time:
+		return v, true
+
+	case string:
+		for _, layout := range []string{time.RFC3339, time.DateOnly} {
+			if t, err := time.Parse(layout, v); err == nil {
+				return t, true
+			}
+		}
+	}
+
+	return time.Time{}, false
+}
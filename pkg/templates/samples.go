@@ -0,0 +1,70 @@
+package templates
+
+import "time"
+
+// samples is the data each notification is previewed and checked with. It
+// has the fields the callers pass in, so a template that uses anything else
+// fails in New.
+var samples = map[string]any{
+	Activation: map[string]any{
+		"Email": "jane@example.com",
+		"Token": "Q4N6ZJ2KXW7YB3RTP5M8CHD0VA",
+	},
+	PasswordReset: map[string]any{
+		"Email": "jane@example.com",
+		"Token": "Q4N6ZJ2KXW7YB3RTP5M8CHD0VA",
+	},
+	PasswordResetComplete: map[string]any{
+		"Email": "jane@example.com",
+	},
+	Invite: map[string]any{
+		"Email":  "jane@example.com",
+		"Role":   "manager",
+		"Token":  "Q4N6ZJ2KXW7YB3RTP5M8CHD0VA",
+		"Expiry": "2025-03-14T09:30:00+03:00",
+	},
+	Lockout: map[string]any{
+		"Email":       "jane@example.com",
+		"IP":          "196.41.32.7",
+		"LockedUntil": "2025-03-14T09:30:00+03:00",
+	},
+	RentDue: map[string]any{
+		"Name":  "Amina Juma",
+		"House": "Sinza A4",
+		"Date":  time.Date(2025, time.March, 14, 0, 0, 0, 0, time.UTC),
+		"Left":  3,
+	},
+	LeaseExpiry: map[string]any{
+		"Name":  "Amina Juma",
+		"House": "Sinza A4",
+		"Date":  time.Date(2025, time.March, 14, 0, 0, 0, 0, time.UTC),
+		"Left":  7,
+	},
+	PaymentReceipt: map[string]any{
+		"Name":      "Amina Juma",
+		"House":     "Sinza A4",
+		"Amount":    int64(350000),
+		"StartDate": time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC),
+		"EndDate":   time.Date(2025, time.May, 31, 0, 0, 0, 0, time.UTC),
+	},
+	DailyDigest: map[string]any{
+		"Date":    time.Date(2025, time.March, 14, 0, 0, 0, 0, time.UTC),
+		"EosDays": 14,
+		"Organizations": []map[string]any{{
+			"Name": "Sinza Apartments",
+			"Payments": []map[string]any{
+				{"Tenant": "Amina Juma", "House": "Sinza A4", "Amount": int64(350000)},
+			},
+			"PaymentsTotal": int64(350000),
+			"Ending": []map[string]any{
+				{"Name": "Baraka Mushi", "House": "Sinza B2", "Eos": time.Date(2025, time.March, 21, 0, 0, 0, 0, time.UTC)},
+			},
+			"Arrears": []map[string]any{
+				{"Name": "Neema Said", "House": "Sinza A1", "Since": time.Date(2025, time.February, 1, 0, 0, 0, 0, time.UTC), "Days": 41},
+			},
+			"Vacated": []map[string]any{
+				{"House": "Sinza C3", "VacatedAt": time.Date(2025, time.March, 13, 16, 45, 0, 0, time.UTC)},
+			},
+		}},
+	},
+}
@@ -0,0 +1,264 @@
+// Package templates renders notifications in the recipient's language.
+//
+// Every notification has one file per locale under files/<locale>/, named
+// after the notification, defining up to three templates:
+//
+//	{{define "subject"}}...{{end}}  the email subject line
+//	{{define "text"}}...{{end}}     the plain text body, and all of a text message
+//	{{define "html"}}...{{end}}     the HTML body of an email
+//
+// Messages to tenants, which may go out as a text message or as an email,
+// have a subject and text only. The HTML body is escaped as HTML, the others
+// are not. Templates may use date, datetime and money, which format
+// their argument for the template's locale.
+//
+// A locale without a notification, or without the locale asked for at all,
+// falls back to the default locale, which must have every notification.
+package templates
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+	texttemplate "text/template"
+)
+
+// Notification names.
+const (
+	Activation            = "activation"
+	PasswordReset         = "password_reset"
+	PasswordResetComplete = "password_reset_complete"
+	Invite                = "invite"
+	Lockout               = "lockout"
+	RentDue               = "rent_due"
+	LeaseExpiry           = "lease_expiry"
+	PaymentReceipt        = "payment_receipt"
+	DailyDigest           = "daily_digest"
+)
+
+const DefaultLocale = "en"
+
+//go:embed files
+var files embed.FS
+
+var ErrUnknownTemplate = errors.New("templates: unknown template")
+
+// Message is a rendered notification.
+type Message struct {
+	// Locale is the one the message was rendered in, which is the default
+	// locale when the one asked for had no such template.
+	Locale  string `json:"locale"`
+	Subject string `json:"subject,omitempty"`
+	Text    string `json:"text"`
+	HTML    string `json:"html,omitempty"`
+}
+
+type page struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+// Set holds the parsed templates of every locale.
+type Set struct {
+	fallback string
+	pages    map[string]map[string]page
+}
+
+// New parses the templates and renders each with its sample data, so that a
+// broken template is found at startup rather than when it is first sent.
+func New(defaultLocale string) (*Set, error) {
+
+	defaultLocale = Normalize(defaultLocale)
+
+	s := &Set{
+		fallback: defaultLocale,
+		pages:    map[string]map[string]page{},
+	}
+
+	dirs, err := fs.ReadDir(files, "files")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, dir := range dirs {
+
+		locale := dir.Name()
+
+		l, ok := locales[locale]
+		if !ok {
+			return nil, fmt.Errorf("templates: no formats for locale %q", locale)
+		}
+
+		names, err := fs.Glob(files, path.Join("files", locale, "*.tmpl"))
+		if err != nil {
+			return nil, err
+		}
+
+		s.pages[locale] = map[string]page{}
+
+		for _, name := range names {
+
+			text, err := texttemplate.New(path.Base(name)).Option("missingkey=error").Funcs(l.funcs()).ParseFS(files, name)
+			if err != nil {
+				return nil, err
+			}
+
+			html, err := htmltemplate.New(path.Base(name)).Option("missingkey=error").Funcs(l.funcs()).ParseFS(files, name)
+			if err != nil {
+				return nil, err
+			}
+
+			s.pages[locale][strings.TrimSuffix(path.Base(name), ".tmpl")] = page{text: text, html: html}
+		}
+	}
+
+	if _, ok := s.pages[defaultLocale]; !ok {
+		return nil, fmt.Errorf("templates: default locale %q has no templates", defaultLocale)
+	}
+
+	for name := range samples {
+		if _, ok := s.pages[defaultLocale][name]; !ok {
+			return nil, fmt.Errorf("templates: default locale %q has no %s template", defaultLocale, name)
+		}
+	}
+
+	for locale, pages := range s.pages {
+		for name := range pages {
+
+			data, ok := samples[name]
+			if !ok {
+				return nil, fmt.Errorf("templates: no sample data for %s", name)
+			}
+
+			if _, err := s.render(locale, name, data); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return s, nil
+}
+
+// Normalize reduces a language tag such as "sw-TZ" to the locale it is
+// matched against, "sw".
+func Normalize(locale string) string {
+
+	locale = strings.ToLower(strings.TrimSpace(locale))
+
+	if i := strings.IndexAny(locale, "-_"); i >= 0 {
+		locale = locale[:i]
+	}
+
+	return locale
+}
+
+// Default returns the locale used when no other fits.
+func (s *Set) Default() string {
+	return s.fallback
+}
+
+// Locales returns the locales there are templates for, sorted.
+func (s *Set) Locales() []string {
+
+	locales := make([]string, 0, len(s.pages))
+
+	for locale := range s.pages {
+		locales = append(locales, locale)
+	}
+
+	slices.Sort(locales)
+
+	return locales
+}
+
+// Supports reports whether there are templates for locale.
+func (s *Set) Supports(locale string) bool {
+	_, ok := s.pages[Normalize(locale)]
+	return ok
+}
+
+// Names returns the notifications there are templates for, sorted.
+func (s *Set) Names() []string {
+
+	names := make([]string, 0, len(s.pages[s.fallback]))
+
+	for name := range s.pages[s.fallback] {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+
+	return names
+}
+
+// Render renders the named notification in locale, or in the default locale
+// when locale has no template for it.
+func (s *Set) Render(locale, name string, data any) (Message, error) {
+
+	locale = Normalize(locale)
+
+	if _, ok := s.pages[locale][name]; !ok {
+		locale = s.fallback
+	}
+
+	return s.render(locale, name, data)
+}
+
+// Preview renders the named notification in locale with sample data.
+func (s *Set) Preview(locale, name string) (Message, error) {
+	return s.Render(locale, name, samples[name])
+}
+
+func (s *Set) render(locale, name string, data any) (Message, error) {
+
+	p, ok := s.pages[locale][name]
+	if !ok {
+		return Message{}, fmt.Errorf("%w: %s", ErrUnknownTemplate, name)
+	}
+
+	msg := Message{Locale: locale}
+
+	var err error
+
+	if msg.Subject, err = executeText(p.text, "subject", data); err != nil {
+		return Message{}, fmt.Errorf("templates: %s/%s: %w", locale, name, err)
+	}
+
+	if msg.Text, err = executeText(p.text, "text", data); err != nil {
+		return Message{}, fmt.Errorf("templates: %s/%s: %w", locale, name, err)
+	}
+
+	if p.html.Lookup("html") != nil {
+
+		var b bytes.Buffer
+
+		if err := p.html.ExecuteTemplate(&b, "html", data); err != nil {
+			return Message{}, fmt.Errorf("templates: %s/%s: %w", locale, name, err)
+		}
+
+		msg.HTML = strings.TrimSpace(b.String())
+	}
+
+	return msg, nil
+}
+
+func executeText(t *texttemplate.Template, name string, data any) (string, error) {
+
+	if t.Lookup(name) == nil {
+		return "", nil
+	}
+
+	var b bytes.Buffer
+
+	if err := t.ExecuteTemplate(&b, name, data); err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(b.String()), nil
+}
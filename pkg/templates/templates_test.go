@@ -0,0 +1,157 @@
+package templates
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// notifications are every notification sent, and email those sent only by
+// email, which have an HTML body as well.
+var (
+	notifications = []string{
+		Activation, PasswordReset, PasswordResetComplete, Invite, Lockout,
+		RentDue, LeaseExpiry, PaymentReceipt, DailyDigest,
+	}
+	email = map[string]bool{
+		Activation: true, PasswordReset: true, PasswordResetComplete: true,
+		Invite: true, Lockout: true, DailyDigest: true,
+	}
+)
+
+func newTestSet(t *testing.T) *Set {
+
+	s, err := New(DefaultLocale)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	return s
+}
+
+func TestRenderEveryTemplate(t *testing.T) {
+
+	s := newTestSet(t)
+
+	if got := strings.Join(s.Locales(), ","); got != "en,sw" {
+		t.Fatalf("Locales() = %s, want en,sw", got)
+	}
+
+	if got, want := len(s.Names()), len(notifications); got != want {
+		t.Errorf("Names() = %v, want the %d notifications", s.Names(), want)
+	}
+
+	rendered := map[string]Message{}
+
+	for _, locale := range []string{"en", "sw"} {
+		for _, name := range notifications {
+
+			msg, err := s.Preview(locale, name)
+
+			if err != nil {
+				t.Errorf("%s/%s: %v", locale, name, err)
+				continue
+			}
+
+			if msg.Locale != locale {
+				t.Errorf("%s/%s: rendered in %s, want no fallback", locale, name, msg.Locale)
+			}
+
+			if msg.Subject == "" || msg.Text == "" {
+				t.Errorf("%s/%s: subject %q, text %q, want both", locale, name, msg.Subject, msg.Text)
+			}
+
+			if (msg.HTML != "") != email[name] {
+				t.Errorf("%s/%s: html %q, want one only for email", locale, name, msg.HTML)
+			}
+
+			for _, part := range []string{msg.Subject, msg.Text, msg.HTML} {
+				if strings.Contains(part, "<no value>") || strings.Contains(part, "{{") {
+					t.Errorf("%s/%s: unfilled template in %q", locale, name, part)
+				}
+			}
+
+			if other, ok := rendered[name]; ok && other.Text == msg.Text {
+				t.Errorf("%s: en and sw text are the same, want sw translated", name)
+			}
+
+			rendered[name] = msg
+		}
+	}
+}
+
+func TestRenderFormatsForLocale(t *testing.T) {
+
+	s := newTestSet(t)
+
+	tests := []struct {
+		locale string
+		name   string
+		want   []string
+	}{
+		{"en", PaymentReceipt, []string{"Amina Juma", "Sinza A4", "TZS 350,000", "1 March 2025", "31 May 2025"}},
+		{"sw", PaymentReceipt, []string{"Amina Juma", "Sinza A4", "TSh 350,000", "1 Machi 2025", "31 Mei 2025"}},
+		{"en", RentDue, []string{"Amina Juma", "14 March 2025"}},
+		{"sw", RentDue, []string{"Amina Juma", "14 Machi 2025"}},
+	}
+
+	for _, tt := range tests {
+
+		msg, err := s.Preview(tt.locale, tt.name)
+		if err != nil {
+			t.Fatalf("%s/%s: %v", tt.locale, tt.name, err)
+		}
+
+		for _, want := range tt.want {
+			if !strings.Contains(msg.Text, want) {
+				t.Errorf("%s/%s: text %q does not contain %q", tt.locale, tt.name, msg.Text, want)
+			}
+		}
+	}
+}
+
+func TestRenderFallsBack(t *testing.T) {
+
+	s := newTestSet(t)
+
+	for locale, want := range map[string]string{"sw-TZ": "sw", "SW": "sw", "fr": "en", "": "en"} {
+
+		msg, err := s.Preview(locale, RentDue)
+		if err != nil {
+			t.Fatalf("%q: %v", locale, err)
+		}
+
+		if msg.Locale != want {
+			t.Errorf("Preview(%q) rendered in %s, want %s", locale, msg.Locale, want)
+		}
+	}
+
+	if _, err := s.Render("en", "no_such_notification", nil); !errors.Is(err, ErrUnknownTemplate) {
+		t.Errorf("Render of an unknown notification: error = %v, want ErrUnknownTemplate", err)
+	}
+}
+
+func TestRenderEscapesHTMLOnly(t *testing.T) {
+
+	s := newTestSet(t)
+
+	msg, err := s.Render("en", Invite, map[string]any{
+		"Email":  "<b>jane</b>@example.com",
+		"Role":   "manager",
+		"Token":  "Q4N6ZJ2KXW7YB3RTP5M8CHD0VA",
+		"Expiry": time.Date(2025, time.March, 14, 9, 30, 0, 0, time.UTC),
+	})
+
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+
+	if !strings.Contains(msg.HTML, "&lt;b&gt;jane&lt;/b&gt;@example.com") {
+		t.Errorf("html %q, want the email escaped", msg.HTML)
+	}
+
+	if !strings.Contains(msg.Text, "<b>jane</b>@example.com") {
+		t.Errorf("text %q, want the email as given", msg.Text)
+	}
+}
@@ -0,0 +1,146 @@
+package totp
+
+import (
+	"testing"
+	"time"
+)
+
+// rfcSecret is the RFC 6238 SHA-1 test key, the ASCII string
+// "12345678901234567890", base32 encoded.
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// The RFC 6238 appendix B SHA-1 vectors, cut to the last 6 of their 8 digits
+// as dynamic truncation does.
+var rfcVectors = []struct {
+	unix int64
+	code string
+}{
+	{59, "287082"},
+	{1111111109, "081804"},
+	{1111111111, "050471"},
+	{1234567890, "005924"},
+	{2000000000, "279037"},
+	{20000000000, "353130"},
+}
+
+func TestCode(t *testing.T) {
+
+	for _, v := range rfcVectors {
+
+		got, err := Code(rfcSecret, time.Unix(v.unix, 0))
+
+		if err != nil {
+			t.Fatalf("Code at %d: %v", v.unix, err)
+		}
+
+		if got != v.code {
+			t.Errorf("Code at %d = %s, want %s", v.unix, got, v.code)
+		}
+	}
+}
+
+func TestCodeInvalidSecret(t *testing.T) {
+
+	for _, secret := range []string{"", "not base32!"} {
+		if _, err := Code(secret, time.Unix(59, 0)); err != ErrInvalidSecret {
+			t.Errorf("Code(%q) error = %v, want ErrInvalidSecret", secret, err)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+
+	for _, v := range rfcVectors {
+
+		at := time.Unix(v.unix, 0)
+
+		step, ok := Validate(rfcSecret, v.code, at)
+
+		if !ok || step != Step(at) {
+			t.Errorf("Validate at %d = %d, %t, want %d, true", v.unix, step, ok, Step(at))
+		}
+	}
+}
+
+func TestValidateSkew(t *testing.T) {
+
+	at := time.Unix(1111111111, 0)
+	code := rfcVectors[2].code
+	issued := Step(at)
+
+	tests := []struct {
+		name  string
+		steps int64
+		ok    bool
+	}{
+		{"two steps early", -2, false},
+		{"one step early", -1, true},
+		{"same step", 0, true},
+		{"one step late", 1, true},
+		{"two steps late", 2, false},
+	}
+
+	for _, tt := range tests {
+
+		step, ok := Validate(rfcSecret, code, at.Add(time.Duration(tt.steps)*Period))
+
+		if ok != tt.ok {
+			t.Errorf("%s: ok = %t, want %t", tt.name, ok, tt.ok)
+			continue
+		}
+
+		// the step returned is the one the code was issued for, not the
+		// current one
+		if ok && step != issued {
+			t.Errorf("%s: step = %d, want %d", tt.name, step, issued)
+		}
+	}
+}
+
+// TestValidateReplay checks that a code accepted once matches the same step
+// when sent again within the skew window, so a caller that records the last
+// step used and refuses any step not after it rejects the replay.
+func TestValidateReplay(t *testing.T) {
+
+	at := time.Unix(1111111111, 0)
+	code := rfcVectors[2].code
+
+	first, ok := Validate(rfcSecret, code, at)
+	if !ok {
+		t.Fatal("first use rejected")
+	}
+
+	again, ok := Validate(rfcSecret, code, at.Add(Period))
+	if !ok {
+		t.Fatal("code rejected one step later")
+	}
+
+	if again > first {
+		t.Errorf("replayed code matched step %d, after the step %d already used", again, first)
+	}
+
+	next, err := Code(rfcSecret, at.Add(Period))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	step, ok := Validate(rfcSecret, next, at.Add(Period))
+	if !ok || step <= first {
+		t.Errorf("next code matched step %d, %t, want a step after %d", step, ok, first)
+	}
+}
+
+func TestValidateMalformed(t *testing.T) {
+
+	at := time.Unix(59, 0)
+
+	for _, code := range []string{"", "28708", "2870820", "abcdef"} {
+		if _, ok := Validate(rfcSecret, code, at); ok {
+			t.Errorf("Validate(%q) accepted", code)
+		}
+	}
+
+	if _, ok := Validate("", "287082", at); ok {
+		t.Error("Validate with an empty secret accepted")
+	}
+}
@@ -0,0 +1,173 @@
+// Package xlsx writes a workbook of one sheet a row at a time, so that an
+// export of any size streams straight to its reader. Strings are written
+// inline rather than to a shared string table, which would have to be held
+// until the end, and no styles are written.
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// ContentType is the media type of a workbook.
+const ContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+const header = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n"
+
+var parts = []struct {
+	name, content string
+}{
+	{"[Content_Types].xml", `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`},
+	{"_rels/.rels", `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`},
+	{"xl/_rels/workbook.xml.rels", `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`},
+}
+
+// Writer writes a workbook. Close must be called once every row is written.
+type Writer struct {
+	zw    *zip.Writer
+	sheet io.Writer
+	row   int
+	buf   bytes.Buffer
+}
+
+// NewWriter starts a workbook on w with one sheet named sheet, which must be
+// a valid sheet name: at most 31 characters, none of them : \ / ? * [ or ].
+func NewWriter(w io.Writer, sheet string) (*Writer, error) {
+
+	x := &Writer{zw: zip.NewWriter(w)}
+
+	for _, p := range parts {
+		if err := x.part(p.name, p.content); err != nil {
+			return nil, err
+		}
+	}
+
+	var name bytes.Buffer
+
+	if err := xml.EscapeText(&name, []byte(sheet)); err != nil {
+		return nil, err
+	}
+
+	workbook := `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="` + name.String() + `" sheetId="1" r:id="rId1"/></sheets>` +
+		`</workbook>`
+
+	if err := x.part("xl/workbook.xml", workbook); err != nil {
+		return nil, err
+	}
+
+	var err error
+
+	if x.sheet, err = x.zw.Create("xl/worksheets/sheet1.xml"); err != nil {
+		return nil, err
+	}
+
+	if _, err := io.WriteString(x.sheet, header+`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`); err != nil {
+		return nil, err
+	}
+
+	return x, nil
+}
+
+func (x *Writer) part(name, content string) error {
+
+	f, err := x.zw.Create(name)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(f, header+content)
+
+	return err
+}
+
+// WriteRow writes the next row. A cell may be a string, a bool, any integer
+// or float type, or nil for an empty cell; anything else is written as the
+// string fmt.Sprint gives.
+func (x *Writer) WriteRow(cells ...any) error {
+
+	x.row++
+	x.buf.Reset()
+
+	fmt.Fprintf(&x.buf, `<row r="%d">`, x.row)
+
+	for i, v := range cells {
+
+		ref := column(i) + strconv.Itoa(x.row)
+
+		switch v := v.(type) {
+
+		case nil:
+			continue
+
+		case bool:
+			b := "0"
+			if v {
+				b = "1"
+			}
+			fmt.Fprintf(&x.buf, `<c r="%s" t="b"><v>%s</v></c>`, ref, b)
+
+		case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+			fmt.Fprintf(&x.buf, `<c r="%s"><v>%v</v></c>`, ref, v)
+
+		default:
+			s, ok := v.(string)
+			if !ok {
+				s = fmt.Sprint(v)
+			}
+			fmt.Fprintf(&x.buf, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">`, ref)
+			if err := xml.EscapeText(&x.buf, []byte(s)); err != nil {
+				return err
+			}
+			x.buf.WriteString(`</t></is></c>`)
+		}
+	}
+
+	x.buf.WriteString(`</row>`)
+
+	_, err := x.sheet.Write(x.buf.Bytes())
+
+	return err
+}
+
+// Flush sends what has been written so far on to the underlying writer, as
+// far as compression allows.
+func (x *Writer) Flush() error {
+	return x.zw.Flush()
+}
+
+// Close ends the sheet and the workbook. It does not close the underlying
+// writer.
+func (x *Writer) Close() error {
+
+	if _, err := io.WriteString(x.sheet, `</sheetData></worksheet>`); err != nil {
+		return err
+	}
+
+	return x.zw.Close()
+}
+
+// column returns the letters of the zero based column i: A, B, ..., Z, AA.
+func column(i int) string {
+
+	var b []byte
+
+	for i++; i > 0; i = (i - 1) / 26 {
+		b = append([]byte{byte('A' + (i-1)%26)}, b...)
+	}
+
+	return string(b)
+}
@@ -0,0 +1,102 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+)
+
+// readPart returns the content of the named part of the workbook in b.
+func readPart(t *testing.T, b []byte, name string) string {
+
+	zr, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		t.Fatalf("opening workbook: %v", err)
+	}
+
+	f, err := zr.Open(name)
+	if err != nil {
+		t.Fatalf("opening %s: %v", name, err)
+	}
+	defer f.Close()
+
+	content, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatalf("reading %s: %v", name, err)
+	}
+
+	// every part has to be well formed for a spreadsheet to open it
+	d := xml.NewDecoder(bytes.NewReader(content))
+	for {
+		if _, err := d.Token(); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("%s is not well formed: %v", name, err)
+		}
+	}
+
+	return string(content)
+}
+
+func TestWriterRoundTrip(t *testing.T) {
+
+	var buf bytes.Buffer
+
+	w, err := NewWriter(&buf, "Tenants & <Houses>")
+	if err != nil {
+		t.Fatalf("NewWriter: %v", err)
+	}
+
+	rows := [][]any{
+		{"name", "phone", "rent", "active", "rate"},
+		{"Asha <A&B>", "+255712345678", int32(150000), true, 0.5},
+		{"=1+1", nil, int64(-5), false, nil},
+	}
+
+	for _, row := range rows {
+		if err := w.WriteRow(row...); err != nil {
+			t.Fatalf("WriteRow: %v", err)
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/_rels/workbook.xml.rels"} {
+		readPart(t, buf.Bytes(), name)
+	}
+
+	if workbook := readPart(t, buf.Bytes(), "xl/workbook.xml"); !strings.Contains(workbook, `name="Tenants &amp; &lt;Houses&gt;"`) {
+		t.Errorf("workbook.xml = %s, want the sheet name escaped", workbook)
+	}
+
+	sheet := readPart(t, buf.Bytes(), "xl/worksheets/sheet1.xml")
+
+	for _, want := range []string{
+		`<row r="1"><c r="A1" t="inlineStr"><is><t xml:space="preserve">name</t></is></c>`,
+		`<c r="A2" t="inlineStr"><is><t xml:space="preserve">Asha &lt;A&amp;B&gt;</t></is></c>`,
+		`<c r="B2" t="inlineStr"><is><t xml:space="preserve">+255712345678</t></is></c>`,
+		`<c r="C2"><v>150000</v></c>`,
+		`<c r="D2" t="b"><v>1</v></c>`,
+		`<c r="E2"><v>0.5</v></c>`,
+		`<row r="3"><c r="A3" t="inlineStr"><is><t xml:space="preserve">=1+1</t></is></c><c r="C3"><v>-5</v></c><c r="D3" t="b"><v>0</v></c></row>`,
+		`</sheetData></worksheet>`,
+	} {
+		if !strings.Contains(sheet, want) {
+			t.Errorf("sheet1.xml is missing %s", want)
+		}
+	}
+}
+
+func TestColumn(t *testing.T) {
+
+	for i, want := range map[int]string{0: "A", 25: "Z", 26: "AA", 51: "AZ", 52: "BA", 701: "ZZ", 702: "AAA"} {
+		if got := column(i); got != want {
+			t.Errorf("column(%d) = %q, want %q", i, got, want)
+		}
+	}
+}